@@ -0,0 +1,133 @@
+package kdbush
+
+import (
+	"fmt"
+	"math"
+)
+
+// sum, min and max of values of every subtree, nodes are numbered as in binary heap: root is 1, children of k are 2k and 2k+1
+type aggregate struct {
+	values        []float64
+	sum, min, max []float64
+}
+
+// Registers per-point values as float64 auxiliary array under the name and precomputes sums, minimums and maximums of every subtree,
+// so SumRange, MinRange and MaxRange don't visit points of subtrees fully covered by the query box.
+// Aggregates are not saved with the index, call SetAggregated again after Load.
+func (bush *KDBush) SetAggregated(name string, values []float64) error {
+	if err := SetAux(bush, name, values); err != nil {
+		return err
+	}
+	a := &aggregate{values: values}
+	if bush.size() > 0 {
+		a.fill(bush, bush.rootNode(), 1)
+	}
+	if bush.aggs == nil {
+		bush.aggs = map[string]*aggregate{}
+	}
+	bush.aggs[name] = a
+	return nil
+}
+
+func (a *aggregate) fill(bush *KDBush, n treeNode, id int) (sum, min, max float64) {
+	sum, min, max = 0, math.Inf(1), math.Inf(-1)
+	add := func(s, lo, hi float64) {
+		sum += s
+		min, max = math.Min(min, lo), math.Max(max, hi)
+	}
+	if n.right-n.left <= bush.NodeSize {
+		for i := n.left; i <= n.right; i++ {
+			v := a.values[bush.idx(i)]
+			add(v, v, v)
+		}
+	} else {
+		m := floor(float64(n.left+n.right) / 2.0)
+		v := a.values[bush.idx(m)]
+		add(v, v, v)
+		l, r := bush.children(n, m)
+		if l.left <= l.right {
+			add(a.fill(bush, l, 2*id))
+		}
+		if r.left <= r.right {
+			add(a.fill(bush, r, 2*id+1))
+		}
+	}
+	for len(a.sum) <= id {
+		a.sum = append(a.sum, 0)
+		a.min = append(a.min, math.Inf(1))
+		a.max = append(a.max, math.Inf(-1))
+	}
+	a.sum[id], a.min[id], a.max[id] = sum, min, max
+	return sum, min, max
+}
+
+// Sum of values registered by SetAggregated of all points within the bounding box
+func (bush *KDBush) SumRange(name string, minX, minY, maxX, maxY float64) (float64, error) {
+	sum, _, _, err := bush.aggregateRange(name, minX, minY, maxX, maxY)
+	return sum, err
+}
+
+// Minimum of values registered by SetAggregated of all points within the bounding box, +Inf if there are no points
+func (bush *KDBush) MinRange(name string, minX, minY, maxX, maxY float64) (float64, error) {
+	_, min, _, err := bush.aggregateRange(name, minX, minY, maxX, maxY)
+	return min, err
+}
+
+// Maximum of values registered by SetAggregated of all points within the bounding box, -Inf if there are no points
+func (bush *KDBush) MaxRange(name string, minX, minY, maxX, maxY float64) (float64, error) {
+	_, _, max, err := bush.aggregateRange(name, minX, minY, maxX, maxY)
+	return max, err
+}
+
+func (bush *KDBush) aggregateRange(name string, minX, minY, maxX, maxY float64) (sum, min, max float64, err error) {
+	sum, min, max = 0, math.Inf(1), math.Inf(-1)
+	a, ok := bush.aggs[name]
+	if !ok {
+		return sum, min, max, fmt.Errorf("kdbush: no aggregated values %q", name)
+	}
+	if bush.size() == 0 {
+		return sum, min, max, nil
+	}
+	addPoint := func(i int) {
+		x, y := bush.x(i), bush.y(i)
+		if x >= minX && x <= maxX && y >= minY && y <= maxY && !bush.removedAt(i) {
+			v := a.values[bush.idx(i)]
+			sum += v
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+
+	type item struct {
+		node treeNode
+		id   int
+	}
+	stack := []item{{bush.rootNode(), 1}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		n := it.node
+		stack = stack[:len(stack)-1]
+
+		if n.right < n.left || n.minX > maxX || n.maxX < minX || n.minY > maxY || n.maxY < minY {
+			continue
+		}
+		//aggregates include removed points, so with tombstones covered subtrees are scanned
+		if bush.removedCount == 0 && n.minX >= minX && n.maxX <= maxX && n.minY >= minY && n.maxY <= maxY {
+			sum += a.sum[it.id]
+			min, max = math.Min(min, a.min[it.id]), math.Max(max, a.max[it.id])
+			continue
+		}
+
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				addPoint(i)
+			}
+			continue
+		}
+
+		m := floor(float64(n.left+n.right) / 2.0)
+		addPoint(m)
+		l, r := bush.children(n, m)
+		stack = append(stack, item{l, 2 * it.id}, item{r, 2*it.id + 1})
+	}
+	return sum, min, max, nil
+}
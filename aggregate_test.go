@@ -0,0 +1,59 @@
+package kdbush
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_SumRange(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	points := make([]Point, 10000)
+	values := make([]float64, len(points))
+	for i := range points {
+		points[i] = &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 1000}
+		values[i] = rnd.Float64()*200 - 100
+	}
+	bush := NewBush(points, 16)
+	assert.NoError(t, bush.SetAggregated("temperature", values))
+
+	check := func(minX, minY, maxX, maxY float64) {
+		sum, min, max := 0.0, math.Inf(1), math.Inf(-1)
+		for _, idx := range bush.Range(minX, minY, maxX, maxY) {
+			sum += values[idx]
+			min, max = math.Min(min, values[idx]), math.Max(max, values[idx])
+		}
+		s, err := bush.SumRange("temperature", minX, minY, maxX, maxY)
+		assert.NoError(t, err)
+		assert.InDelta(t, sum, s, 1e-6)
+		m, _ := bush.MinRange("temperature", minX, minY, maxX, maxY)
+		assert.Equal(t, min, m)
+		m, _ = bush.MaxRange("temperature", minX, minY, maxX, maxY)
+		assert.Equal(t, max, m)
+	}
+	check(100, 150, 700, 620)
+	check(-1, -1, 1001, 1001)
+	check(2000, 2000, 3000, 3000)
+
+	bush.RemoveRange(0, 0, 500, 500)
+	check(100, 150, 700, 620)
+
+	_, err := bush.SumRange("humidity", 0, 0, 1, 1)
+	assert.Error(t, err)
+	assert.Error(t, bush.SetAggregated("short", values[1:]))
+}
+
+func BenchmarkSumRange(b *testing.B) {
+	bush := benchmarkBush()
+	values := make([]float64, len(bush.Idxs))
+	for i := range values {
+		values[i] = float64(i % 100)
+	}
+	bush.SetAggregated("v", values)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bush.SumRange("v", 100, 100, 900, 900)
+	}
+}
@@ -0,0 +1,61 @@
+package kdbush
+
+import (
+	"fmt"
+)
+
+// Read only view of attributes, parallel to the original points input slice of an index.
+// Keeps attributes consistent with query results when indexes are rebuilt from subsets or merged inputs.
+type AttrView[T any] struct {
+	attrs []T
+}
+
+// Creates view of attributes, attrs[i] belongs to the point i of the original points input slice
+func JoinAttributes[T any](attrs []T) *AttrView[T] {
+	return &AttrView[T]{attrs: attrs}
+}
+
+// Number of attributes
+func (v *AttrView[T]) Len() int {
+	return len(v.attrs)
+}
+
+// Returns attribute of the point with original index idx, false if idx is out of range
+func (v *AttrView[T]) At(idx int) (T, bool) {
+	if idx < 0 || idx >= len(v.attrs) {
+		var zero T
+		return zero, false
+	}
+	return v.attrs[idx], true
+}
+
+// Returns attributes for the query result, in the same order.
+// Returns error if any index is out of range, e.g. the view belongs to another index.
+func (v *AttrView[T]) ForResult(idxs []int) ([]T, error) {
+	result := make([]T, len(idxs))
+	for i, idx := range idxs {
+		a, ok := v.At(idx)
+		if !ok {
+			return nil, fmt.Errorf("kdbush: index %d is out of range of %d attributes", idx, len(v.attrs))
+		}
+		result[i] = a
+	}
+	return result, nil
+}
+
+// Returns view for an index built from a subset of the points: point i of the subset is the point from[i] of this view.
+func (v *AttrView[T]) Remap(from []int) (*AttrView[T], error) {
+	attrs, err := v.ForResult(from)
+	if err != nil {
+		return nil, err
+	}
+	return &AttrView[T]{attrs: attrs}, nil
+}
+
+// Returns view for an index built from points of this view followed by points of the other one
+func (v *AttrView[T]) Merge(other *AttrView[T]) *AttrView[T] {
+	attrs := make([]T, 0, len(v.attrs)+len(other.attrs))
+	attrs = append(attrs, v.attrs...)
+	attrs = append(attrs, other.attrs...)
+	return &AttrView[T]{attrs: attrs}
+}
@@ -0,0 +1,38 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinAttributes(t *testing.T) {
+	points := []Point{&SimplePoint{X: 0, Y: 0}, &SimplePoint{X: 5, Y: 5}, &SimplePoint{X: 10, Y: 10}}
+	names := JoinAttributes([]string{"a", "b", "c"})
+	bush := NewBush(points, 10)
+
+	result, err := names.ForResult(bush.Range(4, 4, 20, 20))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"b", "c"}, result)
+
+	_, err = names.ForResult([]int{1, 3})
+	assert.Error(t, err)
+	_, ok := names.At(-1)
+	assert.False(t, ok)
+
+	//index of a subset
+	subset, err := names.Remap([]int{2, 0})
+	assert.NoError(t, err)
+	sub := NewBush([]Point{points[2], points[0]}, 10)
+	result, err = subset.ForResult(sub.Range(8, 8, 20, 20))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, result)
+
+	//index of merged inputs
+	merged := names.Merge(JoinAttributes([]string{"d"}))
+	assert.Equal(t, 4, merged.Len())
+	all := NewBush(append(points, &SimplePoint{X: 20, Y: 20}), 10)
+	result, err = merged.ForResult(all.Range(15, 15, 25, 25))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, result)
+}
@@ -0,0 +1,123 @@
+package kdbush
+
+import (
+	"fmt"
+	sorting "sort"
+)
+
+// Types of values, that could be stored in auxiliary arrays
+type AuxType interface {
+	float64 | float32 | int64 | int32 | uint32 | uint8
+}
+
+// type tags of auxiliary arrays in serialized index
+const (
+	auxFloat64 uint8 = iota + 1
+	auxFloat32
+	auxInt64
+	auxInt32
+	auxUint32
+	auxUint8
+)
+
+// Registers auxiliary per-point array (weights, masks, timestamps, etc.) under the name.
+// Values are parallel to the original points input slice and are saved and loaded together with the index.
+// Registering array with the same name replaces the previous one.
+func SetAux[T AuxType](bush *KDBush, name string, values []T) error {
+	if bush.frozen {
+		return ErrFrozen
+	}
+	if len(values) != bush.originalCount() {
+		return fmt.Errorf("kdbush: aux array %q has %d values, expected %d", name, len(values), bush.originalCount())
+	}
+	if bush.aux == nil {
+		bush.aux = map[string]interface{}{}
+	}
+	bush.aux[name] = values
+	return nil
+}
+
+// Returns auxiliary array registered under the name.
+// Returns false if there is no array with such name or it has another type.
+func GetAux[T AuxType](bush *KDBush, name string) ([]T, bool) {
+	values, ok := bush.aux[name].([]T)
+	return values, ok
+}
+
+// Names of all registered auxiliary arrays, sorted
+func (bush *KDBush) AuxNames() []string {
+	names := make([]string, 0, len(bush.aux))
+	for name := range bush.aux {
+		names = append(names, name)
+	}
+	sorting.Strings(names)
+	return names
+}
+
+// number of points in the original input, aux arrays are parallel to it
+func (bush *KDBush) originalCount() int {
+	if bush.input > 0 {
+		return bush.input
+	}
+	return bush.size()
+}
+
+func auxLen(values interface{}) int {
+	switch v := values.(type) {
+	case []float64:
+		return len(v)
+	case []float32:
+		return len(v)
+	case []int64:
+		return len(v)
+	case []int32:
+		return len(v)
+	case []uint32:
+		return len(v)
+	case []uint8:
+		return len(v)
+	}
+	return -1
+}
+
+// Name of float64 auxiliary array with Z values (elevation), used by WithZRange query option
+const ZAux = "z"
+
+// Sets Z values (elevation) of the points, parallel to the original points input slice.
+// Z is not used to partition the tree, but queries could filter by it with WithZRange.
+func (bush *KDBush) SetZ(z []float64) error {
+	return SetAux(bush, ZAux, z)
+}
+
+// Name of uint32 auxiliary array with generations of the points, used by AsOfGeneration and InGeneration query options
+const GenerationAux = "generation"
+
+// Tags points with original indexes from..to-1 with the generation number, e.g. a batch of backfill.
+// Points, that were never tagged, are generation 0.
+func (bush *KDBush) TagGeneration(from, to int, gen uint32) error {
+	if bush.frozen {
+		return ErrFrozen
+	}
+	if from < 0 || to > bush.originalCount() || from > to {
+		return fmt.Errorf("kdbush: invalid generation range %d..%d", from, to)
+	}
+	gens, ok := GetAux[uint32](bush, GenerationAux)
+	if !ok {
+		gens = make([]uint32, bush.originalCount())
+		if err := SetAux(bush, GenerationAux, gens); err != nil {
+			return err
+		}
+	}
+	for i := from; i < to; i++ {
+		gens[i] = gen
+	}
+	return nil
+}
+
+// Name of int64 auxiliary array with group ids of the points (brand, category, owner), used by KNNDistinctGroups
+const GroupAux = "group"
+
+// Sets group ids of the points, parallel to the original points input slice
+func (bush *KDBush) SetGroups(groups []int64) error {
+	return SetAux(bush, GroupAux, groups)
+}
@@ -0,0 +1,28 @@
+package kdbush
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Runs Within for every query with its own radius, queries are processed in parallel.
+// Returns results parallel to queries. Panics if queries and radii have different length.
+func (bush *KDBush) WithinBatchVar(queries []Point, radii []float64) [][]int {
+	if len(queries) != len(radii) {
+		panic("kdbush: WithinBatchVar needs a radius for every query")
+	}
+	results := make([][]int, len(queries))
+	workers := iMin(runtime.GOMAXPROCS(0), len(queries))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for q := w; q < len(queries); q += workers {
+				results[q] = bush.Within(queries[q], radii[q])
+			}
+		}(w)
+	}
+	wg.Wait()
+	return results
+}
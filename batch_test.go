@@ -0,0 +1,26 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_WithinBatchVar(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	queries := []Point{}
+	radii := []float64{}
+	for i := 0; i < 50; i++ {
+		queries = append(queries, &SimplePoint{X: float64(i * 2), Y: float64(100 - i*2)})
+		radii = append(radii, float64(i%7)*5)
+	}
+
+	results := bush.WithinBatchVar(queries, radii)
+	assert.Len(t, results, len(queries))
+	for i, q := range queries {
+		assert.Equal(t, bush.Within(q, radii[i]), results[i])
+	}
+
+	assert.Empty(t, bush.WithinBatchVar(nil, nil))
+	assert.Panics(t, func() { bush.WithinBatchVar(queries, radii[:1]) })
+}
@@ -0,0 +1,80 @@
+//go:build rtreego
+
+package kdbush
+
+// Comparison with rtreego on the same dataset and queries as other benchmarks.
+// rtreego is not a dependency of the package, run with:
+//
+//	go get github.com/dhconnelly/rtreego && go test -tags rtreego -run NONE -bench RTreeGo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/dhconnelly/rtreego"
+)
+
+type rtreePoint struct {
+	idx  int
+	x, y float64
+}
+
+func (p rtreePoint) Bounds() rtreego.Rect {
+	return rtreego.Point{p.x, p.y}.ToRect(0)
+}
+
+func rtreeObjects() []rtreego.Spatial {
+	points := benchmarkPoints()
+	objs := make([]rtreego.Spatial, len(points))
+	for i, p := range points {
+		x, y := p.Coordinates()
+		objs[i] = rtreePoint{i, x, y}
+	}
+	return objs
+}
+
+func BenchmarkRTreeGo_Build(b *testing.B) {
+	objs := rtreeObjects()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rtreego.NewTree(2, 25, 50, objs...)
+	}
+}
+
+func BenchmarkRTreeGo_Range(b *testing.B) {
+	tree := rtreego.NewTree(2, 25, 50, rtreeObjects()...)
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		box, _ := rtreego.NewRect(rtreego.Point{x, y}, []float64{10, 10})
+		tree.SearchIntersect(box)
+	}
+}
+
+func BenchmarkRTreeGo_Within(b *testing.B) {
+	tree := rtreego.NewTree(2, 25, 50, rtreeObjects()...)
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		//rtreego has no radius search: box search filtered by distance
+		box, _ := rtreego.NewRect(rtreego.Point{x - 10, y - 10}, []float64{20, 20})
+		result := []int{}
+		for _, s := range tree.SearchIntersect(box) {
+			p := s.(rtreePoint)
+			if sqrtDist(p.x, p.y, x, y) <= 10*10 {
+				result = append(result, p.idx)
+			}
+		}
+	}
+}
+
+func BenchmarkRTreeGo_Nearest(b *testing.B) {
+	tree := rtreego.NewTree(2, 25, 50, rtreeObjects()...)
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.NearestNeighbors(10, rtreego.Point{rnd.Float64() * benchExtent, rnd.Float64() * benchExtent})
+	}
+}
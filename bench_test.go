@@ -0,0 +1,261 @@
+package kdbush
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Benchmark dataset parameters, so regressions could be reproduced exactly:
+//
+//	go test -run NONE -bench . -args -points 1000000 -distribution clustered -seed 7 -nodesize 32
+var (
+	benchPoints       = flag.Int("points", 100000, "number of points in benchmark dataset")
+	benchDistribution = flag.String("distribution", "uniform", "distribution of benchmark points: uniform, normal, clustered or grid")
+	benchSeed         = flag.Int64("seed", 1, "seed of benchmark dataset")
+	benchNodeSize     = flag.Int("nodesize", 64, "node size of benchmark index")
+	benchHilbert      = flag.Bool("hilbert", false, "order leaves of benchmark index along Hilbert curve")
+)
+
+// benchmark points are within [0, benchExtent] square
+const benchExtent = 1000
+
+// generates deterministic dataset for the flags
+func benchmarkPoints() []Point {
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	n := *benchPoints
+	points := make([]Point, n)
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(benchExtent, v)) }
+
+	var centers [][2]float64
+	if *benchDistribution == "clustered" {
+		for i := 0; i < 50; i++ {
+			centers = append(centers, [2]float64{rnd.Float64() * benchExtent, rnd.Float64() * benchExtent})
+		}
+	}
+	side := int(math.Ceil(math.Sqrt(float64(n))))
+
+	for i := range points {
+		var x, y float64
+		switch *benchDistribution {
+		case "uniform":
+			x, y = rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		case "normal":
+			x, y = clamp(benchExtent/2+rnd.NormFloat64()*benchExtent/8), clamp(benchExtent/2+rnd.NormFloat64()*benchExtent/8)
+		case "clustered":
+			c := centers[rnd.Intn(len(centers))]
+			x, y = clamp(c[0]+rnd.NormFloat64()*10), clamp(c[1]+rnd.NormFloat64()*10)
+		case "grid":
+			x, y = float64(i%side)*benchExtent/float64(side), float64(i/side)*benchExtent/float64(side)
+		default:
+			panic(fmt.Sprintf("unknown distribution %q", *benchDistribution))
+		}
+		points[i] = &SimplePoint{X: x, Y: y}
+	}
+	return points
+}
+
+func benchmarkBush() *KDBush {
+	if *benchHilbert {
+		return NewBushHilbert(benchmarkPoints(), *benchNodeSize)
+	}
+	return NewBush(benchmarkPoints(), *benchNodeSize)
+}
+
+func BenchmarkKDBush_Build(b *testing.B) {
+	points := benchmarkPoints()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewBush(points, *benchNodeSize)
+	}
+}
+
+func BenchmarkKDBush_Range(b *testing.B) {
+	bush := benchmarkBush()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		bush.Range(x, y, x+10, y+10)
+	}
+}
+
+func BenchmarkKDBush_RangeAppend(b *testing.B) {
+	bush := benchmarkBush()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	var result []int
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		result = bush.RangeAppend(result[:0], x, y, x+10, y+10)
+	}
+}
+
+func BenchmarkKDBush_Within(b *testing.B) {
+	bush := benchmarkBush()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bush.Within(&SimplePoint{X: rnd.Float64() * benchExtent, Y: rnd.Float64() * benchExtent}, 10)
+	}
+}
+
+func BenchmarkKDBush_Nearest(b *testing.B) {
+	bush := benchmarkBush()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bush.Nearest(&SimplePoint{X: rnd.Float64() * benchExtent, Y: rnd.Float64() * benchExtent}, 10, 0)
+	}
+}
+
+// concurrent queries of the shared index, b.N queries are split between goroutines,
+// queries/s metric shows how throughput scales with the number of goroutines
+func benchmarkConcurrent(b *testing.B, query func(rnd *rand.Rand)) {
+	for _, goroutines := range []int{1, 2, 4, 8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("goroutines=%d", goroutines), func(b *testing.B) {
+			var wg sync.WaitGroup
+			start := time.Now()
+			for g := 0; g < goroutines; g++ {
+				n := b.N / goroutines
+				if g < b.N%goroutines {
+					n++
+				}
+				wg.Add(1)
+				go func(rnd *rand.Rand, n int) {
+					defer wg.Done()
+					for i := 0; i < n; i++ {
+						query(rnd)
+					}
+				}(rand.New(rand.NewSource(*benchSeed+int64(g))), n)
+			}
+			wg.Wait()
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "queries/s")
+		})
+	}
+}
+
+func BenchmarkKDBush_ConcurrentRange(b *testing.B) {
+	bush := benchmarkBush()
+	b.ResetTimer()
+	benchmarkConcurrent(b, func(rnd *rand.Rand) {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		bush.Range(x, y, x+10, y+10)
+	})
+}
+
+func BenchmarkKDBush_ConcurrentWithin(b *testing.B) {
+	bush := benchmarkBush()
+	b.ResetTimer()
+	benchmarkConcurrent(b, func(rnd *rand.Rand) {
+		bush.Within(&SimplePoint{X: rnd.Float64() * benchExtent, Y: rnd.Float64() * benchExtent}, 10)
+	})
+}
+
+func BenchmarkKDBush_ConcurrentNearest(b *testing.B) {
+	bush := benchmarkBush()
+	b.ResetTimer()
+	benchmarkConcurrent(b, func(rnd *rand.Rand) {
+		bush.Nearest(&SimplePoint{X: rnd.Float64() * benchExtent, Y: rnd.Float64() * benchExtent}, 10, 0)
+	})
+}
+
+// queries through Live, while it's rebuilt in background
+func BenchmarkLive_ConcurrentRange(b *testing.B) {
+	points := benchmarkPoints()
+	live := NewLive(NewBush(points, *benchNodeSize))
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				live.Rebuild(func() (*KDBush, error) { return NewBush(points[:len(points)/10], *benchNodeSize), nil })
+			}
+		}
+	}()
+	b.ResetTimer()
+	benchmarkConcurrent(b, func(rnd *rand.Rand) {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		live.Index().Range(x, y, x+10, y+10)
+	})
+}
+
+// brute force baselines: linear scans of the same dataset and queries
+
+func BenchmarkBruteForce_Range(b *testing.B) {
+	points := benchmarkPoints()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		result := []int{}
+		for idx, p := range points {
+			px, py := p.Coordinates()
+			if px >= x && px <= x+10 && py >= y && py <= y+10 {
+				result = append(result, idx)
+			}
+		}
+	}
+}
+
+func BenchmarkBruteForce_Within(b *testing.B) {
+	points := benchmarkPoints()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		result := []int{}
+		for idx, p := range points {
+			px, py := p.Coordinates()
+			if sqrtDist(px, py, x, y) <= 10*10 {
+				result = append(result, idx)
+			}
+		}
+	}
+}
+
+func BenchmarkBruteForce_Nearest(b *testing.B) {
+	points := benchmarkPoints()
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		//keeps 10 best in a sorted slice
+		best := make([]ItemDist, 0, 11)
+		for idx, p := range points {
+			px, py := p.Coordinates()
+			d := sqrtDist(px, py, x, y)
+			if len(best) == 10 && d >= best[9].Dist {
+				continue
+			}
+			j := len(best)
+			if j < 10 {
+				best = append(best, ItemDist{})
+			} else {
+				j = 9
+			}
+			for ; j > 0 && best[j-1].Dist > d; j-- {
+				best[j] = best[j-1]
+			}
+			best[j] = ItemDist{idx, d}
+		}
+	}
+}
+
+func BenchmarkRTree_Range(b *testing.B) {
+	tree := NewRTree(benchmarkPoints(), 16)
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := rnd.Float64()*benchExtent, rnd.Float64()*benchExtent
+		tree.Range(x, y, x+10, y+10)
+	}
+}
@@ -0,0 +1,98 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Returns the bounding box of all indexed points, computed at build. All values are 0 for an empty index.
+func (bush *KDBush) Bounds() (minX, minY, maxX, maxY float64) {
+	b := bush.bbox
+	return b[0], b[1], b[2], b[3]
+}
+
+// Stores tight bounding box of every node of the tree at build, 32 bytes per node.
+// Within prunes nodes by distance to their boxes instead of split lines
+// and takes nodes fully inside of the circle without testing their points. Boxes are not saved by Save.
+func WithNodeBounds() Option {
+	return func(cfg *buildConfig) {
+		cfg.nodeBounds = true
+	}
+}
+
+// computes tight boxes of all nodes, nodes are numbered as in binary heap: root is 1, children of k are 2k and 2k+1
+func (bush *KDBush) computeNodeBoxes() {
+	bush.nodeBoxes = nil
+	if bush.size() > 0 {
+		bush.fillNodeBox(0, bush.size()-1, 1)
+	}
+}
+
+func (bush *KDBush) fillNodeBox(left, right, id int) [4]float64 {
+	var b [4]float64
+	if right-left <= bush.NodeSize {
+		b[0], b[1], b[2], b[3] = coordsBounds(bush.Coords[2*left : 2*right+2])
+	} else {
+		m := floor(float64(left+right) / 2.0)
+		x, y := bush.x(m), bush.y(m)
+		b = [4]float64{x, y, x, y}
+		for _, c := range [][3]int{{left, m - 1, 2 * id}, {m + 1, right, 2*id + 1}} {
+			if c[0] <= c[1] {
+				cb := bush.fillNodeBox(c[0], c[1], c[2])
+				b = [4]float64{math.Min(b[0], cb[0]), math.Min(b[1], cb[1]), math.Max(b[2], cb[2]), math.Max(b[3], cb[3])}
+			}
+		}
+	}
+	for len(bush.nodeBoxes) < 4*(id+1) {
+		bush.nodeBoxes = append(bush.nodeBoxes, 0)
+	}
+	copy(bush.nodeBoxes[4*id:], b[:])
+	return b
+}
+
+// Within using tight boxes of the nodes
+func (bush *KDBush) withinNodeBoxes(dst []int, qx, qy, radius float64) []int {
+	result := dst
+	r2 := radius * radius
+	addAll := func(left, right int) {
+		for i := left; i <= right; i++ {
+			if !bush.removedAt(i) {
+				result = append(result, bush.idx(i))
+			}
+		}
+	}
+	sp := stackPool.Get().(*[]int)
+	stack := append((*sp)[:0], 0, bush.size()-1, 1)
+	for len(stack) > 0 {
+		left, right, id := stack[len(stack)-3], stack[len(stack)-2], stack[len(stack)-1]
+		stack = stack[:len(stack)-3]
+		if left > right {
+			continue
+		}
+		b := bush.nodeBoxes[4*id : 4*id+4]
+		n := treeNode{minX: b[0], minY: b[1], maxX: b[2], maxY: b[3]}
+		if boxDistSq(qx, qy, n) > r2 {
+			continue
+		}
+		//the farthest corner is inside of the circle
+		if dx, dy := math.Max(qx-b[0], b[2]-qx), math.Max(qy-b[1], b[3]-qy); dx*dx+dy*dy <= r2 {
+			addAll(left, right)
+			continue
+		}
+
+		if right-left <= bush.NodeSize {
+			for i := left; i <= right; i++ {
+				if sqrtDist(bush.x(i), bush.y(i), qx, qy) <= r2 && !bush.removedAt(i) {
+					result = append(result, bush.idx(i))
+				}
+			}
+			continue
+		}
+		m := floor(float64(left+right) / 2.0)
+		if sqrtDist(bush.x(m), bush.y(m), qx, qy) <= r2 && !bush.removedAt(m) {
+			result = append(result, bush.idx(m))
+		}
+		stack = append(stack, left, m-1, 2*id, m+1, right, 2*id+1)
+	}
+	putStack(sp, stack)
+	return result
+}
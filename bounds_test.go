@@ -0,0 +1,46 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Bounds(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	minX, minY, maxX, maxY := bush.Bounds()
+	assert.Equal(t, [4]float64{1, 1, 99, 98}, [4]float64{minX, minY, maxX, maxY})
+
+	minX, minY, maxX, maxY = NewBush(nil, 10).Bounds()
+	assert.Equal(t, [4]float64{0, 0, 0, 0}, [4]float64{minX, minY, maxX, maxY})
+}
+
+func TestWithNodeBounds(t *testing.T) {
+	points := benchmarkPoints()[:20000]
+	plain := NewBush(points, 16)
+	bush := NewBushWithOptions(points, 16, WithNodeBounds())
+	assert.Equal(t, plain.MemoryUsage()+8*int64(cap(bush.nodeBoxes)), bush.MemoryUsage())
+
+	rnd := rand.New(rand.NewSource(4))
+	for i := 0; i < 50; i++ {
+		q := &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 1000}
+		r := rnd.Float64() * 200
+		assert.Equal(t, sortedInts(plain.Within(q, r)), sortedInts(bush.Within(q, r)))
+	}
+	bush.RemoveWithin(&SimplePoint{X: 500, Y: 500}, 100)
+	plain.RemoveWithin(&SimplePoint{X: 500, Y: 500}, 100)
+	q := &SimplePoint{X: 450, Y: 450}
+	assert.Equal(t, sortedInts(plain.Within(q, 300)), sortedInts(bush.Within(q, 300)))
+
+	assert.Empty(t, NewBushWithOptions(nil, 16, WithNodeBounds()).Within(q, 10))
+}
+
+func BenchmarkWithNodeBounds_Within(b *testing.B) {
+	bush := NewBushWithOptions(benchmarkPoints(), *benchNodeSize, WithNodeBounds())
+	rnd := rand.New(rand.NewSource(*benchSeed))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bush.Within(&SimplePoint{X: rnd.Float64() * benchExtent, Y: rnd.Float64() * benchExtent}, 10)
+	}
+}
@@ -0,0 +1,113 @@
+package kdbush
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// number of points in one chunk of the Builder buffer
+const builderChunk = 1 << 14
+
+// Builder collects coordinates from many goroutines and builds the index from them.
+// Append is safe for concurrent use: every call reserves its own slot, so producers don't wait for each other,
+// the lock is taken only when a new buffer chunk is allocated.
+// Zero value is ready to use. All Append calls should return before Build is called.
+//
+// For a single producer, that streams points e.g. from a file, use NewBuilder with Add and Finish instead:
+// coordinates are collected into one preallocated array, that becomes the array of the index without copying.
+// Add and Append shouldn't be mixed in the same builder.
+type Builder struct {
+	next   int64
+	mu     sync.RWMutex
+	chunks []*[2 * builderChunk]float64
+
+	nodeSize int
+	coords   []float64 //coordinates of points added by Add
+}
+
+// Creates builder for streaming points by a single producer.
+// Coordinates array is allocated once for expectedCount points, it grows if more points are added.
+func NewBuilder(expectedCount, nodeSize int) *Builder {
+	return &Builder{nodeSize: nodeSize, coords: make([]float64, 0, 2*iMax(0, expectedCount))}
+}
+
+// Adds the point, returns its index, that is used in query results of the built index.
+// Not safe for concurrent use, use Append for concurrent producers.
+func (b *Builder) Add(x, y float64) (idx int) {
+	b.coords = append(b.coords, x, y)
+	return len(b.coords)/2 - 1
+}
+
+// Same as Add, but takes coordinates from the point
+func (b *Builder) AddPoint(p Point) (idx int) {
+	x, y := p.Coordinates()
+	return b.Add(x, y)
+}
+
+// Builds the index from all added points with the node size of NewBuilder, Points field of the index is nil.
+// Coordinates array is handed over to the index, so the builder is empty after Finish.
+func (b *Builder) Finish() *KDBush {
+	nodeSize := b.nodeSize
+	if nodeSize <= 0 {
+		nodeSize = DefaultNodeSize
+	}
+	if b.Len() > 0 {
+		return b.Build(nodeSize)
+	}
+	bush := newBushOwningCoords(b.coords, nodeSize)
+	b.coords = nil
+	return bush
+}
+
+// Adds the point, returns its index, that is used in query results of the built index
+func (b *Builder) Append(x, y float64) (idx int) {
+	idx = int(atomic.AddInt64(&b.next, 1) - 1)
+	c := b.chunk(idx / builderChunk)
+	i := idx % builderChunk
+	c[2*i] = x
+	c[2*i+1] = y
+	return idx
+}
+
+// Number of points appended by Append
+func (b *Builder) Len() int {
+	return int(atomic.LoadInt64(&b.next))
+}
+
+func (b *Builder) chunk(n int) *[2 * builderChunk]float64 {
+	b.mu.RLock()
+	if n < len(b.chunks) {
+		c := b.chunks[n]
+		b.mu.RUnlock()
+		return c
+	}
+	b.mu.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.chunks) <= n {
+		b.chunks = append(b.chunks, new([2 * builderChunk]float64))
+	}
+	return b.chunks[n]
+}
+
+// Builds the index from all appended points, Points field of the index is nil.
+// Builder could be used further, next Build includes all points appended so far.
+func (b *Builder) Build(nodeSize int) *KDBush {
+	n := b.Len()
+	bush := &KDBush{NodeSize: nodeSize, input: n}
+	bush.Idxs = make([]int, n)
+	bush.Coords = make([]float64, 2*n)
+
+	b.mu.RLock()
+	for i := 0; i < n; i += builderChunk {
+		copy(bush.Coords[2*i:], b.chunks[i/builderChunk][:2*iMin(builderChunk, n-i)])
+	}
+	b.mu.RUnlock()
+
+	for i := range bush.Idxs {
+		bush.Idxs[i] = i
+	}
+	bush.sortIndex()
+	return bush
+}
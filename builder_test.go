@@ -0,0 +1,72 @@
+package kdbush
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	for _, p := range testPoints {
+		b.Append(p[0], p[1])
+	}
+	bush := b.Build(10)
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+}
+
+func TestBuilder_Concurrent(t *testing.T) {
+	var b Builder
+	const producers, perProducer = 16, 5000
+
+	xs := make([]float64, producers*perProducer)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				x := float64(p*perProducer + i)
+				idx := b.Append(x, -x)
+				xs[idx] = x
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	bush := b.Build(64)
+	assert.Equal(t, producers*perProducer, len(bush.Idxs))
+	for i, idx := range bush.Idxs {
+		assert.Equal(t, xs[idx], bush.Coords[2*i])
+		assert.Equal(t, -xs[idx], bush.Coords[2*i+1])
+	}
+	assert.Len(t, bush.Range(0, -99, 99, 0), 100)
+}
+
+func TestNewBuilder(t *testing.T) {
+	b := NewBuilder(len(testPoints), 10)
+	for i, p := range testPoints {
+		if i%2 == 0 {
+			assert.Equal(t, i, b.Add(p[0], p[1]))
+		} else {
+			assert.Equal(t, i, b.AddPoint(&SimplePoint{X: p[0], Y: p[1]}))
+		}
+	}
+	buf := &b.coords[0]
+	bush := b.Finish()
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+	assert.Same(t, buf, &bush.Coords[0], "coordinates should not be copied")
+	assert.Equal(t, 10, bush.NodeSize)
+
+	//grows beyond expected count
+	b = NewBuilder(1, 0)
+	b.Add(1, 1)
+	b.Add(2, 2)
+	bush = b.Finish()
+	assert.Equal(t, 64, bush.NodeSize)
+	assert.Equal(t, []int{1}, bush.Range(1.5, 1.5, 3, 3))
+	assert.Empty(t, b.Finish().Idxs)
+}
@@ -0,0 +1,26 @@
+package kdbush
+
+// Computes centroid of the points inside of the geometry during traversal, without collecting the results.
+// Returns centroid coordinates and number of points, coordinates are NaN if there are no points.
+func (bush *KDBush) Centroid(geom QueryGeom, opts ...QueryOption) (x, y float64, n int) {
+	x, y, w := bush.WeightedCentroid(geom, func(int) float64 { return 1 }, opts...)
+	return x, y, int(w)
+}
+
+// Same as Centroid, but every point has weight, returned by weight function for its original index.
+// Returns weighted centroid coordinates and total weight, coordinates are NaN if total weight is 0.
+func (bush *KDBush) WeightedCentroid(geom QueryGeom, weight func(idx int) float64, opts ...QueryOption) (x, y, total float64) {
+	var sumX, sumY float64
+	minX, minY, maxX, maxY := geom.Bounds()
+	bush.search(minX, minY, maxX, maxY, newQueryConfig(opts), func(i int) bool {
+		px, py := bush.x(i), bush.y(i)
+		if geom.Contains(px, py) {
+			w := weight(bush.idx(i))
+			sumX += w * px
+			sumY += w * py
+			total += w
+		}
+		return true
+	})
+	return sumX / total, sumY / total, total
+}
@@ -0,0 +1,39 @@
+package kdbush
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Centroid(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	area := Circle{50, 50, 30}
+
+	var sx, sy float64
+	idxs, _ := bush.Query(area)
+	for _, idx := range idxs {
+		sx += testPoints[idx][0]
+		sy += testPoints[idx][1]
+	}
+
+	x, y, n := bush.Centroid(area)
+	assert.Equal(t, len(idxs), n)
+	assert.InDelta(t, sx/float64(n), x, 1e-9)
+	assert.InDelta(t, sy/float64(n), y, 1e-9)
+
+	x, y, n = bush.Centroid(Rect{200, 200, 300, 300})
+	assert.Equal(t, 0, n)
+	assert.True(t, math.IsNaN(x) && math.IsNaN(y))
+}
+
+func TestKDBush_WeightedCentroid(t *testing.T) {
+	bush := NewBush([]Point{&SimplePoint{X: 0, Y: 0}, &SimplePoint{X: 10, Y: 0}, &SimplePoint{X: 100, Y: 100}}, 10)
+	weights := []float64{1, 3, 5}
+
+	x, y, total := bush.WeightedCentroid(Rect{-1, -1, 20, 20}, func(idx int) float64 { return weights[idx] })
+	assert.Equal(t, 4.0, total)
+	assert.Equal(t, 7.5, x)
+	assert.Equal(t, 0.0, y)
+}
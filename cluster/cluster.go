@@ -0,0 +1,350 @@
+// Package cluster implements supercluster algorithm of point clustering for maps on top of kdbush indexes.
+//
+// Points are lng, lat degrees. For every zoom from MaxZoom down to MinZoom, points and clusters of the previous zoom,
+// that are within Radius pixels of each other, are merged into clusters, every zoom has its own index.
+// Clusters of a zoom are queried with GetClusters, cluster hierarchy is navigated with GetChildren, GetLeaves
+// and GetClusterExpansionZoom. Results are the same as of supercluster JavaScript library with the same options.
+package cluster
+
+import (
+	"errors"
+	"math"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+// Returned when the cluster id is not valid for the index
+var ErrNotFound = errors.New("cluster: no cluster with the specified id")
+
+// Options of clustering, zero values are replaced by defaults of DefaultOptions
+type Options struct {
+	MinZoom   int     //minimum zoom level of clusters, 0
+	MaxZoom   int     //maximum zoom level of clusters, 16
+	MinPoints int     //minimum number of points to form a cluster, 2
+	Radius    float64 //cluster radius in pixels, 40
+	Extent    float64 //tile extent, radius is calculated relative to it, 512
+	NodeSize  int     //node size of the kdbush indexes, 64
+
+	//Map and Reduce aggregate properties of points into properties of clusters, like map/reduce options of supercluster.
+	//Map returns properties of the input point by its index, Reduce merges properties of a point or a cluster into accumulated ones
+	//and returns the result. Reduce must not modify props, it belongs to the child feature.
+	//Accumulated properties of a new cluster start from properties of its first child, so without Clone
+	//Reduce must not modify accumulated either and should return a new value.
+	//Clusters have no properties if Map or Reduce is nil.
+	Map    func(idx int) interface{}
+	Reduce func(accumulated, props interface{}) interface{}
+	//Clone copies properties of the first child of a new cluster, so Reduce could merge into accumulated in place
+	Clone func(props interface{}) interface{}
+}
+
+// Returns default options, the same as of supercluster
+func DefaultOptions() Options {
+	return Options{MinZoom: 0, MaxZoom: 16, MinPoints: 2, Radius: 40, Extent: 512, NodeSize: 64}
+}
+
+func (o Options) withDefaults() Options {
+	d := DefaultOptions()
+	if o.MaxZoom == 0 {
+		o.MaxZoom = d.MaxZoom
+	}
+	if o.MinPoints == 0 {
+		o.MinPoints = d.MinPoints
+	}
+	if o.Radius == 0 {
+		o.Radius = d.Radius
+	}
+	if o.Extent == 0 {
+		o.Extent = d.Extent
+	}
+	if o.NodeSize == 0 {
+		o.NodeSize = d.NodeSize
+	}
+	return o
+}
+
+// Cluster or a single point
+type Feature struct {
+	Lng, Lat  float64
+	ID        int  //cluster id if it's a cluster, index in the input points slice otherwise
+	NumPoints int  //number of points in the cluster, 1 for a single point
+	IsCluster bool //feature is a cluster
+
+	Properties interface{} //result of Options.Map for a point, reduced properties of the points for a cluster
+}
+
+// Clusters of all zoom levels
+type Index struct {
+	options Options
+	points  []kdbush.Point
+	levels  []*level //by zoom, from 0 to MaxZoom+1, the last one has input points
+}
+
+// index of one zoom level
+type level struct {
+	bush  *kdbush.KDBush
+	items []item
+}
+
+// point or cluster of a zoom level in projected 0..1 coordinates
+type item struct {
+	x, y      float64
+	zoom      int //last zoom, the item was processed on
+	id        int //cluster id or index of the input point
+	parent    int //id of the parent cluster, -1 if not clustered yet
+	numPoints int
+	props     interface{} //reduced properties of the cluster
+}
+
+// Clusters points for all zoom levels
+func New(points []kdbush.Point, opts Options) *Index {
+	opts = opts.withDefaults()
+	c := &Index{options: opts, points: points, levels: make([]*level, opts.MaxZoom+2)}
+
+	items := make([]item, len(points))
+	for i, p := range points {
+		lng, lat := p.Coordinates()
+		items[i] = item{x: lngX(lng), y: latY(lat), zoom: math.MaxInt, id: i, parent: -1, numPoints: 1}
+	}
+	c.levels[opts.MaxZoom+1] = c.newLevel(items)
+
+	for z := opts.MaxZoom; z >= opts.MinZoom; z-- {
+		c.levels[z] = c.newLevel(c.cluster(c.levels[z+1], z))
+	}
+	return c
+}
+
+func (c *Index) newLevel(items []item) *level {
+	pairs := make([][2]float64, len(items))
+	for i, it := range items {
+		pairs[i] = [2]float64{it.x, it.y}
+	}
+	return &level{bush: kdbush.NewBushFromPairs(pairs, c.options.NodeSize), items: items}
+}
+
+// merges items of the level into clusters of the zoom, returns items of the zoom
+func (c *Index) cluster(l *level, zoom int) []item {
+	r := c.options.Radius / (c.options.Extent * math.Pow(2, float64(zoom)))
+	items := l.items
+	next := []item{}
+
+	for i := range items {
+		if items[i].zoom <= zoom {
+			continue
+		}
+		items[i].zoom = zoom
+
+		x, y := items[i].x, items[i].y
+		neighbors := l.bush.Within(&kdbush.SimplePoint{X: x, Y: y}, r)
+
+		numPointsOrigin := items[i].numPoints
+		numPoints := numPointsOrigin
+		for _, n := range neighbors {
+			if items[n].zoom > zoom {
+				numPoints += items[n].numPoints
+			}
+		}
+
+		if numPoints > numPointsOrigin && numPoints >= c.options.MinPoints {
+			wx, wy := x*float64(numPointsOrigin), y*float64(numPointsOrigin)
+			reduce := c.options.Map != nil && c.options.Reduce != nil
+			var props interface{}
+			if reduce {
+				props = c.properties(items[i])
+				if c.options.Clone != nil {
+					props = c.options.Clone(props)
+				}
+			}
+			//encodes index of the origin item and zoom, so the cluster could be found by id
+			id := i<<5 + (zoom + 1) + len(c.points)
+			for _, n := range neighbors {
+				if items[n].zoom <= zoom {
+					continue
+				}
+				items[n].zoom = zoom
+				wx += items[n].x * float64(items[n].numPoints)
+				wy += items[n].y * float64(items[n].numPoints)
+				items[n].parent = id
+				if reduce {
+					props = c.options.Reduce(props, c.properties(items[n]))
+				}
+			}
+			items[i].parent = id
+			next = append(next, item{x: wx / float64(numPoints), y: wy / float64(numPoints), zoom: math.MaxInt, id: id, parent: -1, numPoints: numPoints, props: props})
+			continue
+		}
+
+		it := items[i]
+		it.zoom = math.MaxInt
+		next = append(next, it)
+		if numPoints > 1 {
+			for _, n := range neighbors {
+				if items[n].zoom <= zoom {
+					continue
+				}
+				items[n].zoom = zoom
+				it := items[n]
+				it.zoom = math.MaxInt
+				next = append(next, it)
+			}
+		}
+	}
+	return next
+}
+
+// Returns clusters and points of the zoom within the lng, lat bounding box.
+// Box could cross the antimeridian, then minLng is greater than maxLng.
+func (c *Index) GetClusters(minLng, minLat, maxLng, maxLat float64, zoom int) []Feature {
+	west := math.Mod(math.Mod(minLng+180, 360)+360, 360) - 180
+	south := math.Max(-90, math.Min(90, minLat))
+	east := 180.0
+	if maxLng != 180 {
+		east = math.Mod(math.Mod(maxLng+180, 360)+360, 360) - 180
+	}
+	north := math.Max(-90, math.Min(90, maxLat))
+
+	if maxLng-minLng >= 360 {
+		west, east = -180, 180
+	} else if west > east {
+		result := c.GetClusters(west, south, 180, north, zoom)
+		return append(result, c.GetClusters(-180, south, east, north, zoom)...)
+	}
+
+	l := c.levels[c.limitZoom(zoom)]
+	ids := l.bush.Range(lngX(west), latY(north), lngX(east), latY(south))
+	result := make([]Feature, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, c.feature(l.items[id]))
+	}
+	return result
+}
+
+// Returns children of the cluster on the next zoom
+func (c *Index) GetChildren(clusterID int) ([]Feature, error) {
+	originID, originZoom := c.origin(clusterID)
+	//clusters are made on zooms from MinZoom, so they originate from zooms after it, lower levels are nil
+	if originZoom <= c.options.MinZoom || originZoom >= len(c.levels) {
+		return nil, ErrNotFound
+	}
+	l := c.levels[originZoom]
+	if l == nil || originID < 0 || originID >= len(l.items) {
+		return nil, ErrNotFound
+	}
+
+	r := c.options.Radius / (c.options.Extent * math.Pow(2, float64(originZoom-1)))
+	origin := l.items[originID]
+	children := []Feature{}
+	for _, n := range l.bush.Within(&kdbush.SimplePoint{X: origin.x, Y: origin.y}, r) {
+		if l.items[n].parent == clusterID {
+			children = append(children, c.feature(l.items[n]))
+		}
+	}
+	if len(children) == 0 {
+		return nil, ErrNotFound
+	}
+	return children, nil
+}
+
+// Returns points of the cluster, limit <= 0 means all of them, first offset points are skipped
+func (c *Index) GetLeaves(clusterID, limit, offset int) ([]Feature, error) {
+	if limit <= 0 {
+		limit = math.MaxInt
+	}
+	leaves := []Feature{}
+	_, err := c.appendLeaves(&leaves, clusterID, limit, offset, 0)
+	return leaves, err
+}
+
+func (c *Index) appendLeaves(leaves *[]Feature, clusterID, limit, offset, skipped int) (int, error) {
+	children, err := c.GetChildren(clusterID)
+	if err != nil {
+		return skipped, err
+	}
+	for _, child := range children {
+		if child.IsCluster {
+			if skipped+child.NumPoints <= offset {
+				//skip the whole cluster
+				skipped += child.NumPoints
+			} else if skipped, err = c.appendLeaves(leaves, child.ID, limit, offset, skipped); err != nil {
+				return skipped, err
+			}
+		} else if skipped < offset {
+			skipped++
+		} else {
+			*leaves = append(*leaves, child)
+		}
+		if len(*leaves) == limit {
+			break
+		}
+	}
+	return skipped, nil
+}
+
+// Returns zoom, on which the cluster expands into several children
+func (c *Index) GetClusterExpansionZoom(clusterID int) (int, error) {
+	_, zoom := c.origin(clusterID)
+	expansionZoom := zoom - 1
+	for expansionZoom <= c.options.MaxZoom {
+		children, err := c.GetChildren(clusterID)
+		if err != nil {
+			return 0, err
+		}
+		expansionZoom++
+		if len(children) != 1 || !children[0].IsCluster {
+			break
+		}
+		clusterID = children[0].ID
+	}
+	return expansionZoom, nil
+}
+
+// decodes index of the origin item and its zoom level from cluster id
+func (c *Index) origin(clusterID int) (id, zoom int) {
+	v := clusterID - len(c.points)
+	if v < 0 {
+		return -1, -1
+	}
+	return v >> 5, v % 32
+}
+
+func (c *Index) limitZoom(zoom int) int {
+	return max(c.options.MinZoom, min(zoom, c.options.MaxZoom+1))
+}
+
+func (c *Index) feature(it item) Feature {
+	if it.numPoints > 1 {
+		return Feature{Lng: xLng(it.x), Lat: yLat(it.y), ID: it.id, NumPoints: it.numPoints, IsCluster: true, Properties: it.props}
+	}
+	lng, lat := c.points[it.id].Coordinates()
+	return Feature{Lng: lng, Lat: lat, ID: it.id, NumPoints: 1, Properties: c.properties(it)}
+}
+
+// properties of the cluster or mapped properties of the point
+func (c *Index) properties(it item) interface{} {
+	if it.numPoints > 1 {
+		return it.props
+	}
+	if c.options.Map == nil {
+		return nil
+	}
+	return c.options.Map(it.id)
+}
+
+// spherical mercator to 0..1 range
+func lngX(lng float64) float64 {
+	return lng/360 + 0.5
+}
+
+func latY(lat float64) float64 {
+	sin := math.Sin(lat * math.Pi / 180)
+	y := 0.5 - 0.25*math.Log((1+sin)/(1-sin))/math.Pi
+	return math.Max(0, math.Min(1, y))
+}
+
+func xLng(x float64) float64 {
+	return (x - 0.5) * 360
+}
+
+func yLat(y float64) float64 {
+	y2 := (180 - y*360) * math.Pi / 180
+	return 360*math.Atan(math.Exp(y2))/math.Pi - 90
+}
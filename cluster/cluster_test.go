@@ -0,0 +1,248 @@
+package cluster
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/MadAppGang/kdbush"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomPoints(n int) []kdbush.Point {
+	rnd := rand.New(rand.NewSource(11))
+	points := make([]kdbush.Point, n)
+	for i := range points {
+		points[i] = kdbush.LngLat{Lng: rnd.Float64()*60 - 30, Lat: rnd.Float64()*40 + 20}
+	}
+	return points
+}
+
+func TestNew(t *testing.T) {
+	points := []kdbush.Point{
+		kdbush.LngLat{Lng: 10, Lat: 50},
+		kdbush.LngLat{Lng: 10.01, Lat: 50.01},
+		kdbush.LngLat{Lng: 9.99, Lat: 50},
+		kdbush.LngLat{Lng: -120, Lat: 40},
+	}
+	c := New(points, Options{})
+
+	features := c.GetClusters(-180, -85, 180, 85, 0)
+	if assert.Len(t, features, 2) {
+		var cluster, single Feature
+		for _, f := range features {
+			if f.IsCluster {
+				cluster = f
+			} else {
+				single = f
+			}
+		}
+		assert.Equal(t, 3, cluster.NumPoints)
+		assert.InDelta(t, 10, cluster.Lng, 0.01)
+		assert.InDelta(t, 50, cluster.Lat, 0.01)
+		assert.Equal(t, Feature{Lng: -120, Lat: 40, ID: 3, NumPoints: 1}, single)
+
+		leaves, err := c.GetLeaves(cluster.ID, 0, 0)
+		assert.NoError(t, err)
+		ids := []int{}
+		for _, l := range leaves {
+			ids = append(ids, l.ID)
+		}
+		assert.ElementsMatch(t, []int{0, 1, 2}, ids)
+
+		zoom, err := c.GetClusterExpansionZoom(cluster.ID)
+		assert.NoError(t, err)
+		assert.True(t, zoom > 5 && zoom <= 17, "zoom %d", zoom)
+	}
+
+	//all points are separate on the max zoom
+	assert.Len(t, c.GetClusters(-180, -85, 180, 85, 17), 4)
+
+	_, err := c.GetChildren(1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.GetChildren(12345)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestIndex_MinZoomIDs(t *testing.T) {
+	points := randomPoints(200)
+	c := New(points, Options{MinZoom: 5})
+	//ids of clusters, that would originate from zooms up to MinZoom
+	for zoom := 0; zoom <= 5; zoom++ {
+		for origin := 0; origin < 3; origin++ {
+			id := origin<<5 + zoom + len(points)
+			_, err := c.GetChildren(id)
+			assert.ErrorIs(t, err, ErrNotFound, "id %d", id)
+			_, err = c.GetLeaves(id, 0, 0)
+			assert.ErrorIs(t, err, ErrNotFound, "id %d", id)
+			_, err = c.GetClusterExpansionZoom(id)
+			assert.ErrorIs(t, err, ErrNotFound, "id %d", id)
+		}
+	}
+}
+
+func TestIndex_Hierarchy(t *testing.T) {
+	points := randomPoints(3000)
+	c := New(points, Options{Radius: 60, MaxZoom: 12})
+
+	for z := 0; z <= 13; z++ {
+		total := 0
+		for _, f := range c.GetClusters(-180, -90, 180, 90, z) {
+			total += f.NumPoints
+			if !f.IsCluster {
+				continue
+			}
+			children, err := c.GetChildren(f.ID)
+			assert.NoError(t, err)
+			n := 0
+			for _, ch := range children {
+				n += ch.NumPoints
+			}
+			assert.Equal(t, f.NumPoints, n)
+
+			leaves, err := c.GetLeaves(f.ID, 0, 0)
+			assert.NoError(t, err)
+			assert.Len(t, leaves, f.NumPoints)
+
+			zoom, err := c.GetClusterExpansionZoom(f.ID)
+			assert.NoError(t, err)
+			assert.True(t, zoom > z)
+		}
+		assert.Equal(t, len(points), total, "zoom %d", z)
+	}
+}
+
+func TestIndex_GetLeavesPaging(t *testing.T) {
+	c := New(randomPoints(500), Options{})
+	var biggest Feature
+	for _, f := range c.GetClusters(-180, -90, 180, 90, 0) {
+		if f.NumPoints > biggest.NumPoints {
+			biggest = f
+		}
+	}
+	all, err := c.GetLeaves(biggest.ID, 0, 0)
+	assert.NoError(t, err)
+	page, err := c.GetLeaves(biggest.ID, 10, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, all[5:15], page)
+}
+
+func TestIndex_MapReduceInPlace(t *testing.T) {
+	type counter struct{ n int }
+	c := New(randomPoints(2000), Options{
+		Map: func(idx int) interface{} { return &counter{1} },
+		Reduce: func(accumulated, props interface{}) interface{} {
+			a := accumulated.(*counter)
+			a.n += props.(*counter).n
+			return a
+		},
+		Clone: func(props interface{}) interface{} {
+			copied := *props.(*counter)
+			return &copied
+		},
+	})
+	for z := 0; z <= 17; z++ {
+		for _, f := range c.GetClusters(-180, -85, 180, 85, z) {
+			assert.Equal(t, f.NumPoints, f.Properties.(*counter).n, "zoom %d", z)
+		}
+	}
+}
+
+func TestIndex_Antimeridian(t *testing.T) {
+	points := []kdbush.Point{
+		kdbush.LngLat{Lng: 179.5, Lat: 0},
+		kdbush.LngLat{Lng: -179.5, Lat: 0},
+		kdbush.LngLat{Lng: 0, Lat: 0},
+	}
+	c := New(points, Options{})
+	features := c.GetClusters(170, -10, -170, 10, 17)
+	ids := []int{}
+	for _, f := range features {
+		ids = append(ids, f.ID)
+	}
+	assert.ElementsMatch(t, []int{0, 1}, ids)
+}
+
+func TestIndex_MapReduce(t *testing.T) {
+	points := randomPoints(2000)
+	revenue := func(idx int) float64 { return float64(idx % 10) }
+	type props struct {
+		revenue    float64
+		categories map[int]int
+	}
+	c := New(points, Options{
+		Map: func(idx int) interface{} {
+			return props{revenue(idx), map[int]int{idx % 3: 1}}
+		},
+		Reduce: func(accumulated, p interface{}) interface{} {
+			a, b := accumulated.(props), p.(props)
+			categories := map[int]int{}
+			for k, v := range a.categories {
+				categories[k] += v
+			}
+			for k, v := range b.categories {
+				categories[k] += v
+			}
+			return props{a.revenue + b.revenue, categories}
+		},
+	})
+
+	for _, z := range []int{0, 3, 6} {
+		for _, f := range c.GetClusters(-180, -85, 180, 85, z) {
+			p := f.Properties.(props)
+			if !f.IsCluster {
+				assert.Equal(t, revenue(f.ID), p.revenue)
+				continue
+			}
+			leaves, err := c.GetLeaves(f.ID, 0, 0)
+			assert.NoError(t, err)
+			expected, categories := 0.0, map[int]int{}
+			for _, l := range leaves {
+				expected += revenue(l.ID)
+				categories[l.ID%3]++
+			}
+			assert.Equal(t, expected, p.revenue)
+			assert.Equal(t, categories, p.categories)
+		}
+	}
+
+	//no properties without map/reduce
+	for _, f := range New(points, Options{}).GetClusters(-180, -85, 180, 85, 2) {
+		assert.Nil(t, f.Properties)
+	}
+}
+
+func TestIndex_SaveLoad(t *testing.T) {
+	c := New(randomPoints(2000), Options{MinZoom: 2, MaxZoom: 14, Radius: 50})
+	var buf bytes.Buffer
+	assert.NoError(t, c.Save(&buf))
+	data := buf.Bytes()
+
+	loaded, err := Load(bytes.NewReader(data))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for z := 0; z <= 16; z++ {
+		expected := c.GetClusters(-180, -85, 180, 85, z)
+		assert.Equal(t, expected, loaded.GetClusters(-180, -85, 180, 85, z), "zoom %d", z)
+		for _, f := range expected {
+			if !f.IsCluster {
+				continue
+			}
+			leaves, err := c.GetLeaves(f.ID, 0, 0)
+			assert.NoError(t, err)
+			loadedLeaves, err := loaded.GetLeaves(f.ID, 0, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, leaves, loadedLeaves)
+		}
+	}
+
+	_, err = Load(bytes.NewReader(data[:len(data)-10]))
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	_, err = Load(bytes.NewReader(corrupted))
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+	_, err = Load(bytes.NewReader([]byte("KDBG")))
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
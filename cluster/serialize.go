@@ -0,0 +1,195 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+// Serialized clusters format, all values are little endian:
+//
+//	magic "KDBC", version uint8
+//	options: minZoom, maxZoom, minPoints uint32, radius, extent float64, nodeSize uint32
+//	points count uint64, for every point lng, lat float64
+//	for every zoom from minZoom to maxZoom+1: items count uint64,
+//	for every item x, y float64, zoom, id, parent int64, numPoints uint64
+//	CRC-32 (IEEE) uint32 of all the bytes before it
+//
+// Indexes of zoom levels are not serialized, Load builds them from items, it's much cheaper than clustering.
+// Map and Reduce can't be serialized, so properties of clusters are not saved and loaded index has no properties.
+const (
+	formatMagic   = "KDBC"
+	formatVersion = 1
+
+	//items and points are read in chunks, so corrupted counts can't cause huge allocation before data is read
+	readChunk = 4096
+	//zoom is encoded in 5 bits of cluster ids
+	maxZoomLimit = 30
+)
+
+// Returned by Load when data is not serialized clusters or it is corrupted
+var ErrInvalidFormat = errors.New("cluster: invalid format")
+
+type itemRecord struct {
+	X, Y             float64
+	Zoom, ID, Parent int64
+	NumPoints        uint64
+}
+
+// Writes clusters of all zoom levels with the options and coordinates of the points to w.
+// Properties of clusters are not written, see Load.
+func (c *Index) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	crc := crc32.NewIEEE()
+	mw := io.MultiWriter(bw, crc)
+	write := func(v interface{}) error {
+		return binary.Write(mw, binary.LittleEndian, v)
+	}
+
+	o := c.options
+	header := []interface{}{
+		[]byte(formatMagic), uint8(formatVersion),
+		uint32(o.MinZoom), uint32(o.MaxZoom), uint32(o.MinPoints), o.Radius, o.Extent, uint32(o.NodeSize),
+		uint64(len(c.points)),
+	}
+	for _, v := range header {
+		if err := write(v); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.points {
+		lng, lat := p.Coordinates()
+		if err := write([2]float64{lng, lat}); err != nil {
+			return err
+		}
+	}
+
+	for z := o.MinZoom; z <= o.MaxZoom+1; z++ {
+		items := c.levels[z].items
+		if err := write(uint64(len(items))); err != nil {
+			return err
+		}
+		for _, it := range items {
+			r := itemRecord{X: it.x, Y: it.y, Zoom: int64(it.zoom), ID: int64(it.id), Parent: int64(it.parent), NumPoints: uint64(it.numPoints)}
+			if err := write(&r); err != nil {
+				return err
+			}
+		}
+	}
+	if err := binary.Write(bw, binary.LittleEndian, crc.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Reads clusters, written by Save. Points of the loaded index are kdbush.LngLat values with the saved coordinates.
+// Options of the loaded index have nil Map and Reduce, features have no properties.
+func Load(r io.Reader) (*Index, error) {
+	br := bufio.NewReader(r)
+	crc := crc32.NewIEEE()
+	d := &decoder{r: io.TeeReader(br, crc)}
+
+	var magic [len(formatMagic)]byte
+	var version uint8
+	d.read(&magic)
+	d.read(&version)
+	if d.err != nil || string(magic[:]) != formatMagic {
+		return nil, ErrInvalidFormat
+	}
+	if version != formatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+
+	var minZoom, maxZoom, minPoints, nodeSize uint32
+	var o Options
+	d.read(&minZoom)
+	d.read(&maxZoom)
+	d.read(&minPoints)
+	d.read(&o.Radius)
+	d.read(&o.Extent)
+	d.read(&nodeSize)
+	if d.err != nil {
+		return nil, d.err
+	}
+	if minZoom > maxZoom || maxZoom > maxZoomLimit || nodeSize == 0 {
+		return nil, fmt.Errorf("%w: invalid options", ErrInvalidFormat)
+	}
+	o.MinZoom, o.MaxZoom, o.MinPoints, o.NodeSize = int(minZoom), int(maxZoom), int(minPoints), int(nodeSize)
+
+	count := d.count()
+	points := []kdbush.Point{}
+	for len(points) < count && d.err == nil {
+		chunk := make([][2]float64, min(count-len(points), readChunk))
+		d.read(chunk)
+		for _, p := range chunk {
+			points = append(points, kdbush.LngLat{Lng: p[0], Lat: p[1]})
+		}
+	}
+
+	c := &Index{options: o, points: points, levels: make([]*level, o.MaxZoom+2)}
+	for z := o.MinZoom; z <= o.MaxZoom+1 && d.err == nil; z++ {
+		n := d.count()
+		items := []item{}
+		for len(items) < n && d.err == nil {
+			chunk := make([]itemRecord, min(n-len(items), readChunk))
+			d.read(chunk)
+			for _, r := range chunk {
+				items = append(items, item{x: r.X, y: r.Y, zoom: int(r.Zoom), id: int(r.ID), parent: int(r.Parent), numPoints: int(r.NumPoints)})
+			}
+		}
+		if d.err != nil {
+			break
+		}
+		for _, it := range items {
+			if it.numPoints < 1 || it.numPoints > len(points) || it.numPoints == 1 && (it.id < 0 || it.id >= len(points)) {
+				return nil, fmt.Errorf("%w: invalid item on zoom %d", ErrInvalidFormat, z)
+			}
+		}
+		c.levels[z] = c.newLevel(items)
+	}
+
+	//the checksum itself is not hashed
+	sum := crc.Sum32()
+	d.r = br
+	var stored uint32
+	d.read(&stored)
+	if d.err == nil && stored != sum {
+		d.err = fmt.Errorf("%w: checksum mismatch", ErrInvalidFormat)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return c, nil
+}
+
+type decoder struct {
+	r   io.Reader
+	err error
+}
+
+func (d *decoder) read(v interface{}) {
+	if d.err == nil {
+		if err := binary.Read(d.r, binary.LittleEndian, v); err != nil {
+			d.err = fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+		}
+	}
+}
+
+// reads count of the following values, it's not trusted for allocation, values are read in chunks
+func (d *decoder) count() int {
+	var n uint64
+	d.read(&n)
+	if d.err == nil && n > math.MaxInt32 {
+		d.err = fmt.Errorf("%w: count %d is too big", ErrInvalidFormat, n)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
@@ -0,0 +1,224 @@
+// Command kdbush-demo is a runnable tour of the kdbush API.
+//
+// Generates a sample dataset of city POIs, builds an index and runs representative queries,
+// printing timing and a short summary of every result. The dataset is generated deterministically
+// from the seed, so the demo works offline and prints the same results on every run.
+//
+//	go run ./cmd/kdbush-demo [-pois 200000] [-seed 1] [-csv pois.csv]
+//
+// -csv also writes the dataset as lng,lat,category CSV, to try it with the kdbush command:
+//
+//	kdbush build -in pois.csv -out pois.kdb -header
+//	kdbush repl pois.kdb
+//
+// Every step is a few lines in the run function, read it side by side with the output.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+// center of the generated city, Berlin
+var center = kdbush.LngLat{Lng: 13.405, Lat: 52.52}
+
+var categories = []string{"cafe", "restaurant", "pharmacy", "atm", "bus stop"}
+
+// sample point of interest, implements kdbush.Point
+type poi struct {
+	Lng, Lat float64
+	Category int //index in categories
+}
+
+func (p *poi) Coordinates() (float64, float64) {
+	return p.Lng, p.Lat
+}
+
+func main() {
+	n := flag.Int("pois", 200000, "number of generated POIs")
+	seed := flag.Int64("seed", 1, "seed of the generated dataset")
+	csvPath := flag.String("csv", "", "also write the dataset to this CSV file")
+	flag.Parse()
+
+	pois := generate(*n, *seed)
+	if *csvPath != "" {
+		if err := writeCSV(*csvPath, pois); err != nil {
+			fmt.Fprintln(os.Stderr, "kdbush-demo:", err)
+			os.Exit(1)
+		}
+	}
+	if err := run(os.Stdout, pois); err != nil {
+		fmt.Fprintln(os.Stderr, "kdbush-demo:", err)
+		os.Exit(1)
+	}
+}
+
+// generates n POIs around city districts: dense centers and sparse outskirts, about 30x20 km
+func generate(n int, seed int64) []*poi {
+	rnd := rand.New(rand.NewSource(seed))
+	districts := make([][3]float64, 24) //lng, lat, spread in degrees
+	for i := range districts {
+		districts[i] = [3]float64{center.Lng + rnd.NormFloat64()*0.08, center.Lat + rnd.NormFloat64()*0.05, 0.005 + rnd.Float64()*0.02}
+	}
+	pois := make([]*poi, n)
+	for i := range pois {
+		d := districts[rnd.Intn(len(districts))]
+		pois[i] = &poi{
+			Lng:      d[0] + rnd.NormFloat64()*d[2]*1.6, //degrees of longitude are shorter at this latitude
+			Lat:      d[1] + rnd.NormFloat64()*d[2],
+			Category: rnd.Intn(len(categories)),
+		}
+	}
+	return pois
+}
+
+func writeCSV(path string, pois []*poi) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "lng,lat,category")
+	for _, p := range pois {
+		fmt.Fprintf(w, "%.6f,%.6f,%s\n", p.Lng, p.Lat, categories[p.Category])
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// prints a line of the report: step, its timing and summary of the result
+type report struct {
+	w io.Writer
+}
+
+func (r report) step(name string, fn func() string) {
+	start := time.Now()
+	summary := fn()
+	fmt.Fprintf(r.w, "%-24s %10s  %s\n", name, time.Since(start).Round(time.Microsecond), summary)
+}
+
+func run(w io.Writer, pois []*poi) error {
+	r := report{w}
+	points := make([]kdbush.Point, len(pois))
+	for i, p := range pois {
+		points[i] = p
+	}
+	fmt.Fprintf(w, "%d POIs around %.3f, %.3f\n\n", len(pois), center.Lng, center.Lat)
+
+	//build, coordinates are lng, lat degrees
+	var bush *kdbush.KDBush
+	r.step("NewBush", func() string {
+		bush = kdbush.NewBush(points, kdbush.DefaultNodeSize)
+		return fmt.Sprintf("%+v, %.1f MB", bush.Stats(), float64(bush.MemoryUsage())/(1<<20))
+	})
+
+	//box around the center, about 1.4 x 1.1 km
+	minLng, minLat, maxLng, maxLat := center.Lng-0.01, center.Lat-0.005, center.Lng+0.01, center.Lat+0.005
+	r.step("Range", func() string {
+		return fmt.Sprintf("%d POIs in the box", len(bush.Range(minLng, minLat, maxLng, maxLat)))
+	})
+	r.step("RangeCount", func() string {
+		return fmt.Sprintf("%d POIs in the box, without building the result", bush.RangeCount(minLng, minLat, maxLng, maxLat))
+	})
+	r.step("RangeInfo", func() string {
+		result, info := bush.RangeInfo(minLng, minLat, maxLng, maxLat, kdbush.MaxNodesVisited(8))
+		return fmt.Sprintf("%d POIs, limited to 8 nodes: %+v", len(result), info)
+	})
+
+	//geographic queries, distances in meters
+	r.step("GeoNearest", func() string {
+		result := bush.GeoNearest(center, 5, 2000, kdbush.Meters)
+		return fmt.Sprintf("5 nearest within 2 km: %s", describe(pois, result))
+	})
+	r.step("GeoNearest + Where", func() string {
+		pharmacy := func(idx int) bool { return pois[idx].Category == 2 }
+		result := bush.GeoNearest(center, 3, 0, kdbush.Meters, kdbush.Where(pharmacy))
+		return fmt.Sprintf("3 nearest pharmacies: %s", describe(pois, result))
+	})
+	r.step("RangeLngLat", func() string {
+		return fmt.Sprintf("%d POIs in the box", len(bush.RangeLngLat(minLng, minLat, maxLng, maxLat)))
+	})
+	r.step("Query GeoPolygon", func() string {
+		district := kdbush.NewGeoPolygon([]kdbush.LngLat{
+			{Lng: 13.37, Lat: 52.50}, {Lng: 13.44, Lat: 52.50}, {Lng: 13.44, Lat: 52.54}, {Lng: 13.37, Lat: 52.53},
+		})
+		result, _ := bush.Query(district)
+		return fmt.Sprintf("%d POIs in the district", len(result))
+	})
+	r.step("Query BufferLine", func() string {
+		route := kdbush.BufferLine([]kdbush.LngLat{center, {Lng: 13.45, Lat: 52.55}}, 200, kdbush.Meters)
+		result, _ := bush.Query(route)
+		return fmt.Sprintf("%d POIs within 200 m of the route", len(result))
+	})
+
+	//planar queries on degrees, fine for ranking by distance in a small area
+	r.step("Nearest", func() string {
+		return fmt.Sprintf("10 nearest: %v", bush.Nearest(&center, 10, 0))
+	})
+	r.step("NearestIter", func() string {
+		next := bush.NearestIter(&center)
+		atms := 0
+		seen := 0
+		for atms < 10 {
+			idx, _, ok := next()
+			if !ok {
+				break
+			}
+			seen++
+			if pois[idx].Category == 3 {
+				atms++
+			}
+		}
+		return fmt.Sprintf("%d nearest POIs seen to find %d ATMs", seen, atms)
+	})
+	r.step("WithinTopPerCategory", func() string {
+		cats := make([]int, len(pois))
+		for i, p := range pois {
+			cats[i] = p.Category
+		}
+		top := bush.WithinTopPerCategory(&center, 0.01, cats, 2)
+		return fmt.Sprintf("2 nearest of %d categories", len(top))
+	})
+
+	//serialization
+	var buf bytes.Buffer
+	r.step("Save", func() string {
+		if err := bush.Save(&buf); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("%.1f MB", float64(buf.Len())/(1<<20))
+	})
+	var err error
+	r.step("Load", func() string {
+		var loaded *kdbush.KDBush
+		if loaded, err = kdbush.Load(&buf); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("same content: %v", loaded.ContentHash() == bush.ContentHash())
+	})
+	return err
+}
+
+// describes results of geographic query as category and rounded distance
+func describe(pois []*poi, result []kdbush.ItemDist) string {
+	s := ""
+	for i, r := range result {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s %.0f m", categories[pois[r.Index].Category], math.Round(r.Dist))
+	}
+	return s
+}
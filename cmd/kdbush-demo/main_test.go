@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(&out, generate(5000, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []string{"NewBush", "Range", "GeoNearest", "Query GeoPolygon", "NearestIter", "Load"} {
+		if !strings.Contains(out.String(), "\n"+step+" ") {
+			t.Errorf("no %s step in the output:\n%s", step, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "same content: true") {
+		t.Errorf("loaded index differs:\n%s", out.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	a, b := generate(100, 7), generate(100, 7)
+	for i := range a {
+		if *a[i] != *b[i] {
+			t.Fatalf("POI %d differs: %+v and %+v", i, *a[i], *b[i])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "pois.csv")
+	if err := writeCSV(path, a); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 101 {
+		t.Errorf("%d lines in CSV, expected header and 100 POIs", lines)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// reads x, y pairs from the columns of CSV data
+func readCSV(r io.Reader, xCol, yCol int, header bool) ([][2]float64, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	pairs := [][2]float64{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header && line == 1 {
+			continue
+		}
+		if xCol >= len(rec) || yCol >= len(rec) {
+			return nil, fmt.Errorf("line %d: expected at least %d columns", line, iMax(xCol, yCol)+1)
+		}
+		x, err := strconv.ParseFloat(rec[xCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		y, err := strconv.ParseFloat(rec[yCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		pairs = append(pairs, [2]float64{x, y})
+	}
+}
+
+func iMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
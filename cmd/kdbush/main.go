@@ -0,0 +1,173 @@
+// Command kdbush builds, inspects and queries serialized kdbush indexes.
+//
+//	kdbush build -in points.csv -out points.kdb [-nodesize 64] [-x 0] [-y 1] [-header]
+//	kdbush build -spec build.json
+//	kdbush info points.kdb
+//	kdbush repl points.kdb
+//
+// repl loads the index once and runs range, within and knn queries interactively,
+// printing timing and result counts, see "help" inside of it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "build":
+		err = runBuild(os.Args[2:])
+	case "info":
+		err = runInfo(os.Args[2:])
+	case "repl":
+		err = runRepl(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+	default:
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "kdbush:", err)
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, `usage:
+  kdbush build -in points.csv -out points.kdb [-nodesize 64] [-x 0] [-y 1] [-header]
+  kdbush build -spec build.json
+  kdbush info points.kdb
+  kdbush repl points.kdb`)
+}
+
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	in := fs.String("in", "", "input CSV file")
+	out := fs.String("out", "", "output index file")
+	nodeSize := fs.Int("nodesize", 64, "KD-tree node size")
+	xCol := fs.Int("x", 0, "x (longitude) column")
+	yCol := fs.Int("y", 1, "y (latitude) column")
+	header := fs.Bool("header", false, "skip the first line")
+	spec := fs.String("spec", "", "JSON file with kdbush.BuildSpec, replaces all other flags")
+	fs.Parse(args)
+	if *spec != "" {
+		return runBuildSpec(*spec)
+	}
+	if *in == "" || *out == "" {
+		return fmt.Errorf("build: -in and -out are required")
+	}
+
+	start := time.Now()
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pairs, err := readCSV(f, *xCol, *yCol, *header)
+	if err != nil {
+		return err
+	}
+	read := time.Since(start)
+
+	start = time.Now()
+	bush := kdbush.NewBushFromPairs(pairs, *nodeSize)
+	built := time.Since(start)
+
+	if err := saveFile(*out, bush); err != nil {
+		return err
+	}
+	fmt.Printf("%d points, read %v, built %v\n", len(pairs), read, built)
+	return nil
+}
+
+func runBuildSpec(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var spec kdbush.BuildSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("build: %s: %v", path, err)
+	}
+	if spec.Output == "" {
+		return fmt.Errorf("build: %s: output is required", path)
+	}
+	start := time.Now()
+	bush, err := kdbush.BuildFromSpec(context.Background(), spec)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d points, built %v\n", len(bush.Idxs), time.Since(start))
+	return nil
+}
+
+func runInfo(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("info: index file is required")
+	}
+	bush, took, err := loadFile(args[0])
+	if err != nil {
+		return err
+	}
+	printInfo(os.Stdout, bush)
+	fmt.Printf("loaded in %v\n", took)
+	return nil
+}
+
+func runRepl(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("repl: index file is required")
+	}
+	bush, took, err := loadFile(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("loaded %d points in %v, type help for commands\n", len(bush.Idxs), took)
+	newRepl(bush, os.Stdout).run(os.Stdin, true)
+	return nil
+}
+
+func loadFile(path string) (*kdbush.KDBush, time.Duration, error) {
+	start := time.Now()
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	bush, err := kdbush.Load(f)
+	return bush, time.Since(start), err
+}
+
+func saveFile(path string, bush *kdbush.KDBush) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := bush.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printInfo(w io.Writer, bush *kdbush.KDBush) {
+	s := bush.Stats()
+	fmt.Fprintf(w, "points: %d, input: %d, removed: %d, node size: %d\n", s.Points, s.Input, s.Removed, bush.NodeSize)
+	if names := bush.AuxNames(); len(names) > 0 {
+		fmt.Fprintf(w, "aux arrays: %v\n", names)
+	}
+}
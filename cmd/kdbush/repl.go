@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+const replHelp = `commands:
+  range minX minY maxX maxY   points in the bounding box
+  within x y radius           points within the radius
+  knn x y k                   k nearest points
+  limit n                     print at most n result indexes (0 - only counts)
+  info                        index statistics
+  help                        this help
+  quit                        exit`
+
+type repl struct {
+	bush  *kdbush.KDBush
+	out   io.Writer
+	limit int
+}
+
+func newRepl(bush *kdbush.KDBush, out io.Writer) *repl {
+	return &repl{bush: bush, out: out, limit: 10}
+}
+
+// reads commands line by line until EOF or quit
+func (r *repl) run(in io.Reader, prompt bool) {
+	scanner := bufio.NewScanner(in)
+	for {
+		if prompt {
+			fmt.Fprint(r.out, "> ")
+		}
+		if !scanner.Scan() {
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return
+		}
+		if err := r.exec(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(r.out, "error:", err)
+		}
+	}
+}
+
+func (r *repl) exec(cmd string, args []string) error {
+	switch cmd {
+	case "help":
+		fmt.Fprintln(r.out, replHelp)
+		return nil
+	case "info":
+		printInfo(r.out, r.bush)
+		return nil
+	case "limit":
+		n, err := intArgs(args, 1)
+		if err != nil {
+			return err
+		}
+		r.limit = n[0]
+		return nil
+	case "range":
+		a, err := floatArgs(args, 4)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		result := r.bush.Range(a[0], a[1], a[2], a[3])
+		r.print(result, time.Since(start))
+		return nil
+	case "within":
+		a, err := floatArgs(args, 3)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		result := r.bush.Within(&kdbush.SimplePoint{X: a[0], Y: a[1]}, a[2])
+		r.print(result, time.Since(start))
+		return nil
+	case "knn":
+		if len(args) != 3 {
+			return fmt.Errorf("expected 3 arguments")
+		}
+		a, err := floatArgs(args[:2], 2)
+		if err != nil {
+			return err
+		}
+		k, err := intArgs(args[2:], 1)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		result := r.bush.Nearest(&kdbush.SimplePoint{X: a[0], Y: a[1]}, k[0], 0)
+		r.print(result, time.Since(start))
+		return nil
+	}
+	return fmt.Errorf("unknown command %q, type help for commands", cmd)
+}
+
+func (r *repl) print(result []int, took time.Duration) {
+	fmt.Fprintf(r.out, "%d results in %v\n", len(result), took)
+	if r.limit <= 0 || len(result) == 0 {
+		return
+	}
+	shown := result
+	if len(shown) > r.limit {
+		shown = shown[:r.limit]
+	}
+	parts := make([]string, len(shown))
+	for i, idx := range shown {
+		parts[i] = strconv.Itoa(idx)
+	}
+	more := ""
+	if len(result) > len(shown) {
+		more = fmt.Sprintf(" ... and %d more", len(result)-len(shown))
+	}
+	fmt.Fprintf(r.out, "%s%s\n", strings.Join(parts, " "), more)
+}
+
+func floatArgs(args []string, n int) ([]float64, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("expected %d arguments", n)
+	}
+	values := make([]float64, n)
+	for i, a := range args {
+		v, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", a)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func intArgs(args []string, n int) ([]int, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("expected %d arguments", n)
+	}
+	values := make([]int, n)
+	for i, a := range args {
+		v, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", a)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
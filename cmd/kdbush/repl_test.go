@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+func TestRepl(t *testing.T) {
+	pairs, err := readCSV(strings.NewReader("x,y\n10,10\n15,11\n1,22\n22,22\n34,12\n19,19\n32,34\n"), 0, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bush := kdbush.NewBushFromPairs(pairs, 10)
+
+	var out bytes.Buffer
+	newRepl(bush, &out).run(strings.NewReader(`
+range 10 10 21 21
+within 15 15 10
+knn 0 0 2
+limit 1
+range 0 0 100 100
+bogus
+range 1 2
+quit
+range 0 0 1 1
+`), false)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []string{"3 results", "0 1 5", "4 results", "0 1 3 5", "2 results", "0 1", "7 results", "0 ... and 6 more", "error: unknown command", "error: expected 4 arguments"}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(lines[i], e) {
+			t.Errorf("line %d: expected %q, got %q", i, e, lines[i])
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	_, err := readCSV(strings.NewReader("1,2\n3\n"), 0, 1, false)
+	if err == nil {
+		t.Error("expected error for short line")
+	}
+	_, err = readCSV(strings.NewReader("1,abc\n"), 0, 1, false)
+	if err == nil {
+		t.Error("expected error for invalid number")
+	}
+}
@@ -0,0 +1,140 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// compact position of the original index, that is not indexed
+const absentPos32 = math.MaxUint32
+
+// Stores coordinates as float32 and indices as uint32, that halves memory of the index,
+// e.g. for web mercator pixel coordinates, where float64 precision is not needed.
+// Coordinates are rounded to float32 at build, queries still take and return float64 values.
+// Idxs and Coords fields of the index are nil, Save and other serialization write the usual format.
+// The option is ignored for inputs of more than math.MaxUint32 points.
+func WithCompactStorage() Option {
+	return func(cfg *buildConfig) {
+		cfg.compact = true
+	}
+}
+
+// Tells whether the index stores coordinates as float32, see WithCompactStorage
+func (bush *KDBush) Compact() bool {
+	return bush.coords32 != nil
+}
+
+// moves Idxs, Coords and pos into compact arrays, coordinates are already rounded to float32 at build
+func (bush *KDBush) compact() {
+	if bush.originalCount() > math.MaxUint32 {
+		return
+	}
+	bush.coords32 = make([]float32, len(bush.Coords))
+	for i, v := range bush.Coords {
+		bush.coords32[i] = float32(v)
+	}
+	bush.idxs32 = make([]uint32, len(bush.Idxs))
+	for i, idx := range bush.Idxs {
+		bush.idxs32[i] = uint32(idx)
+	}
+	//positions are less than originalCount, so math.MaxUint32 is free for not indexed points
+	bush.pos32 = make([]uint32, len(bush.pos))
+	for idx, i := range bush.pos {
+		if i < 0 {
+			bush.pos32[idx] = absentPos32
+		} else {
+			bush.pos32[idx] = uint32(i)
+		}
+	}
+	bush.Idxs, bush.Coords, bush.pos = nil, nil, nil
+}
+
+// x coordinate of the point on position i in the tree
+func (bush *KDBush) x(i int) float64 {
+	if bush.coords32 != nil {
+		return float64(bush.coords32[2*i])
+	}
+	return bush.Coords[2*i]
+}
+
+// y coordinate of the point on position i in the tree
+func (bush *KDBush) y(i int) float64 {
+	if bush.coords32 != nil {
+		return float64(bush.coords32[2*i+1])
+	}
+	return bush.Coords[2*i+1]
+}
+
+// coordinate of the point on position i by axis, 0 for x, 1 for y
+func (bush *KDBush) coord(i, axis int) float64 {
+	if bush.coords32 != nil {
+		return float64(bush.coords32[2*i+axis])
+	}
+	return bush.Coords[2*i+axis]
+}
+
+// original index of the point on position i in the tree
+func (bush *KDBush) idx(i int) int {
+	if bush.idxs32 != nil {
+		return int(bush.idxs32[i])
+	}
+	return bush.Idxs[i]
+}
+
+// number of points in the tree
+func (bush *KDBush) size() int {
+	if bush.idxs32 != nil {
+		return len(bush.idxs32)
+	}
+	return len(bush.Idxs)
+}
+
+// position of the original index in the tree, -1 if it's not indexed, idx should be less than originalCount
+func (bush *KDBush) treePos(idx int) int {
+	if bush.pos32 != nil {
+		if i := bush.pos32[idx]; i != absentPos32 {
+			return int(i)
+		}
+		return -1
+	}
+	return bush.pos[idx]
+}
+
+// number of original indices in pos
+func (bush *KDBush) posLen() int {
+	if bush.pos32 != nil {
+		return len(bush.pos32)
+	}
+	return len(bush.pos)
+}
+
+// length of Coords
+func (bush *KDBush) coordsLen() int {
+	if bush.coords32 != nil {
+		return len(bush.coords32)
+	}
+	return len(bush.Coords)
+}
+
+// Idxs of the index, expanded from compact storage if needed
+func (bush *KDBush) idxSlice() []int {
+	if bush.idxs32 == nil {
+		return bush.Idxs
+	}
+	idxs := make([]int, len(bush.idxs32))
+	for i, idx := range bush.idxs32 {
+		idxs[i] = int(idx)
+	}
+	return idxs
+}
+
+// Coords of the index, expanded from compact storage if needed
+func (bush *KDBush) coordSlice() []float64 {
+	if bush.coords32 == nil {
+		return bush.Coords
+	}
+	coords := make([]float64, len(bush.coords32))
+	for i, v := range bush.coords32 {
+		coords[i] = float64(v)
+	}
+	return coords
+}
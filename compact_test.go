@@ -0,0 +1,81 @@
+package kdbush
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_WithCompactStorage(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	points := make([]Point, 20000)
+	for i := range points {
+		//pixel coordinates of a tile pyramid, exact in float32
+		points[i] = &SimplePoint{X: float64(rnd.Intn(1 << 20)), Y: float64(rnd.Intn(1 << 20))}
+	}
+	plain := NewBushWithOptions(points, 16)
+	bush := NewBushWithOptions(points, 16, WithCompactStorage())
+	assert.True(t, bush.Compact())
+	assert.False(t, plain.Compact())
+	assert.Nil(t, bush.Idxs)
+	assert.Nil(t, bush.Coords)
+	assert.NoError(t, bush.Verify())
+	assert.Equal(t, plain.SpatialOrder(), bush.SpatialOrder())
+	assert.Equal(t, plain.ContentHash(), bush.ContentHash())
+	assert.Equal(t, plain.HilbertKeys(16), bush.HilbertKeys(16))
+	assert.Less(t, bush.MemoryUsage(), plain.MemoryUsage()*6/10)
+
+	q := &SimplePoint{X: 1 << 19, Y: 1 << 19}
+	assert.Equal(t, plain.Range(1e5, 2e5, 4e5, 6e5), bush.Range(1e5, 2e5, 4e5, 6e5))
+	assert.Equal(t, plain.Within(q, 5e4), bush.Within(q, 5e4))
+	assert.Equal(t, plain.Nearest(q, 20, 0), bush.Nearest(q, 20, 0))
+	assert.Equal(t, plain.RangeCount(1e5, 2e5, 4e5, 6e5), bush.RangeCount(1e5, 2e5, 4e5, 6e5))
+	for _, idx := range []int{0, 7, 19999} {
+		assert.Equal(t, plain.TreePos(idx), bush.TreePos(idx))
+	}
+
+	bush.Remove(plain.Nearest(q, 1, 0)[0])
+	plain.Remove(plain.Nearest(q, 1, 0)[0])
+	assert.Equal(t, plain.Nearest(q, 20, 0), bush.Nearest(q, 20, 0))
+
+	var buf bytes.Buffer
+	assert.NoError(t, bush.Save(&buf))
+	loaded, err := Load(&buf)
+	assert.NoError(t, err)
+	assert.False(t, loaded.Compact())
+	assert.Equal(t, plain.Idxs, loaded.Idxs)
+	assert.Equal(t, plain.Coords, loaded.Coords)
+
+	data, err := bush.MarshalJSON()
+	assert.NoError(t, err)
+	expected, _ := plain.MarshalJSON()
+	assert.Equal(t, expected, data)
+}
+
+func TestKDBush_WithCompactStorageRounding(t *testing.T) {
+	points := []Point{&SimplePoint{X: 0.1, Y: 0.2}, &SimplePoint{X: 1.00000001, Y: 1}, &SimplePoint{X: 1, Y: 1}, &SimplePoint{X: math.Pi, Y: -math.E}}
+	rounded := make([]Point, len(points))
+	for i, p := range points {
+		x, y := p.Coordinates()
+		rounded[i] = &SimplePoint{X: float64(float32(x)), Y: float64(float32(y))}
+	}
+	bush := NewBushWithOptions(points, 2, WithCompactStorage(), WithDedup())
+	plain := NewBushWithOptions(rounded, 2, WithDedup())
+	assert.Equal(t, plain.Stats(), bush.Stats())
+	assert.Equal(t, Stats{Input: 4, Points: 3, Collapsed: 1}, bush.Stats(), "1.00000001 is 1 in float32")
+	assert.Equal(t, []int{1, 2}, bush.Duplicates(2))
+	assert.Equal(t, plain.Range(0, 0, 2, 2), bush.Range(0, 0, 2, 2))
+	absent := 0
+	for idx := range points {
+		assert.Equal(t, plain.TreePos(idx), bush.TreePos(idx))
+		if bush.TreePos(idx) < 0 {
+			absent++
+		}
+	}
+	assert.Equal(t, 1, absent, "collapsed point is not indexed")
+	i := bush.TreePos(3)
+	assert.Equal(t, [2]float64{float64(float32(math.Pi)), float64(float32(-math.E))}, [2]float64{bush.x(i), bush.y(i)})
+}
@@ -0,0 +1,43 @@
+package kdbush
+
+import (
+	sorting "sort"
+)
+
+// Groups points into connected components of the proximity graph: two points are connected, when they are within maxDist,
+// and components are closed under this relation, e.g. hotspots or segments of a track with gaps longer than maxDist.
+// Neighbors are expanded through the index, one traversal per point. Removed points are skipped.
+// Every component is sorted by original index, components are sorted by their first index.
+func (bush *KDBush) ConnectedComponents(maxDist float64) [][]int {
+	components := [][]int{}
+	seen := make([]bool, bush.originalCount())
+	r2 := maxDist * maxDist
+	cfg := &queryConfig{}
+	var queue []int //positions in the tree of points to expand
+
+	for idx := 0; idx < bush.posLen(); idx++ {
+		i := bush.treePos(idx)
+		if i < 0 || seen[idx] || bush.removedAt(i) {
+			continue
+		}
+		seen[idx] = true
+		component := []int{idx}
+		queue = append(queue[:0], i)
+		for len(queue) > 0 {
+			p := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y := bush.x(p), bush.y(p)
+			bush.search(x-maxDist, y-maxDist, x+maxDist, y+maxDist, cfg, func(j int) bool {
+				if other := bush.idx(j); !seen[other] && sqrtDist(bush.x(j), bush.y(j), x, y) <= r2 {
+					seen[other] = true
+					component = append(component, other)
+					queue = append(queue, j)
+				}
+				return true
+			})
+		}
+		sorting.Ints(component)
+		components = append(components, component)
+	}
+	return components
+}
@@ -0,0 +1,80 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_ConnectedComponents(t *testing.T) {
+	//chain along x with gaps, next point is 1 apart, gaps are 5
+	points := []Point{}
+	for i := 0; i < 30; i++ {
+		x := float64(i) + 4*float64(i/10)
+		points = append(points, &SimplePoint{X: x, Y: 0})
+	}
+	bush := NewBush(points, 4)
+	components := bush.ConnectedComponents(1)
+	assert.Len(t, components, 3)
+	for c, component := range components {
+		assert.Len(t, component, 10)
+		assert.Equal(t, c*10, component[0])
+	}
+	assert.Len(t, bush.ConnectedComponents(0.5), 30)
+	assert.Len(t, bush.ConnectedComponents(5), 1)
+
+	//removed point splits the chain
+	bush.Remove(5)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, bush.ConnectedComponents(1)[0])
+	assert.Equal(t, []int{6, 7, 8, 9}, bush.ConnectedComponents(1)[1])
+
+	assert.Empty(t, NewBush(nil, 4).ConnectedComponents(1))
+}
+
+func TestKDBush_ConnectedComponentsBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	points := make([]Point, 2000)
+	for i := range points {
+		points[i] = &SimplePoint{X: rnd.Float64() * 100, Y: rnd.Float64() * 100}
+	}
+	bush := NewBush(points, 16)
+	const maxDist = 2
+
+	//union-find over all pairs
+	parent := make([]int, len(points))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, a := range points {
+		ax, ay := a.Coordinates()
+		for j := i + 1; j < len(points); j++ {
+			bx, by := points[j].Coordinates()
+			if sqrtDist(ax, ay, bx, by) <= maxDist*maxDist {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	components := bush.ConnectedComponents(maxDist)
+	total := 0
+	for _, component := range components {
+		for _, idx := range component {
+			assert.Equal(t, find(component[0]), find(idx))
+		}
+		total += len(component)
+	}
+	assert.Equal(t, len(points), total)
+	roots := map[int]bool{}
+	for i := range points {
+		roots[find(i)] = true
+	}
+	assert.Len(t, components, len(roots))
+}
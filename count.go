@@ -0,0 +1,82 @@
+package kdbush
+
+// Same as Range, but returns only the number of items, without building the result slice.
+// Subtrees fully inside of the box are counted without visiting their points.
+func (bush *KDBush) RangeCount(minX, minY, maxX, maxY float64) int {
+	//depth of the tree is tiny, so the stack fits into the array and doesn't allocate
+	var buf [64]treeNode
+	stack := append(buf[:0], bush.rootNode())
+	count := 0
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.right < n.left || n.minX > maxX || n.maxX < minX || n.minY > maxY || n.maxY < minY {
+			continue
+		}
+		if n.minX >= minX && n.maxX <= maxX && n.minY >= minY && n.maxY <= maxY {
+			count += bush.liveCount(n.left, n.right)
+			continue
+		}
+
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				x, y := bush.x(i), bush.y(i)
+				if x >= minX && x <= maxX && y >= minY && y <= maxY && !bush.removedAt(i) {
+					count++
+				}
+			}
+			continue
+		}
+
+		m := floor(float64(n.left+n.right) / 2.0)
+		x, y := bush.x(m), bush.y(m)
+		if x >= minX && x <= maxX && y >= minY && y <= maxY && !bush.removedAt(m) {
+			count++
+		}
+		l, r := bush.children(n, m)
+		stack = append(stack, l, r)
+	}
+	return count
+}
+
+// Same as Within, but returns only the number of items, without building the result slice.
+// Subtrees fully inside of the circle are counted without visiting their points.
+func (bush *KDBush) WithinCount(point Point, radius float64) int {
+	qx, qy := point.Coordinates()
+	r2 := radius * radius
+	var buf [64]treeNode
+	stack := append(buf[:0], bush.rootNode())
+	count := 0
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.right < n.left || boxDistSq(qx, qy, n) > r2 {
+			continue
+		}
+		if (Circle{qx, qy, radius}).ContainsBox(n.minX, n.minY, n.maxX, n.maxY) {
+			count += bush.liveCount(n.left, n.right)
+			continue
+		}
+
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				if sqrtDist(bush.x(i), bush.y(i), qx, qy) <= r2 && !bush.removedAt(i) {
+					count++
+				}
+			}
+			continue
+		}
+
+		m := floor(float64(n.left+n.right) / 2.0)
+		if sqrtDist(bush.x(m), bush.y(m), qx, qy) <= r2 && !bush.removedAt(m) {
+			count++
+		}
+		l, r := bush.children(n, m)
+		stack = append(stack, l, r)
+	}
+	return count
+}
@@ -0,0 +1,56 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_RangeCount(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.Equal(t, len(bush.Range(20, 30, 50, 70)), bush.RangeCount(20, 30, 50, 70))
+	assert.Equal(t, len(testPoints), bush.RangeCount(0, 0, 100, 100))
+	assert.Equal(t, 0, bush.RangeCount(200, 200, 300, 300))
+
+	bush.RemoveRange(0, 0, 50, 50)
+	assert.Equal(t, len(bush.Range(0, 0, 100, 100)), bush.RangeCount(-1, -1, 101, 101))
+	assert.Equal(t, len(bush.Range(20, 30, 50, 70)), bush.RangeCount(20, 30, 50, 70))
+
+	assert.Equal(t, 0, NewBush(nil, 10).RangeCount(0, 0, 1, 1))
+}
+
+func TestKDBush_WithinCount(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.Equal(t, len(bush.Within(&SimplePoint{50, 50}, 20)), bush.WithinCount(&SimplePoint{50, 50}, 20))
+	assert.Equal(t, len(testPoints), bush.WithinCount(&SimplePoint{50, 50}, 100))
+	assert.Equal(t, 0, bush.WithinCount(&SimplePoint{500, 500}, 20))
+
+	bush.RemoveWithin(&SimplePoint{40, 40}, 15)
+	assert.Equal(t, len(bush.Within(&SimplePoint{50, 50}, 20)), bush.WithinCount(&SimplePoint{50, 50}, 20))
+
+	assert.Equal(t, 0, NewBush(nil, 10).WithinCount(&SimplePoint{0, 0}, 1))
+}
+
+func TestKDBush_CountRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	points := make([]Point, 20000)
+	for i := range points {
+		points[i] = &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 1000}
+	}
+	bush := NewBush(points, 16)
+
+	for i := 0; i < 100; i++ {
+		x, y := rnd.Float64()*1000, rnd.Float64()*1000
+		w, h, r := rnd.Float64()*300, rnd.Float64()*300, rnd.Float64()*300
+		assert.Equal(t, len(bush.Range(x, y, x+w, y+h)), bush.RangeCount(x, y, x+w, y+h))
+		assert.Equal(t, len(bush.Within(&SimplePoint{x, y}, r)), bush.WithinCount(&SimplePoint{x, y}, r))
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		bush.RangeCount(100, 100, 400, 400)
+	})
+	if !raceEnabled {
+		assert.Zero(t, allocs)
+	}
+}
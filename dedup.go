@@ -0,0 +1,111 @@
+package kdbush
+
+// Collapses points with exactly the same coordinates into one point of the tree, e.g. thousands of addresses of one building.
+// The point with the lowest original index represents the others: queries return only representatives,
+// ExpandDuplicates and RangeExpanded expand them to all original indices, DuplicateCount gives weight of the representative.
+// Other points of the group are not in the tree, their TreePos is -1, removing the representative removes the whole group.
+// Groups are not saved by Save.
+func WithDedup() Option {
+	return func(cfg *buildConfig) {
+		cfg.dedup = true
+	}
+}
+
+// keeps only the first point of every group with the same coordinates in Idxs and Coords, builds dupRep
+func (bush *KDBush) collapseDuplicates() {
+	first := make(map[[2]float64]int, len(bush.Idxs))
+	rep := make([]int, bush.input)
+	for i := range rep {
+		rep[i] = -1
+	}
+	n := 0
+	for i, idx := range bush.Idxs {
+		key := [2]float64{bush.Coords[2*i], bush.Coords[2*i+1]}
+		if r, ok := first[key]; ok {
+			rep[idx] = r
+			continue
+		}
+		first[key] = idx
+		rep[idx] = idx
+		bush.Idxs[n] = idx
+		bush.Coords[2*n], bush.Coords[2*n+1] = key[0], key[1]
+		n++
+	}
+	if n == len(bush.Idxs) {
+		return
+	}
+	bush.collapsed = len(bush.Idxs) - n
+	bush.Idxs, bush.Coords = bush.Idxs[:n], bush.Coords[:2*n]
+	bush.dupRep = rep
+}
+
+// groups original indices by tree position of their representatives, called after derive
+func (bush *KDBush) groupDuplicates() {
+	if bush.dupRep == nil {
+		return
+	}
+	bush.dupStart = make([]int, bush.size()+1)
+	for _, r := range bush.dupRep {
+		if r >= 0 {
+			bush.dupStart[bush.treePos(r)+1]++
+		}
+	}
+	for i := 1; i < len(bush.dupStart); i++ {
+		bush.dupStart[i] += bush.dupStart[i-1]
+	}
+	bush.dupMembers = make([]int, bush.dupStart[bush.size()])
+	next := append([]int(nil), bush.dupStart[:bush.size()]...)
+	for idx, r := range bush.dupRep {
+		if r >= 0 {
+			p := bush.treePos(r)
+			bush.dupMembers[next[p]] = idx
+			next[p]++
+		}
+	}
+}
+
+// Returns original indices of all points with the same coordinates as the point idx, sorted, including idx itself.
+// Without WithDedup option, or if the point has no duplicates, returns just idx. Returns nil for points not in the index.
+func (bush *KDBush) Duplicates(idx int) []int {
+	if bush.dupRep != nil && idx >= 0 && idx < len(bush.dupRep) && bush.dupRep[idx] >= 0 {
+		p := bush.treePos(bush.dupRep[idx])
+		return append([]int(nil), bush.dupMembers[bush.dupStart[p]:bush.dupStart[p+1]]...)
+	}
+	if bush.TreePos(idx) < 0 {
+		return nil
+	}
+	return []int{idx}
+}
+
+// Number of original points represented by the point idx, 1 without WithDedup option, 0 for points not in the index
+func (bush *KDBush) DuplicateCount(idx int) int {
+	p := bush.TreePos(idx)
+	switch {
+	case p < 0:
+		return 0
+	case bush.dupStart == nil:
+		return 1
+	}
+	return bush.dupStart[p+1] - bush.dupStart[p]
+}
+
+// Replaces every representative in the query result by original indices of all points it represents
+func (bush *KDBush) ExpandDuplicates(result []int) []int {
+	if bush.dupStart == nil {
+		return result
+	}
+	expanded := make([]int, 0, len(result))
+	for _, idx := range result {
+		if p := bush.TreePos(idx); p >= 0 {
+			expanded = append(expanded, bush.dupMembers[bush.dupStart[p]:bush.dupStart[p+1]]...)
+		} else {
+			expanded = append(expanded, idx)
+		}
+	}
+	return expanded
+}
+
+// Same as Range, but returns all original points, also those collapsed by WithDedup option
+func (bush *KDBush) RangeExpanded(minX, minY, maxX, maxY float64) []int {
+	return bush.ExpandDuplicates(bush.Range(minX, minY, maxX, maxY))
+}
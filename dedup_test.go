@@ -0,0 +1,61 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_WithDedup(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	points := make([]Point, 5000)
+	for i := range points {
+		//many points on 100 integer coordinates
+		points[i] = &SimplePoint{X: float64(rnd.Intn(10)), Y: float64(rnd.Intn(10))}
+	}
+	plain := NewBush(points, 8)
+	bush := NewBushWithOptions(points, 8, WithDedup())
+	assert.Len(t, bush.Idxs, 100)
+	assert.Equal(t, Stats{Input: 5000, Points: 100, Collapsed: 4900}, bush.Stats())
+	assert.NoError(t, bush.Verify())
+
+	reps := bush.Range(2, 3, 5, 7)
+	assert.Len(t, reps, 4*5)
+	assert.Equal(t, sortedInts(plain.Range(2, 3, 5, 7)), sortedInts(bush.RangeExpanded(2, 3, 5, 7)))
+
+	total := 0
+	for _, idx := range reps {
+		group := bush.Duplicates(idx)
+		assert.Equal(t, idx, group[0], "representative is the lowest index")
+		assert.Equal(t, len(group), bush.DuplicateCount(idx))
+		x, y := points[idx].Coordinates()
+		for _, member := range group {
+			mx, my := points[member].Coordinates()
+			assert.Equal(t, [2]float64{x, y}, [2]float64{mx, my})
+			assert.Equal(t, group, bush.Duplicates(member))
+		}
+		total += len(group)
+	}
+	assert.Len(t, plain.Range(2, 3, 5, 7), total)
+
+	//removing the representative removes the group
+	idx := reps[0]
+	member := bush.Duplicates(idx)[1]
+	assert.False(t, bush.Remove(member))
+	assert.True(t, bush.Remove(idx))
+	assert.NotContains(t, bush.RangeExpanded(2, 3, 5, 7), member)
+	assert.Nil(t, bush.Duplicates(len(points)))
+	assert.Zero(t, bush.DuplicateCount(member))
+}
+
+func TestKDBush_WithDedupNoDuplicates(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBushWithOptions(points, 10, WithDedup())
+	plain := NewBush(points, 10)
+	assert.Equal(t, plain.Idxs, bush.Idxs)
+	assert.Nil(t, bush.dupStart)
+	assert.Equal(t, []int{3}, bush.Duplicates(3))
+	assert.Equal(t, 1, bush.DuplicateCount(3))
+	assert.Equal(t, plain.Range(20, 30, 50, 70), bush.RangeExpanded(20, 30, 50, 70))
+}
@@ -0,0 +1,95 @@
+package kdbush
+
+import (
+	"fmt"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const modulePath = "github.com/MadAppGang/kdbush"
+
+// Core and all packages built by default should depend only on the standard library,
+// integrations with third party modules should be behind build tags.
+func TestStdlibOnly(t *testing.T) {
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != "." && (strings.HasPrefix(info.Name(), ".") || info.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				if !constraint.IsGoBuild(c.Text) {
+					continue
+				}
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				if !defaultBuild(expr) {
+					return nil
+				}
+			}
+		}
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			first := strings.SplitN(p, "/", 2)[0]
+			if strings.Contains(first, ".") && p != modulePath && !strings.HasPrefix(p, modulePath+"/") {
+				t.Errorf("%s imports %s, non standard library imports should be behind build tags", path, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// platforms, files built by default on any of them are checked
+var depsPlatforms = [][2]string{
+	{runtime.GOOS, runtime.GOARCH}, {"linux", "amd64"}, {"darwin", "arm64"}, {"windows", "amd64"}, {"js", "wasm"}, {"wasip1", "wasm"},
+}
+
+// operating systems, that satisfy unix build tag
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true, "illumos": true,
+	"ios": true, "linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// tells whether the file with the build constraint is built without opt-in tags, like sqlite or prometheus, on some platform
+func defaultBuild(expr constraint.Expr) bool {
+	for _, platform := range depsPlatforms {
+		goos, goarch := platform[0], platform[1]
+		ok := expr.Eval(func(tag string) bool {
+			switch {
+			case tag == goos, tag == goarch, tag == "gc", tag == "cgo":
+				return true
+			case tag == "unix":
+				return unixOS[goos]
+			}
+			return slices.Contains(build.Default.ReleaseTags, tag)
+		})
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package kdbush
+
+// Name of int64 auxiliary array with stable point IDs, Diff matches points by it when both indexes have it
+const IDAux = "id"
+
+// Point, that changed coordinates between two builds
+type Moved struct {
+	A, B int //index of the point in the original input of a and b
+}
+
+// Difference between two builds of the index
+type DiffReport struct {
+	Added     []int   //indexes in b of points, that are not in a
+	Removed   []int   //indexes in a of points, that are not in b
+	Moved     []Moved //points with the same ID, but different coordinates
+	Unchanged int     //number of points, that are the same in both
+}
+
+// Returns true if there is no difference
+func (r DiffReport) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Moved) == 0
+}
+
+// Compares two indexes, points removed with tombstones are not taken into account.
+// If both indexes have IDAux array, points are matched by ID, otherwise by coordinates,
+// and moved points are reported as removed and added.
+func Diff(a, b *KDBush) DiffReport {
+	idsA, okA := GetAux[int64](a, IDAux)
+	idsB, okB := GetAux[int64](b, IDAux)
+	if okA && okB {
+		return diffByID(a, b, idsA, idsB)
+	}
+	return diffByCoords(a, b)
+}
+
+// calls fn for every live point in original index order
+func (bush *KDBush) eachPoint(fn func(idx int, x, y float64)) {
+	for idx := 0; idx < bush.posLen(); idx++ {
+		if i := bush.treePos(idx); i >= 0 && !bush.removedAt(i) {
+			fn(idx, bush.x(i), bush.y(i))
+		}
+	}
+}
+
+func diffByCoords(a, b *KDBush) DiffReport {
+	report := DiffReport{Added: []int{}, Removed: []int{}, Moved: []Moved{}}
+	inA := map[[2]float64][]int{}
+	a.eachPoint(func(idx int, x, y float64) {
+		inA[[2]float64{x, y}] = append(inA[[2]float64{x, y}], idx)
+	})
+	b.eachPoint(func(idx int, x, y float64) {
+		key := [2]float64{x, y}
+		if same := inA[key]; len(same) > 0 {
+			inA[key] = same[1:]
+			report.Unchanged++
+		} else {
+			report.Added = append(report.Added, idx)
+		}
+	})
+	a.eachPoint(func(idx int, x, y float64) {
+		key := [2]float64{x, y}
+		if rest := inA[key]; len(rest) > 0 && rest[0] == idx {
+			inA[key] = rest[1:]
+			report.Removed = append(report.Removed, idx)
+		}
+	})
+	return report
+}
+
+func diffByID(a, b *KDBush, idsA, idsB []int64) DiffReport {
+	type item struct {
+		idx  int
+		x, y float64
+	}
+	report := DiffReport{Added: []int{}, Removed: []int{}, Moved: []Moved{}}
+	inA := map[int64]item{}
+	a.eachPoint(func(idx int, x, y float64) {
+		inA[idsA[idx]] = item{idx, x, y}
+	})
+	b.eachPoint(func(idx int, x, y float64) {
+		id := idsB[idx]
+		old, ok := inA[id]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, idx)
+		case old.x == x && old.y == y:
+			report.Unchanged++
+		default:
+			report.Moved = append(report.Moved, Moved{old.idx, idx})
+		}
+		delete(inA, id)
+	})
+	a.eachPoint(func(idx int, x, y float64) {
+		if _, ok := inA[idsA[idx]]; ok {
+			report.Removed = append(report.Removed, idx)
+		}
+	})
+	return report
+}
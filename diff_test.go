@@ -0,0 +1,36 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff_Coords(t *testing.T) {
+	a := NewBushFromPairs([][2]float64{{1, 1}, {2, 2}, {3, 3}, {3, 3}}, 2)
+	b := NewBushFromPairs([][2]float64{{3, 3}, {1, 1}, {4, 4}}, 2)
+
+	report := Diff(a, b)
+	assert.Equal(t, []int{2}, report.Added)
+	assert.Equal(t, []int{1, 3}, report.Removed)
+	assert.Empty(t, report.Moved)
+	assert.Equal(t, 2, report.Unchanged)
+	assert.True(t, Diff(a, a).Empty())
+
+	b.Remove(2)
+	assert.Empty(t, Diff(a, b).Added)
+}
+
+func TestDiff_IDs(t *testing.T) {
+	a := NewBushFromPairs([][2]float64{{1, 1}, {2, 2}, {3, 3}}, 2)
+	b := NewBushFromPairs([][2]float64{{3, 3}, {5, 5}, {2, 2}}, 2)
+	SetAux(a, IDAux, []int64{10, 20, 30})
+	SetAux(b, IDAux, []int64{30, 10, 40})
+
+	report := Diff(a, b)
+	assert.Equal(t, []int{2}, report.Added)
+	assert.Equal(t, []int{1}, report.Removed)
+	assert.Equal(t, []Moved{{A: 0, B: 1}}, report.Moved)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.False(t, report.Empty())
+}
@@ -10,7 +10,7 @@
 //
 // 2. 2 dimensional
 //
-// 3. indexing 16-40 times faster then  rtreego(https://github.com/dhconnelly/rtreego) (TODO: benchmark)
+// 3. fast indexing and queries, benchmarks against brute force and rtreego(https://github.com/dhconnelly/rtreego) are in bench_test.go
 //
 // 4. Implements radius search  (rtreego and go.geo only have range search)
 //
@@ -28,6 +28,19 @@
 //
 // C++11 port: https://github.com/mourner/kdbush.hpp
 //
+// Dependencies
+//
+// The package and all packages built by default depend only on the standard library,
+// so the core could be used in constrained environments (TinyGo, FIPS builds).
+// Integrations, that need third party modules, are behind build tags, e.g. tests of sqlstore
+// need "sqlite" tag: go test -tags sqlite ./sqlstore. TestStdlibOnly enforces it.
+//
+// Concurrency
+//
+// Queries never modify the index, so it could be queried from any number of goroutines at the same time.
+// Removals and registering of auxiliary arrays are not safe to run concurrently with queries,
+// call Freeze before sharing the index to forbid them. TestKDBush_ConcurrentQueries checks it with -race.
+//
 // If you liked the project, start it please: https://github.com/MadAppGang/kdbush
 //
 package kdbush
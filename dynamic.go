@@ -0,0 +1,190 @@
+package kdbush
+
+import (
+	"sync"
+)
+
+// Default number of buffered inserts, that starts rebuild of DynamicBush
+const DefaultRebuildThreshold = 1024
+
+// DynamicBush is a mostly static index with occasional updates.
+// Inserted points go to a small unsorted buffer, deleted ones are marked with tombstones, queries merge both.
+// When the buffer exceeds the threshold, the static tree is rebuilt in background, queries and updates are not blocked meanwhile.
+// Points are identified by ids, returned by Insert, ids are stable across rebuilds.
+// Safe for concurrent use.
+type DynamicBush struct {
+	nodeSize  int
+	threshold int
+
+	mu      sync.RWMutex
+	nextID  int
+	points  map[int][2]float64 //all live points by id
+	tree    *KDBush
+	treeIDs []int       //ids by original index of the tree
+	treePos map[int]int //original index in the tree by id
+	buffer  []int       //ids inserted after the tree snapshot, could contain deleted ones
+
+	rebuilding bool
+	snapshot   int   //length of the buffer, included into the tree being rebuilt
+	deferred   []int //ids of tree points deleted during rebuild
+	done       chan struct{}
+}
+
+// Creates empty dynamic index, threshold <= 0 means DefaultRebuildThreshold
+func NewDynamicBush(nodeSize, threshold int) *DynamicBush {
+	if threshold <= 0 {
+		threshold = DefaultRebuildThreshold
+	}
+	return &DynamicBush{
+		nodeSize:  nodeSize,
+		threshold: threshold,
+		points:    map[int][2]float64{},
+		tree:      NewBushFromPairs(nil, nodeSize),
+		treePos:   map[int]int{},
+	}
+}
+
+// Adds the point, returns its id
+func (d *DynamicBush) Insert(x, y float64) (id int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id = d.nextID
+	d.nextID++
+	d.points[id] = [2]float64{x, y}
+	d.buffer = append(d.buffer, id)
+	if len(d.buffer) > d.threshold && !d.rebuilding {
+		d.startRebuild()
+	}
+	return id
+}
+
+// Deletes the point by id, returns false if there is no such point
+func (d *DynamicBush) Delete(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.points[id]; !ok {
+		return false
+	}
+	delete(d.points, id)
+	if idx, ok := d.treePos[id]; ok {
+		d.tree.Remove(idx)
+	}
+	if d.rebuilding {
+		d.deferred = append(d.deferred, id)
+	}
+	return true
+}
+
+// Number of live points
+func (d *DynamicBush) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.points)
+}
+
+// Finds all points within the bounding box, returns their ids
+func (d *DynamicBush) Range(minX, minY, maxX, maxY float64) []int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	result := d.tree.Range(minX, minY, maxX, maxY)
+	for i, idx := range result {
+		result[i] = d.treeIDs[idx]
+	}
+	for _, id := range d.buffer {
+		if p, ok := d.points[id]; ok && p[0] >= minX && p[0] <= maxX && p[1] >= minY && p[1] <= maxY {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// Finds all points within the radius from the query point, returns their ids
+func (d *DynamicBush) Within(point Point, radius float64) []int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	result := d.tree.Within(point, radius)
+	for i, idx := range result {
+		result[i] = d.treeIDs[idx]
+	}
+	qx, qy := point.Coordinates()
+	r2 := radius * radius
+	for _, id := range d.buffer {
+		if p, ok := d.points[id]; ok && sqrtDist(p[0], p[1], qx, qy) <= r2 {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// Rebuilds the static tree from all live points and waits for it
+func (d *DynamicBush) Rebuild() {
+	for {
+		d.Wait()
+		d.mu.Lock()
+		if !d.rebuilding {
+			d.startRebuild()
+			d.mu.Unlock()
+			d.Wait()
+			return
+		}
+		d.mu.Unlock()
+	}
+}
+
+// Waits until background rebuilds, if any, are finished
+func (d *DynamicBush) Wait() {
+	for {
+		d.mu.RLock()
+		done, rebuilding := d.done, d.rebuilding
+		d.mu.RUnlock()
+		if !rebuilding {
+			return
+		}
+		<-done
+	}
+}
+
+// takes snapshot of live points and builds the tree from it in background, called under the lock
+func (d *DynamicBush) startRebuild() {
+	ids := make([]int, 0, len(d.points))
+	pairs := make([][2]float64, 0, len(d.points))
+	for id, p := range d.points {
+		ids = append(ids, id)
+		pairs = append(pairs, p)
+	}
+	d.rebuilding = true
+	d.snapshot = len(d.buffer)
+	d.deferred = nil
+	d.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		tree := NewBushFromPairs(pairs, d.nodeSize)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		defer close(done)
+		d.tree = tree
+		d.treeIDs = ids
+		d.treePos = make(map[int]int, len(ids))
+		for idx, id := range ids {
+			d.treePos[id] = idx
+		}
+		for _, id := range d.deferred {
+			if idx, ok := d.treePos[id]; ok {
+				d.tree.Remove(idx)
+			}
+		}
+		//keep only points inserted during rebuild
+		buffer := []int{}
+		for _, id := range d.buffer[d.snapshot:] {
+			if _, ok := d.points[id]; ok {
+				buffer = append(buffer, id)
+			}
+		}
+		d.buffer = buffer
+		d.rebuilding, d.deferred = false, nil
+		if len(d.buffer) > d.threshold {
+			d.startRebuild()
+		}
+	}(d.done)
+}
@@ -0,0 +1,79 @@
+package kdbush
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamicBush(t *testing.T) {
+	d := NewDynamicBush(10, 16)
+	ids := []int{}
+	for _, p := range testPoints {
+		ids = append(ids, d.Insert(p[0], p[1]))
+	}
+	assert.Equal(t, len(testPoints), d.Len())
+
+	static := NewBush(getTestPoints(), 10)
+	assert.Equal(t, sortedInts(static.Range(20, 30, 50, 70)), sortedInts(d.Range(20, 30, 50, 70)))
+
+	//delete points from the tree and from the buffer
+	d.Wait()
+	for _, id := range []int{ids[3], ids[99]} {
+		assert.True(t, d.Delete(id))
+		static.Remove(id)
+	}
+	assert.False(t, d.Delete(ids[3]))
+	assert.False(t, d.Delete(12345))
+
+	q := &SimplePoint{X: 50, Y: 50}
+	assert.Equal(t, sortedInts(static.Within(q, 40)), sortedInts(d.Within(q, 40)))
+
+	d.Rebuild()
+	assert.Empty(t, d.buffer)
+	assert.Equal(t, sortedInts(static.Range(0, 0, 100, 100)), sortedInts(d.Range(0, 0, 100, 100)))
+	assert.Equal(t, len(testPoints)-2, d.Len())
+}
+
+func TestDynamicBush_Concurrent(t *testing.T) {
+	d := NewDynamicBush(8, 50)
+	rnd := rand.New(rand.NewSource(5))
+	live := map[int]bool{}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				d.Range(0, 0, 50, 50)
+			}
+		}
+	}()
+
+	for i := 0; i < 3000; i++ {
+		if len(live) > 0 && rnd.Intn(3) == 0 {
+			for id := range live {
+				assert.True(t, d.Delete(id))
+				delete(live, id)
+				break
+			}
+			continue
+		}
+		live[d.Insert(rnd.Float64()*100, rnd.Float64()*100)] = true
+	}
+	close(stop)
+	wg.Wait()
+	d.Wait()
+
+	result := d.Range(-1, -1, 101, 101)
+	assert.Len(t, result, len(live))
+	for _, id := range result {
+		assert.True(t, live[id])
+	}
+}
@@ -0,0 +1,37 @@
+package kdbush
+
+import (
+	"unsafe"
+)
+
+// Opens index written by SaveMmap from the byte slice, e.g. a file embedded into the binary:
+//
+//	//go:embed pois.kdbm
+//	var poisIndex []byte
+//
+//	bush, err := kdbush.OpenBushBytes(poisIndex)
+//
+// Arrays of the index point into data without copying, when data is 8 bytes aligned,
+// otherwise data is copied once into aligned memory. Data shouldn't be changed while the index is used.
+// Returns ErrMmapUnsupported on platforms, where in-memory layout differs from the file format,
+// use Save and Load with bytes.Reader there.
+func OpenBushBytes(data []byte) (*KDBush, error) {
+	if !mmapLayoutNative() {
+		return nil, ErrMmapUnsupported
+	}
+	if len(data) == 0 {
+		return nil, ErrInvalidFormat
+	}
+	if uintptr(unsafe.Pointer(&data[0]))%8 != 0 {
+		data = alignedCopy(data)
+	}
+	return parseMmap(data)
+}
+
+// copies data into memory, that is aligned for uint64
+func alignedCopy(data []byte) []byte {
+	words := make([]uint64, (len(data)+7)/8)
+	aligned := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), len(data))
+	copy(aligned, data)
+	return aligned
+}
@@ -0,0 +1,73 @@
+package kdbush
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/pois.kdbm
+var poisIndex []byte
+
+// 10x10 grid, the index embedded as testdata/pois.kdbm
+func poisPoints() []Point {
+	points := make([]Point, 100)
+	for i := range points {
+		points[i] = &SimplePoint{X: float64(i % 10), Y: float64(i / 10)}
+	}
+	return points
+}
+
+func TestOpenBushBytes(t *testing.T) {
+	bush := NewBush(poisPoints(), 8)
+	if *updateGolden {
+		var buf bytes.Buffer
+		assert.NoError(t, bush.SaveMmap(&buf))
+		assert.NoError(t, os.WriteFile("testdata/pois.kdbm", buf.Bytes(), 0644))
+		return
+	}
+
+	embedded, err := OpenBushBytes(poisIndex)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, bush.Idxs, embedded.Idxs)
+	assert.Equal(t, bush.Coords, embedded.Coords)
+	assert.NoError(t, embedded.Verify())
+	assert.Equal(t, bush.Within(&SimplePoint{X: 4, Y: 4}, 2), embedded.Within(&SimplePoint{X: 4, Y: 4}, 2))
+
+	//misaligned data is copied
+	var buf bytes.Buffer
+	assert.NoError(t, bush.SaveMmap(&buf))
+	shifted := append(make([]byte, 1, buf.Len()+1), buf.Bytes()...)[1:]
+	misaligned, err := OpenBushBytes(shifted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, bush.Range(2, 2, 5, 5), misaligned.Range(2, 2, 5, 5))
+		assert.NotEqual(t, unsafe.Pointer(&shifted[mmapHeaderSize]), unsafe.Pointer(&misaligned.Idxs[0]))
+	}
+
+	for _, data := range [][]byte{nil, {}, []byte("KDBM"), []byte("KDBG12345678"), buf.Bytes()[:buf.Len()/2]} {
+		_, err := OpenBushBytes(data)
+		assert.ErrorIs(t, err, ErrInvalidFormat)
+	}
+	for _, pos := range []uint64{math.MaxUint64 - 1, 100, 8} {
+		_, err := OpenBushBytes(corruptMmapPos(buf.Bytes(), 42, pos))
+		assert.ErrorIs(t, err, ErrInvalidFormat)
+	}
+}
+
+func ExampleOpenBushBytes() {
+	bush, err := OpenBushBytes(poisIndex)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(bush.Range(2, 2, 3, 3))
+	// Output: [32 33 23 22]
+}
@@ -0,0 +1,67 @@
+package kdbush
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Generates up to n random locations in bbox at least minDist from all indexed points and from each other,
+// e.g. candidate sites far from existing stores. Candidates are thrown uniformly (Poisson-disk dart throwing)
+// and checked against the index, the check stops at the first point found within minDist.
+// Gives up after 30 failed candidates per requested location, so fewer than n locations are returned,
+// when the empty space of the box is used up. Removed points are ignored.
+func (bush *KDBush) SampleEmptySpace(bbox Rect, minDist float64, n int, rng *rand.Rand) []SimplePoint {
+	result := []SimplePoint{}
+	if n <= 0 || bbox.MinX > bbox.MaxX || bbox.MinY > bbox.MaxY {
+		return result
+	}
+	r2 := minDist * minDist
+	cfg := &queryConfig{}
+
+	//accepted locations by cells of minDist size, to keep them apart
+	cells := map[[2]int][]int{}
+	cell := func(x, y float64) [2]int {
+		if minDist <= 0 {
+			return [2]int{}
+		}
+		return [2]int{int(math.Floor(x / minDist)), int(math.Floor(y / minDist))}
+	}
+
+	for failed := 0; len(result) < n && failed < 30*n; {
+		x := bbox.MinX + rng.Float64()*(bbox.MaxX-bbox.MinX)
+		y := bbox.MinY + rng.Float64()*(bbox.MaxY-bbox.MinY)
+		if minDist > 0 && (bush.occupied(x, y, minDist, cfg) || tooClose(result, cells, cell(x, y), x, y, r2)) {
+			failed++
+			continue
+		}
+		c := cell(x, y)
+		cells[c] = append(cells[c], len(result))
+		result = append(result, SimplePoint{X: x, Y: y})
+	}
+	return result
+}
+
+// returns true if some indexed point is closer than r to x, y
+func (bush *KDBush) occupied(x, y, r float64, cfg *queryConfig) bool {
+	found := false
+	r2 := r * r
+	bush.search(x-r, y-r, x+r, y+r, cfg, func(i int) bool {
+		found = sqrtDist(bush.x(i), bush.y(i), x, y) < r2
+		return !found
+	})
+	return found
+}
+
+// returns true if some of the accepted locations in neighboring cells is closer than sqrt(r2) to x, y
+func tooClose(accepted []SimplePoint, cells map[[2]int][]int, c [2]int, x, y, r2 float64) bool {
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for _, k := range cells[[2]int{c[0] + dx, c[1] + dy}] {
+				if sqrtDist(accepted[k].X, accepted[k].Y, x, y) < r2 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package kdbush
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_SampleEmptySpace(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	box := Rect{MinX: 0, MinY: 0, MaxX: 100, MaxY: 100}
+	sites := bush.SampleEmptySpace(box, 5, 20, rand.New(rand.NewSource(1)))
+	assert.NotEmpty(t, sites)
+	assert.LessOrEqual(t, len(sites), 20)
+	for i, s := range sites {
+		assert.True(t, s.X >= 0 && s.X <= 100 && s.Y >= 0 && s.Y <= 100)
+		assert.Empty(t, bush.Within(&s, 5-1e-9))
+		for _, o := range sites[:i] {
+			assert.GreaterOrEqual(t, math.Hypot(s.X-o.X, s.Y-o.Y), 5.0)
+		}
+	}
+	assert.Equal(t, sites, bush.SampleEmptySpace(box, 5, 20, rand.New(rand.NewSource(1))), "deterministic by rng")
+
+	//no space far enough from all points
+	assert.Empty(t, bush.SampleEmptySpace(box, 200, 5, rand.New(rand.NewSource(1))))
+	assert.Len(t, bush.SampleEmptySpace(box, 0, 5, rand.New(rand.NewSource(1))), 5)
+
+	//removed points free their space
+	crowded := bush.SampleEmptySpace(Rect{MinX: 53, MinY: 0, MaxX: 55, MaxY: 2}, 3, 1, rand.New(rand.NewSource(1)))
+	assert.Empty(t, crowded)
+	for _, idx := range bush.Within(&SimplePoint{X: 54, Y: 1}, 6) {
+		bush.Remove(idx)
+	}
+	assert.Len(t, bush.SampleEmptySpace(Rect{MinX: 53, MinY: 0, MaxX: 55, MaxY: 2}, 3, 1, rand.New(rand.NewSource(1))), 1)
+}
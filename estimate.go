@@ -0,0 +1,153 @@
+package kdbush
+
+import (
+	sorting "sort"
+)
+
+// number of points tested in partially covered leaf to estimate its count
+const estimateSamples = 8
+
+// node of the tree with its bounding box, used by traversals that need node bounds
+type treeNode struct {
+	left, right, axis      int
+	minX, minY, maxX, maxY float64
+}
+
+func (bush *KDBush) rootNode() treeNode {
+	b := bush.bbox
+	return treeNode{0, bush.size() - 1, 0, b[0], b[1], b[2], b[3]}
+}
+
+// split axis of the node with median on position m, axis is the axis of alternating split, that is used by default
+func (bush *KDBush) splitAxis(m, axis int) int {
+	if bush.splitAxes != nil {
+		return int(bush.splitAxes[m])
+	}
+	return axis
+}
+
+// position of the first split axis, that is neither 0 nor 1, -1 if all axes are valid
+func invalidAxis(axes []uint8) int {
+	for i, a := range axes {
+		if a > 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// splits the node by its median point m, returns both children
+func (bush *KDBush) children(n treeNode, m int) (treeNode, treeNode) {
+	l, r := n, n
+	l.right, r.left = m-1, m+1
+	axis := bush.splitAxis(m, n.axis)
+	l.axis, r.axis = (axis+1)%2, (axis+1)%2
+	if axis == 0 {
+		l.maxX, r.minX = bush.x(m), bush.x(m)
+	} else {
+		l.maxY, r.minY = bush.y(m), bush.y(m)
+	}
+	return l, r
+}
+
+// number of not removed points on positions from left to right
+func (bush *KDBush) liveCount(left, right int) int {
+	if bush.removedCount == 0 {
+		return right - left + 1
+	}
+	n := 0
+	for i := left; i <= right; i++ {
+		if !bush.removedAt(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// Estimates number of points within the given bounding box.
+// Subtrees fully inside of the box are counted without visiting the points,
+// partially covered leaves are estimated by testing a sample of their points.
+// Result differs from the exact count by at most maxError fraction of the result,
+// maxError = 0 gives the exact count.
+func (bush *KDBush) EstimateCountRange(minX, minY, maxX, maxY, maxError float64) int {
+	type partial struct {
+		left, right, estimate int
+	}
+	inRange := func(i int) bool {
+		x, y := bush.x(i), bush.y(i)
+		return x >= minX && x <= maxX && y >= minY && y <= maxY && !bush.removedAt(i)
+	}
+	exactCount := func(left, right int) int {
+		n := 0
+		for i := left; i <= right; i++ {
+			if inRange(i) {
+				n++
+			}
+		}
+		return n
+	}
+
+	count := 0
+	partials := []partial{}
+	stack := []treeNode{bush.rootNode()}
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.right < n.left || n.minX > maxX || n.maxX < minX || n.minY > maxY || n.maxY < minY {
+			continue
+		}
+		if n.minX >= minX && n.maxX <= maxX && n.minY >= minY && n.maxY <= maxY {
+			count += bush.liveCount(n.left, n.right)
+			continue
+		}
+
+		if n.right-n.left <= bush.NodeSize {
+			size := n.right - n.left + 1
+			if maxError <= 0 || size <= estimateSamples {
+				count += exactCount(n.left, n.right)
+				continue
+			}
+			step := size / estimateSamples
+			hits, samples := 0, 0
+			for i := n.left; i <= n.right; i += step {
+				if inRange(i) {
+					hits++
+				}
+				samples++
+			}
+			partials = append(partials, partial{n.left, n.right, (hits*size + samples/2) / samples})
+			continue
+		}
+
+		m := floor(float64(n.left+n.right) / 2.0)
+		if inRange(m) {
+			count++
+		}
+		l, r := bush.children(n, m)
+		stack = append(stack, l, r)
+	}
+
+	//every estimated leaf could be wrong by its size at most,
+	//scan the biggest ones exactly until the rest fits into the error budget
+	uncertainty := 0
+	estimate := count
+	for _, p := range partials {
+		uncertainty += p.right - p.left + 1
+		estimate += p.estimate
+	}
+	budget := int(maxError * float64(estimate))
+	sorting.Slice(partials, func(i, j int) bool {
+		return partials[i].right-partials[i].left > partials[j].right-partials[j].left
+	})
+	for _, p := range partials {
+		if uncertainty <= budget {
+			count += p.estimate
+			continue
+		}
+		uncertainty -= p.right - p.left + 1
+		count += exactCount(p.left, p.right)
+	}
+	return count
+}
@@ -0,0 +1,33 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_EstimateCountRange(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.Equal(t, len(bush.Range(20, 30, 50, 70)), bush.EstimateCountRange(20, 30, 50, 70, 0))
+	assert.Equal(t, len(testPoints), bush.EstimateCountRange(0, 0, 100, 100, 0.1))
+	assert.Equal(t, 0, bush.EstimateCountRange(200, 200, 300, 300, 0.1))
+
+	bush.RemoveRange(0, 0, 50, 50)
+	assert.Equal(t, len(bush.Range(0, 0, 100, 100)), bush.EstimateCountRange(-1, -1, 101, 101, 0))
+}
+
+func TestKDBush_EstimateCountRangeError(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	points := make([]Point, 100000)
+	for i := range points {
+		points[i] = &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 1000}
+	}
+	bush := NewBush(points, 64)
+
+	for _, maxError := range []float64{0.01, 0.05, 0.2} {
+		exact := len(bush.Range(100, 150, 700, 620))
+		estimate := bush.EstimateCountRange(100, 150, 700, 620, maxError)
+		assert.InDelta(t, exact, estimate, maxError*float64(estimate), "max error %v", maxError)
+	}
+}
@@ -0,0 +1,78 @@
+// Load generator for the tracking demo.
+//
+//	go run ./examples/tracking/loadgen -url http://localhost:8080 -c 32 -d 30s
+//
+// Sends random /nearby queries around the city center from c concurrent clients
+// and prints throughput and latency percentiles.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+func main() {
+	url := flag.String("url", "http://localhost:8080", "tracking demo base url")
+	clients := flag.Int("c", 16, "concurrent clients")
+	duration := flag.Duration("d", 10*time.Second, "test duration")
+	meters := flag.Float64("meters", 500, "query radius in meters")
+	lng := flag.Float64("lng", 13.405, "city center longitude")
+	lat := flag.Float64("lat", 52.52, "city center latitude")
+	flag.Parse()
+
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: *clients}}
+	deadline := time.Now().Add(*duration)
+
+	var mu sync.Mutex
+	latencies := []time.Duration{}
+	errors := 0
+
+	var wg sync.WaitGroup
+	for c := 0; c < *clients; c++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			local := []time.Duration{}
+			failed := 0
+			for time.Now().Before(deadline) {
+				q := fmt.Sprintf("%s/nearby?lng=%f&lat=%f&meters=%f", *url,
+					*lng+(rnd.Float64()-0.5)*0.3, *lat+(rnd.Float64()-0.5)*0.2, *meters)
+				start := time.Now()
+				resp, err := client.Get(q)
+				if err != nil {
+					failed++
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					failed++
+					continue
+				}
+				local = append(local, time.Since(start))
+			}
+			mu.Lock()
+			latencies = append(latencies, local...)
+			errors += failed
+			mu.Unlock()
+		}(int64(c))
+	}
+	wg.Wait()
+
+	if len(latencies) == 0 {
+		log.Fatalf("no successful requests, %d errors", errors)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	pct := func(p float64) time.Duration { return latencies[int(p*float64(len(latencies)-1))] }
+
+	fmt.Printf("requests: %d, errors: %d, qps: %.0f\n", len(latencies), errors, float64(len(latencies))/duration.Seconds())
+	fmt.Printf("latency p50: %v, p90: %v, p99: %v, max: %v\n", pct(0.5), pct(0.9), pct(0.99), latencies[len(latencies)-1])
+}
@@ -0,0 +1,242 @@
+// Live vehicle tracking demo.
+//
+// Simulates a fleet of vehicles moving around a city, rebuilds the index from
+// fresh positions in the background, and serves radius queries over HTTP/JSON.
+// Queries are always served from the last complete index, rebuilds never block them.
+//
+//	go run ./examples/tracking -vehicles 100000 -interval 1s
+//	curl 'localhost:8080/nearby?lng=13.40&lat=52.52&meters=500'
+//	curl 'localhost:8080/stats'
+//
+// Use ./examples/tracking/loadgen or run.sh to put it under load.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+const earthRadius = 6371008.8 // meters
+
+type vehicle struct {
+	ID      int     `json:"id"`
+	Lng     float64 `json:"lng"`
+	Lat     float64 `json:"lat"`
+	heading float64
+	speed   float64 // meters per second
+}
+
+// vehicle implements kdbush.Point
+func (v *vehicle) Coordinates() (float64, float64) {
+	return v.Lng, v.Lat
+}
+
+// one built index with the positions it was built from
+type generation struct {
+	number   int64
+	bush     *kdbush.KDBush
+	vehicles []vehicle
+	builtAt  time.Time
+	took     time.Duration
+}
+
+type tracker struct {
+	nodeSize int
+	fleet    []vehicle
+	current  atomic.Pointer[generation]
+
+	queries  atomic.Int64
+	rebuilds atomic.Int64
+}
+
+func newTracker(n int, centerLng, centerLat float64, nodeSize int, rnd *rand.Rand) *tracker {
+	t := &tracker{nodeSize: nodeSize, fleet: make([]vehicle, n)}
+	for i := range t.fleet {
+		t.fleet[i] = vehicle{
+			ID:      i,
+			Lng:     centerLng + (rnd.Float64()-0.5)*0.3,
+			Lat:     centerLat + (rnd.Float64()-0.5)*0.2,
+			heading: rnd.Float64() * 2 * math.Pi,
+			speed:   3 + rnd.Float64()*15,
+		}
+	}
+	t.rebuild()
+	return t
+}
+
+// moves every vehicle along its heading, turning a bit randomly
+func (t *tracker) move(dt time.Duration, rnd *rand.Rand) {
+	s := dt.Seconds()
+	for i := range t.fleet {
+		v := &t.fleet[i]
+		v.heading += (rnd.Float64() - 0.5) * 0.5
+		d := v.speed * s
+		v.Lat += d * math.Cos(v.heading) / earthRadius * 180 / math.Pi
+		v.Lng += d * math.Sin(v.heading) / (earthRadius * math.Cos(v.Lat*math.Pi/180)) * 180 / math.Pi
+	}
+}
+
+// builds index from the snapshot of current positions and publishes it
+func (t *tracker) rebuild() {
+	start := time.Now()
+	snapshot := make([]vehicle, len(t.fleet))
+	copy(snapshot, t.fleet)
+
+	points := make([]kdbush.Point, len(snapshot))
+	for i := range snapshot {
+		points[i] = &snapshot[i]
+	}
+	bush := kdbush.NewBush(points, t.nodeSize)
+
+	prev := t.current.Load()
+	number := int64(1)
+	if prev != nil {
+		number = prev.number + 1
+	}
+	t.current.Store(&generation{
+		number:   number,
+		bush:     bush,
+		vehicles: snapshot,
+		builtAt:  time.Now(),
+		took:     time.Since(start),
+	})
+	t.rebuilds.Add(1)
+}
+
+func (t *tracker) run(interval time.Duration, stop <-chan struct{}) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.move(interval, rnd)
+			t.rebuild()
+		}
+	}
+}
+
+type nearbyVehicle struct {
+	vehicle
+	Distance float64 `json:"distance_m"`
+}
+
+type nearbyResponse struct {
+	Generation int64           `json:"generation"`
+	Count      int             `json:"count"`
+	TookMicros int64           `json:"took_us"`
+	Vehicles   []nearbyVehicle `json:"vehicles"`
+}
+
+// finds vehicles within the radius in meters, with their distances
+func withinMeters(g *generation, lng, lat, meters float64) []nearbyVehicle {
+	result := []nearbyVehicle{}
+	for _, idx := range g.bush.WithinMeters(lng, lat, meters) {
+		v := g.vehicles[idx]
+		result = append(result, nearbyVehicle{vehicle: v, Distance: haversine(lng, lat, v.Lng, v.Lat)})
+	}
+	return result
+}
+
+func haversine(lng1, lat1, lng2, lat2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func (t *tracker) handleNearby(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	q := r.URL.Query()
+	lng, err1 := strconv.ParseFloat(q.Get("lng"), 64)
+	lat, err2 := strconv.ParseFloat(q.Get("lat"), 64)
+	meters, err3 := strconv.ParseFloat(q.Get("meters"), 64)
+	if err1 != nil || err2 != nil || err3 != nil || meters < 0 {
+		http.Error(w, "lng, lat and meters are required numbers", http.StatusBadRequest)
+		return
+	}
+
+	g := t.current.Load()
+	found := withinMeters(g, lng, lat, meters)
+	t.queries.Add(1)
+
+	writeJSON(w, nearbyResponse{
+		Generation: g.number,
+		Count:      len(found),
+		TookMicros: time.Since(start).Microseconds(),
+		Vehicles:   found,
+	})
+}
+
+type statsResponse struct {
+	Generation  int64   `json:"generation"`
+	Vehicles    int     `json:"vehicles"`
+	Rebuilds    int64   `json:"rebuilds"`
+	Queries     int64   `json:"queries"`
+	LastBuildMs float64 `json:"last_build_ms"`
+	StalenessMs float64 `json:"staleness_ms"`
+	NodeSize    int     `json:"node_size"`
+}
+
+func (t *tracker) handleStats(w http.ResponseWriter, r *http.Request) {
+	g := t.current.Load()
+	writeJSON(w, statsResponse{
+		Generation:  g.number,
+		Vehicles:    len(g.vehicles),
+		Rebuilds:    t.rebuilds.Load(),
+		Queries:     t.queries.Load(),
+		LastBuildMs: float64(g.took.Microseconds()) / 1000,
+		StalenessMs: float64(time.Since(g.builtAt).Microseconds()) / 1000,
+		NodeSize:    t.nodeSize,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	count := flag.Int("vehicles", 50000, "number of simulated vehicles")
+	interval := flag.Duration("interval", time.Second, "positions update and index rebuild interval")
+	nodeSize := flag.Int("nodesize", 64, "KD-tree node size")
+	lng := flag.Float64("lng", 13.405, "city center longitude")
+	lat := flag.Float64("lat", 52.52, "city center latitude")
+	flag.Parse()
+
+	rnd := rand.New(rand.NewSource(1))
+	t := newTracker(*count, *lng, *lat, *nodeSize, rnd)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.run(*interval, stop)
+	}()
+
+	http.HandleFunc("/nearby", t.handleNearby)
+	http.HandleFunc("/stats", t.handleStats)
+
+	log.Printf("tracking %d vehicles, listening on %s", *count, *addr)
+	err := http.ListenAndServe(*addr, nil)
+	close(stop)
+	wg.Wait()
+	log.Fatal(err)
+}
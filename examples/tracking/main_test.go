@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queries the server from several goroutines while the index is rebuilt, run it with -race
+func TestTracker_ConcurrentQueries(t *testing.T) {
+	tr := newTracker(5000, 13.405, 52.52, 16, rand.New(rand.NewSource(1)))
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		tr.run(time.Millisecond, stop)
+		close(done)
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nearby", tr.handleNearby)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	for c := 0; c < 8; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				resp, err := http.Get(srv.URL + "/nearby?lng=13.405&lat=52.52&meters=1000")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var r nearbyResponse
+				err = json.NewDecoder(resp.Body).Decode(&r)
+				resp.Body.Close()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for _, v := range r.Vehicles {
+					if v.Distance > 1000 {
+						t.Errorf("vehicle %d is %f meters away", v.ID, v.Distance)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-done
+
+	if tr.rebuilds.Load() < 2 {
+		t.Errorf("expected background rebuilds, got %d", tr.rebuilds.Load())
+	}
+}
+
+func TestWithinMeters(t *testing.T) {
+	tr := newTracker(5000, 13.405, 52.52, 16, rand.New(rand.NewSource(1)))
+	g := tr.current.Load()
+	found := withinMeters(g, 13.405, 52.52, 2000)
+
+	expected := 0
+	for _, v := range g.vehicles {
+		if haversine(13.405, 52.52, v.Lng, v.Lat) <= 2000 {
+			expected++
+		}
+	}
+	if len(found) != expected {
+		t.Errorf("expected %d vehicles, got %d", expected, len(found))
+	}
+}
@@ -0,0 +1,20 @@
+#!/bin/sh
+# Starts the tracking demo and puts it under load for a while.
+# Usage: ./examples/tracking/run.sh [vehicles] [clients] [duration]
+set -e
+
+VEHICLES=${1:-100000}
+CLIENTS=${2:-32}
+DURATION=${3:-20s}
+ADDR=localhost:8080
+
+go build -o /tmp/kdbush-tracking ./examples/tracking
+go build -o /tmp/kdbush-loadgen ./examples/tracking/loadgen
+
+/tmp/kdbush-tracking -addr $ADDR -vehicles $VEHICLES -interval 500ms &
+SERVER=$!
+trap 'kill $SERVER' EXIT
+sleep 2
+
+/tmp/kdbush-loadgen -url http://$ADDR -c $CLIENTS -d $DURATION
+curl -s http://$ADDR/stats
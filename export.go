@@ -0,0 +1,101 @@
+package kdbush
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	sorting "sort"
+	"strconv"
+)
+
+// Spatial order of exported points
+type Order int
+
+const (
+	TreeOrder    Order = iota //order of the KD-tree, as points are stored in the index
+	MortonOrder               //Z-order curve
+	HilbertOrder              //Hilbert curve, the best locality
+)
+
+// Format of exported points
+type Format int
+
+const (
+	CSV    Format = iota //idx,x,y lines with header
+	NDJSON               //{"idx":0,"x":1,"y":2} lines
+	WKB                  //idx and hex encoded WKB point separated by tab, PostGIS COPY text format
+)
+
+// Writes all not removed points in the spatial order, so downstream systems could bulk load them with good locality.
+// idx of every point is its index in the original points input slice.
+func (bush *KDBush) ExportSorted(w io.Writer, order Order, format Format) error {
+	positions := make([]int, 0, bush.size()-bush.removedCount)
+	for i := 0; i < bush.size(); i++ {
+		if !bush.removedAt(i) {
+			positions = append(positions, i)
+		}
+	}
+	var keys []uint64
+	switch order {
+	case MortonOrder:
+		keys = bush.MortonKeys(MaxMortonBits)
+	case HilbertOrder:
+		keys = bush.HilbertKeys(MaxMortonBits)
+	case TreeOrder:
+	default:
+		return fmt.Errorf("kdbush: unknown export order %d", order)
+	}
+	if keys != nil {
+		sorting.SliceStable(positions, func(a, b int) bool {
+			return keys[bush.idx(positions[a])] < keys[bush.idx(positions[b])]
+		})
+	}
+
+	bw := bufio.NewWriter(w)
+	var line []byte
+	var wkb [21]byte
+	switch format {
+	case CSV:
+		bw.WriteString("idx,x,y\n")
+	case NDJSON, WKB:
+	default:
+		return fmt.Errorf("kdbush: unknown export format %d", format)
+	}
+	for _, i := range positions {
+		idx, x, y := bush.idx(i), bush.x(i), bush.y(i)
+		line = line[:0]
+		switch format {
+		case CSV:
+			line = strconv.AppendInt(line, int64(idx), 10)
+			line = append(line, ',')
+			line = strconv.AppendFloat(line, x, 'g', -1, 64)
+			line = append(line, ',')
+			line = strconv.AppendFloat(line, y, 'g', -1, 64)
+		case NDJSON:
+			line = append(line, `{"idx":`...)
+			line = strconv.AppendInt(line, int64(idx), 10)
+			line = append(line, `,"x":`...)
+			line = strconv.AppendFloat(line, x, 'g', -1, 64)
+			line = append(line, `,"y":`...)
+			line = strconv.AppendFloat(line, y, 'g', -1, 64)
+			line = append(line, '}')
+		case WKB:
+			//little endian point: byte order, type 1, x, y
+			wkb[0] = 1
+			binary.LittleEndian.PutUint32(wkb[1:], 1)
+			binary.LittleEndian.PutUint64(wkb[5:], math.Float64bits(x))
+			binary.LittleEndian.PutUint64(wkb[13:], math.Float64bits(y))
+			line = strconv.AppendInt(line, int64(idx), 10)
+			line = append(line, '\t')
+			line = hex.AppendEncode(line, wkb[:])
+		}
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
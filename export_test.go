@@ -0,0 +1,41 @@
+package kdbush
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_ExportSorted(t *testing.T) {
+	points := []Point{
+		&SimplePoint{X: 0, Y: 0},
+		&SimplePoint{X: 1, Y: 0},
+		&SimplePoint{X: 0, Y: 1},
+		&SimplePoint{X: 1, Y: 1.5},
+	}
+	bush := NewBush(points, 10)
+
+	var buf bytes.Buffer
+	assert.NoError(t, bush.ExportSorted(&buf, HilbertOrder, CSV))
+	assert.Equal(t, "idx,x,y\n0,0,0\n2,0,1\n3,1,1.5\n1,1,0\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, bush.ExportSorted(&buf, MortonOrder, NDJSON))
+	assert.Equal(t, `{"idx":0,"x":0,"y":0}
+{"idx":1,"x":1,"y":0}
+{"idx":2,"x":0,"y":1}
+{"idx":3,"x":1,"y":1.5}
+`, buf.String())
+
+	bush.Remove(0)
+	buf.Reset()
+	assert.NoError(t, bush.ExportSorted(&buf, TreeOrder, WKB))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines, "1\t0101000000000000000000f03f0000000000000000")
+
+	assert.Error(t, bush.ExportSorted(&buf, Order(9), CSV))
+	assert.Error(t, bush.ExportSorted(&buf, TreeOrder, Format(9)))
+}
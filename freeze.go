@@ -0,0 +1,28 @@
+package kdbush
+
+import "errors"
+
+// Queries keep traversal state in local variables, only methods that modify the index
+// (Remove, RemoveRange, RemoveWithin, SetAux, SetZ, TagGeneration, SetAggregated) are not safe for concurrent use.
+
+// Returned or used as panic value when a frozen index is modified
+var ErrFrozen = errors.New("kdbush: index is frozen")
+
+// Makes the index read-only: all methods, that modify it, fail with ErrFrozen afterwards,
+// methods returning errors return it, removals panic with it.
+// Frozen index is safe to share across goroutines without locks. Exported Points, Idxs and Coords
+// are not protected and should never be modified by the caller.
+func (bush *KDBush) Freeze() {
+	bush.frozen = true
+}
+
+// Returns true if the index was frozen
+func (bush *KDBush) Frozen() bool {
+	return bush.frozen
+}
+
+func (bush *KDBush) mustNotBeFrozen() {
+	if bush.frozen {
+		panic(ErrFrozen)
+	}
+}
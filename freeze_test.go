@@ -0,0 +1,76 @@
+package kdbush
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Freeze(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.False(t, bush.Frozen())
+	bush.Freeze()
+	assert.True(t, bush.Frozen())
+
+	assert.PanicsWithValue(t, ErrFrozen, func() { bush.Remove(0) })
+	assert.PanicsWithValue(t, ErrFrozen, func() { bush.RemoveRange(0, 0, 100, 100) })
+	assert.PanicsWithValue(t, ErrFrozen, func() { bush.RemoveWithin(&SimplePoint{X: 50, Y: 50}, 10) })
+	assert.ErrorIs(t, bush.SetZ(make([]float64, len(testPoints))), ErrFrozen)
+	assert.ErrorIs(t, bush.TagGeneration(0, 10, 1), ErrFrozen)
+	assert.ErrorIs(t, bush.SetAggregated("v", make([]float64, len(testPoints))), ErrFrozen)
+	assert.Zero(t, bush.RemovedCount())
+	assert.Empty(t, bush.AuxNames())
+
+	other := NewBush(getTestPoints()[:10], 4)
+	other.Remove(3)
+	data, err := other.GobEncode()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, bush.GobDecode(data), ErrFrozen)
+	data, err = other.MarshalJSON()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, bush.UnmarshalJSON(data), ErrFrozen)
+	assert.Equal(t, 10, bush.NodeSize)
+	assert.Len(t, bush.Idxs, len(testPoints))
+	assert.True(t, bush.Frozen())
+}
+
+// run with -race: all queries of a shared index have no data races
+func TestKDBush_ConcurrentQueries(t *testing.T) {
+	bush := NewBush(geoTestPoints(), 16)
+	assert.NoError(t, bush.SetZ(make([]float64, len(bush.Idxs))))
+	bush.Freeze()
+
+	type answers struct {
+		rng, within, nearest, query, geo []int
+		saved                            []byte
+	}
+	run := func() answers {
+		var a answers
+		a.rng = bush.Range(-50, -20, 30, 40)
+		a.within = bush.Within(&SimplePoint{X: 10, Y: 10}, 30)
+		a.nearest = bush.Nearest(&SimplePoint{X: 100, Y: -30}, 20, 0, WithZRange(-1, 1))
+		a.query, _ = bush.Query(Circle{X: -90, Y: 45, Radius: 20}, ParallelLeafScan(2, 1), Excluding(Rect{-100, 40, -80, 50}))
+		for _, item := range bush.GeoNearest(LngLat{170, 60}, 10, 0, Kilometers) {
+			a.geo = append(a.geo, item.Index)
+		}
+		var buf bytes.Buffer
+		assert.NoError(t, bush.Save(&buf))
+		a.saved = buf.Bytes()
+		return a
+	}
+
+	expected := run()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				assert.Equal(t, expected, run())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,239 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Mean Earth radius in meters, used by all geographic functions
+const EarthRadius = 6371008.8
+
+// Unit of geographic distances
+type Unit int
+
+const (
+	Meters Unit = iota
+	Kilometers
+	Miles
+	NauticalMiles
+	Radians //central angle
+)
+
+// number of meters in the unit
+func (u Unit) meters() float64 {
+	switch u {
+	case Kilometers:
+		return 1000
+	case Miles:
+		return 1609.344
+	case NauticalMiles:
+		return 1852
+	case Radians:
+		return EarthRadius
+	}
+	return 1
+}
+
+// Converts distance in the unit to radians of the central angle
+func (u Unit) toRadians(d float64) float64 {
+	return d * u.meters() / EarthRadius
+}
+
+// Converts radians of the central angle to distance in the unit
+func (u Unit) fromRadians(r float64) float64 {
+	return r * EarthRadius / u.meters()
+}
+
+// Item of the query result with its distance to the query point
+type ItemDist struct {
+	Index int     //index in the original points input slice
+	Dist  float64 //distance to the query point
+}
+
+// Finds k nearest points to the query point, treating stored coordinates as lng, lat degrees, see also WithMercatorStorage.
+// Distances are great circle distances in the unit, results are sorted by distance, ties are handled as in Nearest.
+// k <= 0 means no limit on number of results, maxDist <= 0 means no limit on distance.
+func (bush *KDBush) GeoNearest(at LngLat, k int, maxDist float64, unit Unit, opts ...QueryOption) []ItemDist {
+	result := []ItemDist{}
+	lng, lat := at.Lng, at.Lat
+	maxHav := 1.0
+	if maxDist > 0 {
+		maxHav = haverSin(math.Min(math.Pi, unit.toRadians(maxDist)))
+	}
+	cosLat := math.Cos(lat * rad)
+	cfg := newQueryConfig(opts)
+
+	nodeDist := func(n treeNode) float64 { return geoBoxDist(lng, lat, cosLat, n) }
+	pointDist := func(i int) float64 { return haverSinDist(lng, lat, bush.x(i), bush.y(i), cosLat) }
+	if m, ok := bush.mercator(); ok {
+		nodeDist = func(n treeNode) float64 { return geoBoxDist(lng, lat, cosLat, m.lngLatNode(n)) }
+		pointDist = func(i int) float64 {
+			pLng, pLat := m.Inverse(bush.x(i), bush.y(i))
+			return haverSinDist(lng, lat, pLng, pLat, cosLat)
+		}
+	}
+	bush.nearest(nodeDist, pointDist, maxHav, cfg,
+		func(i int, h float64) bool {
+			if !cfg.kept(k, len(result), h) {
+				return false
+			}
+			result = append(result, ItemDist{bush.idx(i), unit.fromRadians(havToRadians(h))})
+			return true
+		})
+	return result
+}
+
+// Same as around of geokdbush: returns indices of up to maxResults nearest points within maxDistanceKm kilometers,
+// sorted by great circle distance. maxResults <= 0 and maxDistanceKm <= 0 mean no limit.
+func (bush *KDBush) GeoAround(lng, lat float64, maxResults int, maxDistanceKm float64) []int {
+	items := bush.GeoNearest(LngLat{Lng: lng, Lat: lat}, maxResults, maxDistanceKm, Kilometers)
+	result := make([]int, len(items))
+	for i, item := range items {
+		result[i] = item.Index
+	}
+	return result
+}
+
+// Finds all items within the lng, lat bounding box, treating stored coordinates as lng, lat degrees, see also WithMercatorStorage.
+// Box, that crosses the antimeridian, has minLng greater than maxLng, e.g. 170, -10, -170, 10,
+// it's split into two queries. Longitudes out of [-180, 180] are normalized.
+func (bush *KDBush) RangeLngLat(minLng, minLat, maxLng, maxLat float64) []int {
+	query := bush.Range
+	if m, ok := bush.mercator(); ok {
+		//mercator storage is monotonic on both axes, so the box stays a box, with y flipped
+		query = func(minLng, minLat, maxLng, maxLat float64) []int {
+			minX, minY := m.Forward(minLng, maxLat)
+			maxX, maxY := m.Forward(maxLng, minLat)
+			return bush.Range(minX, minY, maxX, maxY)
+		}
+	}
+	if maxLng-minLng >= 360 {
+		return query(-180, minLat, 180, maxLat)
+	}
+	if minLng != 180 {
+		minLng = normLng(minLng)
+	}
+	if maxLng != 180 {
+		maxLng = normLng(maxLng)
+	}
+	if minLng <= maxLng {
+		return query(minLng, minLat, maxLng, maxLat)
+	}
+	return append(query(minLng, minLat, 180, maxLat), query(-180, minLat, maxLng, maxLat)...)
+}
+
+// Finds all items within meters great circle distance from lng, lat, treating stored coordinates as lng, lat degrees,
+// see also WithMercatorStorage. The tree is pruned by GeoBoundingBox, points inside the box are checked by haversine distance.
+// Results are not sorted, use GeoNearest to get them sorted with distances.
+func (bush *KDBush) WithinMeters(lng, lat, meters float64) []int {
+	if meters < 0 {
+		return []int{}
+	}
+	maxHav := haverSin(math.Min(math.Pi, Meters.toRadians(meters)))
+	cosLat := math.Cos(lat * rad)
+	m, projected := bush.mercator()
+	result := bush.RangeLngLat(GeoBoundingBox(lng, lat, meters))
+	n := 0
+	for _, idx := range result {
+		i := bush.treePos(idx)
+		pLng, pLat := bush.x(i), bush.y(i)
+		if projected {
+			pLng, pLat = m.Inverse(pLng, pLat)
+		}
+		if haverSinDist(lng, lat, pLng, pLat, cosLat) <= maxHav {
+			result[n] = idx
+			n++
+		}
+	}
+	return result[:n]
+}
+
+// Bounding box of all points within radiusMeters great circle distance from lng, lat, a prefilter box for radius queries.
+// Longitude extent is exact for the sphere: it grows with latitude as asin(sin(r) / cos(lat)), not as r / cos(lat).
+// If the circle covers a pole, the box spans all longitudes from the pole to the farthest latitude.
+// Box, that crosses the antimeridian, has minLng greater than maxLng, the same as RangeLngLat takes.
+func GeoBoundingBox(lng, lat, radiusMeters float64) (minLng, minLat, maxLng, maxLat float64) {
+	r := Meters.toRadians(math.Max(0, radiusMeters))
+	d := r / rad
+	minLat, maxLat = lat-d, lat+d
+	if minLat <= -90 || maxLat >= 90 {
+		return -180, math.Max(-90, minLat), 180, math.Min(90, maxLat)
+	}
+	dLng := capLngExtent(r, math.Cos(lat*rad))
+	if dLng >= 180 {
+		return -180, minLat, 180, maxLat
+	}
+	lng = normLng(lng)
+	minLng, maxLng = lng-dLng, lng+dLng
+	if minLng < -180 {
+		minLng += 360
+	}
+	if maxLng > 180 {
+		maxLng -= 360
+	}
+	return minLng, minLat, maxLng, maxLat
+}
+
+// half of the longitude extent in degrees of the circle with radius r radians at latitude with cosLat,
+// the circle shouldn't cover a pole
+func capLngExtent(r, cosLat float64) float64 {
+	if cosLat < 1e-9 {
+		return 180
+	}
+	return math.Min(180, math.Asin(math.Min(1, math.Sin(r)/cosLat))/rad)
+}
+
+// Great circle distance between two points in the unit
+func GeoDistance(a, b LngLat, unit Unit) float64 {
+	return unit.fromRadians(havToRadians(haverSinDist(a.Lng, a.Lat, b.Lng, b.Lat, math.Cos(a.Lat*rad))))
+}
+
+// lower bound of haversine distance from the point to the lng/lat box of the node
+func geoBoxDist(lng, lat, cosLat float64, n treeNode) float64 {
+	//query point is between minimum and maximum longitudes
+	if lng >= n.minX && lng <= n.maxX {
+		if lat < n.minY {
+			return haverSin((lat - n.minY) * rad)
+		}
+		if lat > n.maxY {
+			return haverSin((lat - n.maxY) * rad)
+		}
+		return 0
+	}
+	//query point is west or east of the box, find the extremum of great circle distance to the closest longitude
+	havDLng := math.Min(haverSin((lng-n.minX)*rad), haverSin((lng-n.maxX)*rad))
+	extremumLat := vertexLat(lat, havDLng)
+	if extremumLat > n.minY && extremumLat < n.maxY {
+		return haverSinDistPartial(havDLng, cosLat, lat, extremumLat)
+	}
+	return math.Min(haverSinDistPartial(havDLng, cosLat, lat, n.minY), haverSinDistPartial(havDLng, cosLat, lat, n.maxY))
+}
+
+func haverSin(theta float64) float64 {
+	s := math.Sin(theta / 2)
+	return s * s
+}
+
+func haverSinDistPartial(havDLng, cosLat1, lat1, lat2 float64) float64 {
+	return cosLat1*math.Cos(lat2*rad)*havDLng + haverSin((lat1-lat2)*rad)
+}
+
+func haverSinDist(lng1, lat1, lng2, lat2, cosLat1 float64) float64 {
+	return haverSinDistPartial(haverSin((lng1-lng2)*rad), cosLat1, lat1, lat2)
+}
+
+// latitude of the point of great circle through the lat and the meridian dLng away, where it's the closest to the meridian
+func vertexLat(lat, havDLng float64) float64 {
+	cosDLng := 1 - 2*havDLng
+	if cosDLng <= 0 {
+		if lat > 0 {
+			return 90
+		}
+		return -90
+	}
+	return math.Atan(math.Tan(lat*rad)/cosDLng) / rad
+}
+
+// converts haversine value to central angle
+func havToRadians(h float64) float64 {
+	return 2 * math.Asin(math.Sqrt(math.Max(0, math.Min(1, h))))
+}
@@ -0,0 +1,164 @@
+package kdbush
+
+import (
+	"math"
+	"math/rand"
+	sorting "sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func geoTestPoints() []Point {
+	rnd := rand.New(rand.NewSource(7))
+	points := make([]Point, 5000)
+	for i := range points {
+		points[i] = &SimplePoint{X: rnd.Float64()*360 - 180, Y: rnd.Float64()*180 - 90}
+	}
+	return points
+}
+
+func TestGeoDistance(t *testing.T) {
+	//Paris - London
+	assert.InDelta(t, 343.5, GeoDistance(LngLat{2.3522, 48.8566}, LngLat{-0.1276, 51.5072}, Kilometers), 1)
+	assert.InDelta(t, 343.5e3, GeoDistance(LngLat{2.3522, 48.8566}, LngLat{-0.1276, 51.5072}, Meters), 1e3)
+	assert.InDelta(t, 213.4, GeoDistance(LngLat{2.3522, 48.8566}, LngLat{-0.1276, 51.5072}, Miles), 1)
+	//across the antimeridian
+	assert.InDelta(t, 111.2, GeoDistance(LngLat{179.5, 0}, LngLat{-179.5, 0}, Kilometers), 0.1)
+}
+
+func TestKDBush_GeoNearest(t *testing.T) {
+	points := geoTestPoints()
+	bush := NewBush(points, 16)
+
+	for _, q := range []LngLat{{0, 0}, {179.9, 10}, {-30, 88}, {100, -75}} {
+		expected := make([]ItemDist, len(points))
+		for i, p := range points {
+			x, y := p.Coordinates()
+			expected[i] = ItemDist{i, GeoDistance(q, LngLat{x, y}, Meters)}
+		}
+		sorting.Slice(expected, func(i, j int) bool { return expected[i].Dist < expected[j].Dist })
+
+		result := bush.GeoNearest(q, 10, 0, Meters)
+		if assert.Len(t, result, 10) {
+			for i := range result {
+				assert.Equal(t, expected[i].Index, result[i].Index)
+				assert.InDelta(t, expected[i].Dist, result[i].Dist, 1e-3)
+			}
+		}
+
+		limited := bush.GeoNearest(q, 0, 1000, Kilometers)
+		n := 0
+		for _, e := range expected {
+			if e.Dist <= 1000e3 {
+				assert.InDelta(t, e.Dist/1000, limited[n].Dist, 1e-6)
+				n++
+			}
+		}
+		assert.Len(t, limited, n)
+	}
+}
+
+func TestKDBush_GeoAround(t *testing.T) {
+	points := geoTestPoints()
+	bush := NewBush(points, 16)
+
+	nearest := bush.GeoNearest(LngLat{30, 70}, 5, 0, Meters)
+	around := bush.GeoAround(30, 70, 5, 0)
+	if assert.Len(t, around, 5) {
+		for i := range around {
+			assert.Equal(t, nearest[i].Index, around[i])
+		}
+	}
+
+	//near the pole Euclidean distance in degrees is wrong, great circle one is used
+	for _, idx := range bush.GeoAround(30, 85, 0, 500) {
+		x, y := points[idx].Coordinates()
+		assert.True(t, GeoDistance(LngLat{30, 85}, LngLat{x, y}, Kilometers) <= 500)
+	}
+	assert.Len(t, bush.GeoAround(30, 85, 0, 500), len(bush.GeoNearest(LngLat{30, 85}, 0, 500e3, Meters)))
+}
+
+func TestKDBush_RangeLngLat(t *testing.T) {
+	points := []Point{LngLat{179, 0}, LngLat{-179, 1}, LngLat{180, 2}, LngLat{-180, 3}, LngLat{0, 0}, LngLat{175, 20}}
+	bush := NewBush(points, 2)
+
+	assert.Equal(t, []int{0, 1, 2, 3}, sortedInts(bush.RangeLngLat(170, -10, -170, 10)))
+	assert.Equal(t, []int{0, 1, 2, 3}, sortedInts(bush.RangeLngLat(170, -10, 190, 10)))
+	assert.Equal(t, []int{4}, bush.RangeLngLat(-10, -10, 10, 10))
+	assert.Equal(t, []int{0, 2}, sortedInts(bush.RangeLngLat(170, -10, 180, 10)))
+	assert.Len(t, bush.RangeLngLat(-200, -90, 200, 90), len(points))
+	assert.Empty(t, bush.RangeLngLat(170, 30, -170, 40))
+}
+
+// point at the distance in meters from lng, lat by the bearing in radians
+func geoDestination(lng, lat, meters, bearing float64) (float64, float64) {
+	d := meters / EarthRadius
+	lat1, lng1 := lat*rad, lng*rad
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(bearing))
+	lng2 := lng1 + math.Atan2(math.Sin(bearing)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return normLng(lng2 / rad), lat2 / rad
+}
+
+func TestGeoBoundingBox(t *testing.T) {
+	inBox := func(lng, lat, minLng, minLat, maxLng, maxLat float64) bool {
+		const eps = 1e-9
+		if lat < minLat-eps || lat > maxLat+eps {
+			return false
+		}
+		if minLng <= maxLng {
+			return lng >= minLng-eps && lng <= maxLng+eps
+		}
+		return lng >= minLng-eps || lng <= maxLng+eps
+	}
+	rnd := rand.New(rand.NewSource(4))
+	for _, c := range [][3]float64{{13.4, 52.5, 1000}, {0, 0, 500e3}, {20, 75, 300e3}, {-60, -80, 900e3}, {179.9, 10, 50e3}, {-179.5, -30, 200e3}, {10, 89, 200e3}} {
+		minLng, minLat, maxLng, maxLat := GeoBoundingBox(c[0], c[1], c[2])
+		widest := 0.0
+		for i := 0; i < 2000; i++ {
+			lng, lat := geoDestination(c[0], c[1], c[2]*math.Sqrt(rnd.Float64()), rnd.Float64()*2*math.Pi)
+			assert.True(t, inBox(lng, lat, minLng, minLat, maxLng, maxLat), "%v: %v, %v out of %v, %v, %v, %v", c, lng, lat, minLng, minLat, maxLng, maxLat)
+		}
+		for b := 0.0; b < 2*math.Pi; b += 0.001 {
+			lng, _ := geoDestination(c[0], c[1], c[2], b)
+			widest = math.Max(widest, math.Abs(normLng(lng-c[0])))
+		}
+		if minLng != -180 || maxLng != 180 {
+			//box is tight: the circle touches its sides
+			assert.InDelta(t, widest, math.Abs(normLng(maxLng-c[0])), 1e-3, "%v", c)
+		}
+	}
+
+	minLng, minLat, maxLng, maxLat := GeoBoundingBox(179.9, 10, 50e3)
+	assert.Greater(t, minLng, maxLng, "crosses the antimeridian")
+	assert.ElementsMatch(t, []float64{minLat, maxLat}, []float64{10 - 50e3/EarthRadius/rad, 10 + 50e3/EarthRadius/rad})
+
+	minLng, minLat, maxLng, maxLat = GeoBoundingBox(10, 89, 200e3)
+	assert.Equal(t, [4]float64{-180, 89 - 200e3/EarthRadius/rad, 180, 90}, [4]float64{minLng, minLat, maxLng, maxLat}, "covers the pole")
+
+	//naive 1 / cos(lat) expansion is too narrow at high latitudes
+	minLng, _, _, _ = GeoBoundingBox(0, 80, 1000e3)
+	naive := 1000e3 / EarthRadius / rad / math.Cos(80*rad)
+	assert.Less(t, minLng, -naive-5)
+}
+
+func TestKDBush_WithinMeters(t *testing.T) {
+	points := geoTestPoints()
+	bush := NewBush(points, 16)
+	mercator := NewBushWithOptions(points, 16, WithMercatorStorage(85))
+
+	for _, c := range [][3]float64{{0, 0, 1000e3}, {179.9, 10, 800e3}, {-30, 80, 1500e3}, {100, -75, 600e3}, {10, 89, 400e3}, {5, 5, 0}} {
+		expected := []int{}
+		for i, p := range points {
+			lng, lat := p.Coordinates()
+			if GeoDistance(LngLat{c[0], c[1]}, LngLat{lng, lat}, Meters) <= c[2] {
+				expected = append(expected, i)
+			}
+		}
+		assert.Equal(t, expected, sortedInts(bush.WithinMeters(c[0], c[1], c[2])), "%v", c)
+		if math.Abs(c[1])+c[2]/EarthRadius/rad < 85 {
+			assert.Equal(t, expected, sortedInts(mercator.WithinMeters(c[0], c[1], c[2])), "%v", c)
+		}
+	}
+	assert.Empty(t, bush.WithinMeters(0, 0, -1))
+}
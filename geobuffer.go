@@ -0,0 +1,151 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Area within the distance from a line or a polygon on the sphere, lng, lat in degrees.
+// Edges are great circle arcs, like edges of GeoPolygon.
+// Distance to the edges is computed exactly for every candidate point, so the buffer has no segmentation error,
+// the only approximation is the spherical Earth model, the same as of GeoDistance.
+// Could be used with Query, Centroid, Excluding and all other functions, that take QueryGeom.
+type GeoBuffer struct {
+	verts   [][3]float64 //unit vectors of vertices
+	normals [][3]float64 //unit normals of edges, zero for degenerate edges
+	radius  float64      //buffer distance in radians
+	polygon *GeoPolygon  //interior of the buffered polygon, nil for lines
+	minLng  float64
+	minLat  float64
+	maxLng  float64
+	maxLat  float64
+}
+
+// Buffers the line (polyline) by the distance in the given unit.
+// Line with one vertex is buffered to a circle.
+func BufferLine(line []LngLat, dist float64, unit Unit) *GeoBuffer {
+	ring := make([][2]float64, len(line))
+	for i, v := range line {
+		ring[i] = [2]float64{normLng(v.Lng), math.Max(-maxPolygonLat, math.Min(maxPolygonLat, v.Lat))}
+	}
+	return newGeoBuffer(ring, false, dist, unit)
+}
+
+// Buffers the polygon by the distance in the given unit: the result contains the polygon
+// and all points within the distance from its boundary. Closing vertex is optional.
+func BufferPolygon(ring []LngLat, dist float64, unit Unit) *GeoBuffer {
+	polygon := NewGeoPolygon(ring)
+	b := newGeoBuffer(polygon.ring, true, dist, unit)
+	if len(polygon.ring) > 0 {
+		b.polygon = polygon
+		minLng, minLat, maxLng, maxLat := polygon.Bounds()
+		b.minLng, b.maxLng = math.Min(b.minLng, minLng), math.Max(b.maxLng, maxLng)
+		b.minLat, b.maxLat = math.Min(b.minLat, minLat), math.Max(b.maxLat, maxLat)
+	}
+	return b
+}
+
+func newGeoBuffer(ring [][2]float64, closed bool, dist float64, unit Unit) *GeoBuffer {
+	b := &GeoBuffer{radius: math.Max(0, unit.toRadians(dist))}
+	if len(ring) == 0 {
+		b.minLng, b.minLat, b.maxLng, b.maxLat = 0, 0, -1, -1
+		return b
+	}
+
+	edges := len(ring) - 1
+	if closed {
+		edges = len(ring)
+	}
+	b.verts = make([][3]float64, len(ring))
+	for i, v := range ring {
+		b.verts[i] = lngLatToVec(v)
+	}
+	b.normals = make([][3]float64, iMax(1, edges))
+
+	minLat, maxLat := ring[0][1], ring[0][1]
+	minLng, maxLng := ring[0][0], ring[0][0]
+	antimerid := false
+	for i := 0; i < edges; i++ {
+		j := (i + 1) % len(ring)
+		n := cross(b.verts[i], b.verts[j])
+		if nn := math.Sqrt(dot(n, n)); nn > 1e-15 {
+			b.normals[i] = [3]float64{n[0] / nn, n[1] / nn, n[2] / nn}
+		}
+		lo, hi := arcLatRange(ring[i], ring[j])
+		minLat, maxLat = math.Min(minLat, lo), math.Max(maxLat, hi)
+		minLng, maxLng = math.Min(minLng, ring[j][0]), math.Max(maxLng, ring[j][0])
+		if math.Abs(ring[j][0]-ring[i][0]) > 180 {
+			antimerid = true
+		}
+	}
+
+	d := b.radius / rad
+	b.minLat, b.maxLat = math.Max(-90, minLat-d), math.Min(90, maxLat+d)
+	//longitude extent of the distance is the widest at the latitude closest to a pole
+	cosLat := math.Cos(math.Max(math.Abs(b.minLat), math.Abs(b.maxLat)) * rad)
+	if antimerid || d >= 90 || cosLat < 1e-9 || maxLng-minLng+2*d/cosLat >= 360 {
+		b.minLng, b.maxLng = -180, 180
+		return b
+	}
+	dLng := capLngExtent(b.radius, cosLat)
+	b.minLng, b.maxLng = minLng-dLng, maxLng+dLng
+	if b.minLng < -180 || b.maxLng > 180 {
+		//wraps around the antimeridian
+		b.minLng, b.maxLng = -180, 180
+	}
+	return b
+}
+
+func (b *GeoBuffer) Bounds() (minLng, minLat, maxLng, maxLat float64) {
+	return b.minLng, b.minLat, b.maxLng, b.maxLat
+}
+
+func (b *GeoBuffer) Contains(lng, lat float64) bool {
+	if len(b.verts) == 0 {
+		return false
+	}
+	if b.polygon != nil && b.polygon.Contains(lng, lat) {
+		return true
+	}
+	p := lngLatToVec([2]float64{lng, lat})
+	if len(b.verts) == 1 {
+		return vecAngle(p, b.verts[0]) <= b.radius
+	}
+	for i, n := range b.normals {
+		if arcDist(p, b.verts[i], b.verts[(i+1)%len(b.verts)], n) <= b.radius {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance in the given unit from the point to the buffered line or to the boundary of the buffered polygon
+func (b *GeoBuffer) Distance(p LngLat, unit Unit) float64 {
+	if len(b.verts) == 0 {
+		return math.Inf(1)
+	}
+	v := lngLatToVec([2]float64{normLng(p.Lng), p.Lat})
+	d := vecAngle(v, b.verts[0])
+	for i := 0; i < len(b.normals) && len(b.verts) > 1; i++ {
+		d = math.Min(d, arcDist(v, b.verts[i], b.verts[(i+1)%len(b.verts)], b.normals[i]))
+	}
+	return unit.fromRadians(d)
+}
+
+// angular distance from the point p to the great circle arc from a to b with unit normal n
+func arcDist(p, a, b, n [3]float64) float64 {
+	if n != [3]float64{} {
+		//closest point of the whole great circle: p projected to the plane of the arc
+		s := dot(p, n)
+		c := [3]float64{p[0] - s*n[0], p[1] - s*n[1], p[2] - s*n[2]}
+		if dot(c, c) > 1e-30 && onArc(a, b, n, c) {
+			return math.Asin(math.Min(1, math.Abs(s)))
+		}
+	}
+	return math.Min(vecAngle(p, a), vecAngle(p, b))
+}
+
+// angle between unit vectors, precise for small and large angles
+func vecAngle(a, b [3]float64) float64 {
+	c := cross(a, b)
+	return math.Atan2(math.Sqrt(dot(c, c)), dot(a, b))
+}
@@ -0,0 +1,65 @@
+package kdbush
+
+import (
+	sorting "sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferLine(t *testing.T) {
+	//1 degree on the equator is about 111.2 km
+	b := BufferLine([]LngLat{{0, 0}, {10, 0}}, 100, Kilometers)
+	assert.True(t, b.Contains(5, 0.8))
+	assert.True(t, b.Contains(5, -0.8))
+	assert.True(t, b.Contains(10.5, 0))
+	assert.False(t, b.Contains(5, 1))
+	assert.False(t, b.Contains(11, 0))
+	assert.False(t, b.Contains(10.8, 0.8))
+	assert.InDelta(t, 111.2, b.Distance(LngLat{5, 1}, Kilometers), 0.1)
+
+	circle := BufferLine([]LngLat{{20, 45}}, 50, Kilometers)
+	assert.True(t, circle.Contains(20, 45.4))
+	assert.False(t, circle.Contains(20, 45.5))
+
+	empty := BufferLine(nil, 100, Kilometers)
+	assert.False(t, empty.Contains(0, 0))
+}
+
+func TestBufferPolygon(t *testing.T) {
+	b := BufferPolygon([]LngLat{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, 100, Kilometers)
+	assert.True(t, b.Contains(5, 5), "interior")
+	assert.True(t, b.Contains(-0.8, 5))
+	assert.True(t, b.Contains(5, 10.8))
+	assert.False(t, b.Contains(-1, 5))
+	assert.False(t, b.Contains(5, -1))
+}
+
+func TestGeoBuffer_Query(t *testing.T) {
+	points := geoTestPoints()
+	bush := NewBush(points, 16)
+
+	buffers := []*GeoBuffer{
+		BufferLine([]LngLat{{-20, 30}, {15, 40}, {30, 10}}, 500, Kilometers),
+		BufferLine([]LngLat{{175, -5}, {-175, 5}}, 300, Kilometers),
+		BufferLine([]LngLat{{-100, 80}, {80, 80}}, 200, Kilometers),
+		BufferLine([]LngLat{{178, 60}}, 1000, Kilometers),
+		BufferPolygon([]LngLat{{100, -40}, {120, -40}, {120, -20}, {100, -20}}, 400, Kilometers),
+		BufferPolygon([]LngLat{{0, 75}, {90, 75}, {180, 75}, {-90, 75}}, 100, Kilometers),
+	}
+	for i, b := range buffers {
+		var expected []int
+		for idx, p := range points {
+			x, y := p.Coordinates()
+			if b.Contains(x, y) {
+				expected = append(expected, idx)
+				assert.True(t, b.polygon != nil || b.Distance(LngLat{x, y}, Kilometers) <= b.radius*EarthRadius/1e3+1e-6)
+			}
+		}
+		assert.NotEmpty(t, expected, "buffer %d", i)
+
+		result, _ := bush.Query(b)
+		sorting.Ints(result)
+		assert.Equal(t, expected, result, "buffer %d", i)
+	}
+}
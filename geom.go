@@ -0,0 +1,158 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Geometry, that could be used as a query area.
+// Bounds are used to prune the tree, Contains is called for every point inside of the bounds.
+type QueryGeom interface {
+	Bounds() (minX, minY, maxX, maxY float64)
+	Contains(x, y float64) bool
+}
+
+// Geometry, that could tell whether the whole box is inside of it.
+// Used by Excluding query option to skip fully excluded subtrees.
+type BoxContainer interface {
+	ContainsBox(minX, minY, maxX, maxY float64) bool
+}
+
+// Axis aligned rectangle query geometry
+type Rect struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+func (r Rect) Bounds() (float64, float64, float64, float64) {
+	return r.MinX, r.MinY, r.MaxX, r.MaxY
+}
+
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+func (r Rect) ContainsBox(minX, minY, maxX, maxY float64) bool {
+	return minX >= r.MinX && maxX <= r.MaxX && minY >= r.MinY && maxY <= r.MaxY
+}
+
+// Circle query geometry, the same area as Within uses
+type Circle struct {
+	X, Y, Radius float64
+}
+
+func (c Circle) Bounds() (float64, float64, float64, float64) {
+	return c.X - c.Radius, c.Y - c.Radius, c.X + c.Radius, c.Y + c.Radius
+}
+
+func (c Circle) Contains(x, y float64) bool {
+	return sqrtDist(x, y, c.X, c.Y) <= c.Radius*c.Radius
+}
+
+func (c Circle) ContainsBox(minX, minY, maxX, maxY float64) bool {
+	//the farthest corner of the box should be inside
+	dx := fMax(c.X-minX, maxX-c.X)
+	dy := fMax(c.Y-minY, maxY-c.Y)
+	return dx*dx+dy*dy <= c.Radius*c.Radius
+}
+
+// Rectangle rotated around its center, e.g. a corridor along a road heading
+type OrientedRect struct {
+	cx, cy, halfW, halfH float64
+	cos, sin             float64
+}
+
+// Creates rectangle with center cx, cy and half sizes halfW, halfH along its own axes,
+// rotated by angle radians counter-clockwise from the x axis
+func NewOrientedRect(cx, cy, halfW, halfH, angle float64) OrientedRect {
+	return OrientedRect{cx: cx, cy: cy, halfW: halfW, halfH: halfH, cos: math.Cos(angle), sin: math.Sin(angle)}
+}
+
+func (r OrientedRect) Bounds() (float64, float64, float64, float64) {
+	dx := math.Abs(r.halfW*r.cos) + math.Abs(r.halfH*r.sin)
+	dy := math.Abs(r.halfW*r.sin) + math.Abs(r.halfH*r.cos)
+	return r.cx - dx, r.cy - dy, r.cx + dx, r.cy + dy
+}
+
+func (r OrientedRect) Contains(x, y float64) bool {
+	//coordinates in the frame of the rectangle
+	dx, dy := x-r.cx, y-r.cy
+	u := dx*r.cos + dy*r.sin
+	v := -dx*r.sin + dy*r.cos
+	return math.Abs(u) <= r.halfW && math.Abs(v) <= r.halfH
+}
+
+func (r OrientedRect) ContainsBox(minX, minY, maxX, maxY float64) bool {
+	//the rectangle is convex, so all corners of the box should be inside
+	return r.Contains(minX, minY) && r.Contains(maxX, minY) && r.Contains(minX, maxY) && r.Contains(maxX, maxY)
+}
+
+// Same as Range, but for the rectangle with center cx, cy and half sizes halfW, halfH,
+// rotated by angleRad counter-clockwise. The tree is pruned by the bounding box of the rectangle,
+// points inside the box are checked exactly.
+func (bush *KDBush) RangeOriented(cx, cy, halfW, halfH, angleRad float64) []int {
+	result, _ := bush.Query(NewOrientedRect(cx, cy, halfW, halfH, angleRad))
+	return result
+}
+
+// Planar polygon query geometry, a ring of x, y vertices, closing vertex is optional.
+// Uses even-odd rule, points on the boundary could be either inside or outside.
+type Polygon [][2]float64
+
+func (p Polygon) Bounds() (minX, minY, maxX, maxY float64) {
+	if len(p) == 0 {
+		return 0, 0, -1, -1
+	}
+	minX, minY, maxX, maxY = p[0][0], p[0][1], p[0][0], p[0][1]
+	for _, v := range p[1:] {
+		minX, maxX = fMin(minX, v[0]), fMax(maxX, v[0])
+		minY, maxY = fMin(minY, v[1]), fMax(maxY, v[1])
+	}
+	return minX, minY, maxX, maxY
+}
+
+func (p Polygon) Contains(x, y float64) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a[1] > y) != (b[1] > y) && x < (b[0]-a[0])*(y-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Finds all items inside of the geometry and returns an array of indices
+// Returns results and the flag, that is true when the query was stopped before the whole tree was searched.
+func (bush *KDBush) Query(geom QueryGeom, opts ...QueryOption) (result []int, truncated bool) {
+	return QueryAs(bush, AsIndices(), geom, opts...)
+}
+
+// Same as Query, but returns results in the form defined by kind
+func QueryAs[T any](bush *KDBush, kind ResultKind[T], geom QueryGeom, opts ...QueryOption) (result []T, truncated bool) {
+	return queryAs(bush, kind, geom, newQueryConfig(opts))
+}
+
+func queryAs[T any](bush *KDBush, kind ResultKind[T], geom QueryGeom, cfg *queryConfig) (result []T, truncated bool) {
+	result = []T{}
+	minX, minY, maxX, maxY := geom.Bounds()
+	truncated = bush.search(minX, minY, maxX, maxY, cfg, func(i int) bool {
+		if geom.Contains(bush.x(i), bush.y(i)) {
+			result = append(result, kind(bush, i))
+		}
+		return true
+	})
+	return result, truncated
+}
+
+func fMin(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func fMax(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
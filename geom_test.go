@@ -0,0 +1,170 @@
+package kdbush
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Query(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+
+	result, _ := bush.Query(Rect{20, 30, 50, 70})
+	assert.Equal(t, bush.Range(20, 30, 50, 70), result)
+
+	result, _ = bush.Query(Circle{50, 50, 20})
+	assert.Equal(t, bush.Within(&SimplePoint{X: 50, Y: 50}, 20), result)
+
+	triangle := Polygon{{0, 0}, {100, 0}, {0, 100}}
+	result, _ = bush.Query(triangle)
+	for i, p := range testPoints {
+		inside := p[0]+p[1] < 100
+		assert.Equal(t, inside, index(result, i) >= 0, "point %v", p)
+	}
+}
+
+func TestKDBush_RangeOriented(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+
+	//corridor along the diagonal, 40 long and 10 wide
+	result := bush.RangeOriented(50, 50, 20, 5, math.Pi/4)
+	for i, p := range testPoints {
+		u := ((p[0] - 50) + (p[1] - 50)) / math.Sqrt2
+		v := ((p[1] - 50) - (p[0] - 50)) / math.Sqrt2
+		inside := math.Abs(u) <= 20 && math.Abs(v) <= 5
+		assert.Equal(t, inside, index(result, i) >= 0, "point %v", p)
+	}
+	assert.NotEmpty(t, result)
+
+	//not rotated, the same as Range
+	assert.Equal(t, sortedInts(bush.Range(20, 30, 50, 70)), sortedInts(bush.RangeOriented(35, 50, 15, 20, 0)))
+	assert.Equal(t, sortedInts(bush.Range(20, 30, 50, 70)), sortedInts(bush.RangeOriented(35, 50, 20, 15, math.Pi/2)))
+
+	r := NewOrientedRect(0, 0, 2, 1, math.Pi/6)
+	minX, minY, maxX, maxY := r.Bounds()
+	assert.InDelta(t, 2*math.Cos(math.Pi/6)+math.Sin(math.Pi/6), maxX, 1e-12)
+	assert.InDelta(t, -maxX, minX, 1e-12)
+	assert.InDelta(t, 2*math.Sin(math.Pi/6)+math.Cos(math.Pi/6), maxY, 1e-12)
+	assert.InDelta(t, -maxY, minY, 1e-12)
+	assert.True(t, r.ContainsBox(-0.5, -0.5, 0.5, 0.5))
+	assert.False(t, r.ContainsBox(-1, -1, 1, 1))
+}
+
+func TestGeoPolygon_Antimeridian(t *testing.T) {
+	fence := NewGeoPolygon([]LngLat{{170, -10}, {-170, -10}, {-170, 10}, {170, 10}, {170, -10}})
+
+	assert.True(t, fence.Contains(175, 0))
+	assert.True(t, fence.Contains(-175, 0))
+	assert.True(t, fence.Contains(180, 5))
+	assert.True(t, fence.Contains(-180, 5))
+	assert.True(t, fence.Contains(540, 5))
+	assert.False(t, fence.Contains(0, 0))
+	assert.False(t, fence.Contains(160, 0))
+	assert.False(t, fence.Contains(-160, 0))
+	assert.False(t, fence.Contains(175, 20))
+}
+
+func TestGeoPolygon_GreatCircleEdges(t *testing.T) {
+	//edge along 60° parallel is a great circle arc, that bulges to the north
+	p := NewGeoPolygon([]LngLat{{-40, 60}, {40, 60}, {40, 70}, {-40, 70}})
+	assert.False(t, p.Contains(0, 61))
+	assert.False(t, p.Contains(0, 65))
+	assert.True(t, p.Contains(0, 68))
+	assert.True(t, p.Contains(0, 73))
+	assert.True(t, p.Contains(39, 61))
+
+	_, minLat, _, maxLat := p.Bounds()
+	assert.InDelta(t, 60, minLat, 1e-9)
+	assert.InDelta(t, 74.4, maxLat, 0.1)
+}
+
+func TestGeoPolygon_Pole(t *testing.T) {
+	arctic := NewGeoPolygon([]LngLat{{0, 80}, {90, 80}, {180, 80}, {-90, 80}})
+	assert.True(t, arctic.Contains(0, 85))
+	assert.True(t, arctic.Contains(123, 89.9))
+	assert.True(t, arctic.Contains(-45, 83))
+	assert.False(t, arctic.Contains(45, 81))
+	assert.False(t, arctic.Contains(10, 70))
+	assert.False(t, arctic.Contains(10, -85))
+
+	minLng, _, maxLng, maxLat := arctic.Bounds()
+	assert.Equal(t, []float64{-180, 180, 90}, []float64{minLng, maxLng, maxLat})
+
+	antarctic := NewGeoPolygon([]LngLat{{0, -80}, {-90, -80}, {180, -80}, {90, -80}})
+	assert.True(t, antarctic.Contains(30, -85))
+	assert.False(t, antarctic.Contains(30, 85))
+	assert.False(t, antarctic.Contains(30, -70))
+}
+
+func TestKDBush_QueryGeoPolygon(t *testing.T) {
+	points := []Point{}
+	for lng := -180.0; lng < 180; lng += 2.5 {
+		for lat := -89.0; lat <= 89; lat += 2.5 {
+			points = append(points, &SimplePoint{X: lng, Y: lat})
+		}
+	}
+	bush := NewBush(points, 16)
+
+	geoms := []*GeoPolygon{
+		NewGeoPolygon([]LngLat{{150, -30}, {-140, -30}, {-150, 20}, {160, 25}}),
+		NewGeoPolygon([]LngLat{{0, 75}, {120, 70}, {-120, 72}}),
+		NewGeoPolygon([]LngLat{{-10, -10}, {10, -10}, {10, 10}, {-10, 10}}),
+	}
+	for _, g := range geoms {
+		result, _ := bush.Query(g)
+		expected := 0
+		for i, p := range points {
+			x, y := p.Coordinates()
+			if g.Contains(x, y) {
+				expected++
+				assert.True(t, index(result, i) >= 0, "point %v, %v is missing", x, y)
+			}
+		}
+		assert.Equal(t, expected, len(result))
+		assert.NotZero(t, expected)
+	}
+}
+
+type countingRect struct {
+	Rect
+	calls *int
+}
+
+func (c countingRect) Contains(x, y float64) bool {
+	*c.calls++
+	return c.Rect.Contains(x, y)
+}
+
+func TestKDBush_Excluding(t *testing.T) {
+	points := make([]Point, 10000)
+	for i := range points {
+		points[i] = &SimplePoint{X: float64(i % 100), Y: float64(i / 100)}
+	}
+	bush := NewBush(points, 16)
+	zone := Rect{20, 20, 60, 60}
+	hole := Circle{80, 80, 10}
+
+	result, _ := bush.RangeWithOptions(10, 10, 90, 90, Excluding(zone, hole))
+	expected := []int{}
+	for _, idx := range bush.Range(10, 10, 90, 90) {
+		x, y := points[idx].Coordinates()
+		if !zone.Contains(x, y) && !hole.Contains(x, y) {
+			expected = append(expected, idx)
+		}
+	}
+	assert.Equal(t, expected, result)
+
+	//covered subtrees are not visited
+	calls := 0
+	result, _ = bush.RangeWithOptions(10, 10, 90, 90, Excluding(countingRect{zone, &calls}))
+	assert.Len(t, result, 81*81-41*41)
+	assert.True(t, calls < 41*41/2, "calls %d", calls)
+
+	//exclusions are applied to nearest query too
+	nearest := bush.Nearest(&SimplePoint{X: 40, Y: 40}, 1, 0, Excluding(zone))
+	assert.Len(t, nearest, 1)
+	x, y := points[nearest[0]].Coordinates()
+	assert.False(t, zone.Contains(x, y))
+	assert.Equal(t, 21.0, fMax(40-x, fMax(x-40, fMax(40-y, y-40))))
+}
@@ -0,0 +1,157 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// latitude of vertices is clamped to it, the math below is singular at the poles
+const maxPolygonLat = 90 - 1e-9
+
+// Polygon on the sphere for lng/lat data: a ring of lng, lat vertices in degrees, edges are great circle arcs.
+// Polygons could cross the antimeridian and contain a pole.
+// Ring, that goes around a pole, contains the pole on the side of the mean latitude of its vertices.
+// Uses even-odd rule, points on the boundary could be either inside or outside.
+type GeoPolygon struct {
+	ring      [][2]float64 //normalized vertices, without closing one
+	northPole bool         //polygon contains the north pole
+	southPole bool         //polygon contains the south pole
+	antimerid bool         //some edge crosses the antimeridian
+	minLat    float64
+	maxLat    float64
+	minLng    float64
+	maxLng    float64
+}
+
+// Creates geographic polygon from the ring of vertices, closing vertex is optional
+func NewGeoPolygon(ring []LngLat) *GeoPolygon {
+	g := &GeoPolygon{}
+	for i, v := range ring {
+		if i == len(ring)-1 && i > 0 && v == ring[0] {
+			break
+		}
+		g.ring = append(g.ring, [2]float64{normLng(v.Lng), math.Max(-maxPolygonLat, math.Min(maxPolygonLat, v.Lat))})
+	}
+	if len(g.ring) == 0 {
+		g.minLat, g.maxLat, g.minLng, g.maxLng = 0, -1, 0, -1
+		return g
+	}
+
+	winding, meanLat := 0.0, 0.0
+	g.minLat, g.maxLat = math.Inf(1), math.Inf(-1)
+	g.minLng, g.maxLng = math.Inf(1), math.Inf(-1)
+	for i := range g.ring {
+		a, b := g.ring[i], g.ring[(i+1)%len(g.ring)]
+		winding += normLng(b[0] - a[0])
+		meanLat += a[1] / float64(len(g.ring))
+		if math.Abs(b[0]-a[0]) > 180 {
+			g.antimerid = true
+		}
+		g.minLng, g.maxLng = math.Min(g.minLng, a[0]), math.Max(g.maxLng, a[0])
+		lo, hi := arcLatRange(a, b)
+		g.minLat, g.maxLat = math.Min(g.minLat, lo), math.Max(g.maxLat, hi)
+	}
+
+	if math.Abs(winding) > 180 {
+		g.northPole = meanLat >= 0
+		g.southPole = !g.northPole
+	}
+	if g.northPole {
+		g.maxLat = 90
+	}
+	if g.southPole {
+		g.minLat = -90
+	}
+	if g.antimerid || g.northPole || g.southPole {
+		g.minLng, g.maxLng = -180, 180
+	}
+	return g
+}
+
+func (g *GeoPolygon) Bounds() (minLng, minLat, maxLng, maxLat float64) {
+	return g.minLng, g.minLat, g.maxLng, g.maxLat
+}
+
+// Counts crossings of the polygon edges with the meridian arc from the point to the north pole
+func (g *GeoPolygon) Contains(lng, lat float64) bool {
+	lng = normLng(lng)
+	inside := false
+	for i := range g.ring {
+		a, b := g.ring[i], g.ring[(i+1)%len(g.ring)]
+		da := normLng(a[0] - lng)
+		db := normLng(b[0] - lng)
+		if (da >= 0) == (db >= 0) || math.Abs(da-db) >= 180 {
+			continue
+		}
+		if arcLatAt(a[1], b[1], da, db) > lat {
+			inside = !inside
+		}
+	}
+	return inside != g.northPole
+}
+
+// latitude of the great circle through points with latitudes latA, latB and
+// longitudes dA, dB relative to the meridian, at the meridian
+func arcLatAt(latA, latB, dA, dB float64) float64 {
+	tA := math.Tan(latA * rad)
+	tB := math.Tan(latB * rad)
+	return math.Atan((tA*math.Sin(dB*rad)-tB*math.Sin(dA*rad))/math.Sin((dB-dA)*rad)) / rad
+}
+
+// latitude range of the great circle arc between a and b, arc could bulge poleward of its ends
+func arcLatRange(a, b [2]float64) (float64, float64) {
+	lo, hi := math.Min(a[1], b[1]), math.Max(a[1], b[1])
+	va, vb := lngLatToVec(a), lngLatToVec(b)
+	n := cross(va, vb)
+	nn := math.Sqrt(dot(n, n))
+	if nn < 1e-15 {
+		return lo, hi
+	}
+	n = [3]float64{n[0] / nn, n[1] / nn, n[2] / nn}
+
+	//the highest point of the great circle: the pole projected to its plane
+	c := [3]float64{-n[2] * n[0], -n[2] * n[1], 1 - n[2]*n[2]}
+	cn := math.Sqrt(dot(c, c))
+	if cn < 1e-15 {
+		return lo, hi
+	}
+	c = [3]float64{c[0] / cn, c[1] / cn, c[2] / cn}
+	top := math.Asin(math.Max(-1, math.Min(1, c[2]))) / rad
+
+	if onArc(va, vb, n, c) {
+		hi = math.Max(hi, top)
+	}
+	if onArc(va, vb, n, [3]float64{-c[0], -c[1], -c[2]}) {
+		lo = math.Min(lo, -top)
+	}
+	return lo, hi
+}
+
+// checks if the point c of the great circle with normal n lies on the short arc from a to b
+func onArc(a, b, n, c [3]float64) bool {
+	return dot(cross(a, c), n) >= 0 && dot(cross(c, b), n) >= 0
+}
+
+func lngLatToVec(p [2]float64) [3]float64 {
+	lng, lat := p[0]*rad, p[1]*rad
+	return [3]float64{math.Cos(lat) * math.Cos(lng), math.Cos(lat) * math.Sin(lng), math.Sin(lat)}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// normalizes longitude to [-180, 180)
+func normLng(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
+
+// degrees to radians
+const rad = math.Pi / 180
@@ -0,0 +1,218 @@
+package kdbush
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	sorting "sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "regenerate golden fixtures in testdata/golden with brute force search")
+
+// Dataset with expected query results, shared with JS kdbush and geokdbush test suites, see testdata/golden/README.md
+type goldenFixture struct {
+	Name     string       `json:"name"`
+	NodeSize int          `json:"nodeSize"`
+	Points   [][2]float64 `json:"points"`
+	Geo      bool         `json:"geo,omitempty"`
+
+	//tree arrays, only for datasets, that come from kdbush test suite
+	Idxs   []int     `json:"ids,omitempty"`
+	Coords []float64 `json:"coords,omitempty"`
+
+	Range   []goldenRange   `json:"range,omitempty"`
+	Within  []goldenWithin  `json:"within,omitempty"`
+	Nearest []goldenNearest `json:"nearest,omitempty"`
+	Around  []goldenAround  `json:"around,omitempty"`
+}
+
+// ids are sorted, order of range and within results is not a part of the contract
+type goldenRange struct {
+	Box [4]float64 `json:"box"`
+	Ids []int      `json:"ids"`
+}
+
+type goldenWithin struct {
+	Point  [2]float64 `json:"point"`
+	Radius float64    `json:"radius"`
+	Ids    []int      `json:"ids"`
+}
+
+// ids are sorted by distance
+type goldenNearest struct {
+	Point   [2]float64 `json:"point"`
+	K       int        `json:"k"`
+	MaxDist float64    `json:"maxDist"`
+	Ids     []int      `json:"ids"`
+}
+
+// geokdbush around: lng, lat query, max distance in kilometers, ids are sorted by great circle distance
+type goldenAround struct {
+	Lng   float64 `json:"lng"`
+	Lat   float64 `json:"lat"`
+	K     int     `json:"k"`
+	MaxKm float64 `json:"maxKm"`
+	Ids   []int   `json:"ids"`
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	if *updateGolden {
+		writeGoldenFixtures(t)
+	}
+	files, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		var f goldenFixture
+		if !assert.NoError(t, json.Unmarshal(data, &f), file) {
+			continue
+		}
+		t.Run(f.Name, func(t *testing.T) {
+			bush := NewBushFromPairs(f.Points, f.NodeSize)
+			if f.Idxs != nil {
+				assert.Equal(t, f.Idxs, bush.Idxs)
+				assert.Equal(t, f.Coords, bush.Coords)
+			}
+			for _, q := range f.Range {
+				assert.Equal(t, q.Ids, sortedInts(bush.Range(q.Box[0], q.Box[1], q.Box[2], q.Box[3])), "range %v", q.Box)
+			}
+			for _, q := range f.Within {
+				assert.Equal(t, q.Ids, sortedInts(bush.Within(&SimplePoint{X: q.Point[0], Y: q.Point[1]}, q.Radius)), "within %v %v", q.Point, q.Radius)
+			}
+			for _, q := range f.Nearest {
+				assert.Equal(t, q.Ids, bush.Nearest(&SimplePoint{X: q.Point[0], Y: q.Point[1]}, q.K, q.MaxDist), "nearest %v", q.Point)
+			}
+			for _, q := range f.Around {
+				assert.Equal(t, q.Ids, bush.GeoAround(q.Lng, q.Lat, q.K, q.MaxKm), "around %v %v", q.Lng, q.Lat)
+			}
+		})
+	}
+}
+
+// computes expected results by brute force, so fixtures don't depend on this implementation
+func writeGoldenFixtures(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	round := func(v float64) float64 { return math.Round(v*1000) / 1000 }
+
+	canonical := goldenFixture{Name: "kdbush", NodeSize: 10, Idxs: testIdxs, Coords: testCoords}
+	for _, p := range testPoints {
+		canonical.Points = append(canonical.Points, [2]float64{p[0], p[1]})
+	}
+
+	uniform := goldenFixture{Name: "uniform", NodeSize: 16}
+	for i := 0; i < 2000; i++ {
+		uniform.Points = append(uniform.Points, [2]float64{round(rnd.Float64() * 1000), round(rnd.Float64() * 1000)})
+	}
+
+	//many coincident points and points on the query boundaries
+	duplicates := goldenFixture{Name: "duplicates", NodeSize: 4}
+	for i := 0; i < 500; i++ {
+		duplicates.Points = append(duplicates.Points, [2]float64{float64(rnd.Intn(10)), float64(rnd.Intn(10))})
+	}
+
+	geo := goldenFixture{Name: "geo", NodeSize: 64, Geo: true}
+	for i := 0; i < 3000; i++ {
+		geo.Points = append(geo.Points, [2]float64{round(rnd.Float64()*360 - 180), round(rnd.Float64()*180 - 90)})
+	}
+
+	for _, f := range []*goldenFixture{&canonical, &uniform, &duplicates} {
+		minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, p := range f.Points {
+			minX, minY, maxX, maxY = math.Min(minX, p[0]), math.Min(minY, p[1]), math.Max(maxX, p[0]), math.Max(maxY, p[1])
+		}
+		w, h := maxX-minX, maxY-minY
+		for i := 0; i < 10; i++ {
+			x, y := minX+rnd.Float64()*w, minY+rnd.Float64()*h
+			box := [4]float64{round(x), round(y), round(x + rnd.Float64()*w/3), round(y + rnd.Float64()*h/3)}
+			if f == &duplicates {
+				x, y := float64(rnd.Intn(10)), float64(rnd.Intn(10))
+				box = [4]float64{x, y, x + float64(rnd.Intn(4)), y + float64(rnd.Intn(4))}
+			}
+			f.Range = append(f.Range, goldenRange{box, bruteForce(f.Points, func(p [2]float64) bool {
+				return p[0] >= box[0] && p[0] <= box[2] && p[1] >= box[1] && p[1] <= box[3]
+			})})
+
+			q := [2]float64{round(minX + rnd.Float64()*w), round(minY + rnd.Float64()*h)}
+			r := round(rnd.Float64() * w / 5)
+			if f == &duplicates {
+				q, r = [2]float64{float64(rnd.Intn(10)), float64(rnd.Intn(10))}, float64(rnd.Intn(4))
+			}
+			f.Within = append(f.Within, goldenWithin{q, r, bruteForce(f.Points, func(p [2]float64) bool {
+				return sqrtDist(p[0], p[1], q[0], q[1]) <= r*r
+			})})
+		}
+	}
+
+	//nearest results of coincident points depend on tie breaking, so only datasets without ties have them
+	for _, f := range []*goldenFixture{&canonical, &uniform} {
+		for i := 0; i < 10; i++ {
+			q := [2]float64{round(rnd.Float64() * 100), round(rnd.Float64() * 100)}
+			if f == &uniform {
+				q = [2]float64{round(rnd.Float64() * 1000), round(rnd.Float64() * 1000)}
+			}
+			k, maxDist := 1+rnd.Intn(20), 0.0
+			if i%2 == 1 {
+				maxDist = round(rnd.Float64() * 30)
+			}
+			ids := bruteNearest(f.Points, k, maxDist, func(p [2]float64) float64 { return math.Hypot(p[0]-q[0], p[1]-q[1]) })
+			f.Nearest = append(f.Nearest, goldenNearest{q, k, maxDist, ids})
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		lng, lat := round(rnd.Float64()*360-180), round(rnd.Float64()*180-90)
+		k, maxKm := 1+rnd.Intn(20), 0.0
+		if i%2 == 1 {
+			k, maxKm = 0, round(rnd.Float64()*1000)
+		}
+		ids := bruteNearest(geo.Points, k, maxKm, func(p [2]float64) float64 {
+			return GeoDistance(LngLat{lng, lat}, LngLat{p[0], p[1]}, Kilometers)
+		})
+		geo.Around = append(geo.Around, goldenAround{lng, lat, k, maxKm, ids})
+	}
+
+	for _, f := range []*goldenFixture{&canonical, &uniform, &duplicates, &geo} {
+		data, err := json.MarshalIndent(f, "", "  ")
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join("testdata", "golden", f.Name+".json"), append(data, '\n'), 0644))
+	}
+}
+
+func bruteForce(points [][2]float64, match func(p [2]float64) bool) []int {
+	ids := []int{}
+	for i, p := range points {
+		if match(p) {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+func bruteNearest(points [][2]float64, k int, maxDist float64, dist func(p [2]float64) float64) []int {
+	items := []ItemDist{}
+	for i, p := range points {
+		if d := dist(p); maxDist <= 0 || d <= maxDist {
+			items = append(items, ItemDist{i, d})
+		}
+	}
+	sorting.SliceStable(items, func(i, j int) bool { return items[i].Dist < items[j].Dist })
+	if k > 0 && len(items) > k {
+		items = items[:k]
+	}
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.Index
+	}
+	return ids
+}
@@ -0,0 +1,78 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// FNV-1a 64-bit constants
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// Hash of coordinates of the indexed points in order of original indices, removed points are included.
+// Equals HashPoints of the input points, if the build didn't drop, clamp or project them,
+// so a scheduled rebuild could be skipped when the new extract has the same hash as the current index.
+// The hash is stable: it doesn't depend on node size, build options, process or platform.
+func (bush *KDBush) ContentHash() uint64 {
+	h := uint64(fnvOffset)
+	for idx := 0; idx < bush.originalCount(); idx++ {
+		if i := bush.TreePos(idx); i >= 0 {
+			h = fnvPoint(h, bush.x(i), bush.y(i))
+		}
+	}
+	return h
+}
+
+// Same as ContentHash, but doesn't depend on the order of points: the same set of points in any order has the same hash.
+// Equals HashPointsUnordered of the input points, if the build didn't drop, clamp or project them.
+func (bush *KDBush) ContentHashUnordered() uint64 {
+	var h uint64
+	for i := 0; i < bush.size(); i++ {
+		h += pointHash(bush.x(i), bush.y(i))
+	}
+	return hashIndex(h, uint64(bush.size()))
+}
+
+// Hash of coordinates of the points in their order, see ContentHash
+func HashPoints(points []Point) uint64 {
+	h := uint64(fnvOffset)
+	for _, p := range points {
+		x, y := p.Coordinates()
+		h = fnvPoint(h, x, y)
+	}
+	return h
+}
+
+// Hash of coordinates of the points, that doesn't depend on their order, see ContentHashUnordered
+func HashPointsUnordered(points []Point) uint64 {
+	var h uint64
+	for _, p := range points {
+		h += pointHash(p.Coordinates())
+	}
+	return hashIndex(h, uint64(len(points)))
+}
+
+// adds bytes of both coordinates to FNV-1a hash
+func fnvPoint(h uint64, x, y float64) uint64 {
+	for _, v := range [2]uint64{coordBits(x), coordBits(y)} {
+		for s := 0; s < 64; s += 8 {
+			h ^= (v >> uint(s)) & 0xff
+			h *= fnvPrime
+		}
+	}
+	return h
+}
+
+// well mixed hash of the point, sums of them don't cancel out like xors of duplicates do
+func pointHash(x, y float64) uint64 {
+	return hashIndex(coordBits(x)^hashIndex(coordBits(y), 0), 0)
+}
+
+// bits of the coordinate, negative zero is the same as zero
+func coordBits(v float64) uint64 {
+	if v == 0 {
+		return 0
+	}
+	return math.Float64bits(v)
+}
@@ -0,0 +1,39 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_ContentHash(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	assert.Equal(t, HashPoints(points), bush.ContentHash())
+	assert.Equal(t, HashPointsUnordered(points), bush.ContentHashUnordered())
+
+	//doesn't depend on the tree
+	assert.Equal(t, bush.ContentHash(), NewBush(points, 3).ContentHash())
+	bush.Remove(5)
+	assert.Equal(t, HashPoints(points), bush.ContentHash())
+
+	shuffled := append([]Point(nil), points...)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	assert.NotEqual(t, HashPoints(points), HashPoints(shuffled))
+	assert.Equal(t, HashPointsUnordered(points), HashPointsUnordered(shuffled))
+
+	changed := append([]Point(nil), points...)
+	changed[3] = &SimplePoint{X: 1e-9, Y: 0}
+	assert.NotEqual(t, HashPoints(points), HashPoints(changed))
+	assert.NotEqual(t, HashPointsUnordered(points), HashPointsUnordered(changed))
+
+	//duplicates don't cancel out
+	a := &SimplePoint{X: 1, Y: 2}
+	assert.NotEqual(t, HashPointsUnordered([]Point{a}), HashPointsUnordered([]Point{a, a, a}))
+	assert.NotEqual(t, HashPointsUnordered(nil), HashPointsUnordered([]Point{a, a}))
+	assert.Equal(t, HashPoints([]Point{&SimplePoint{X: 0}}), HashPoints([]Point{&SimplePoint{X: -1 * 0.0}}))
+
+	//stable across versions and platforms
+	assert.Equal(t, uint64(0x2f121cea1c5c97f8), HashPoints([]Point{a})) //FNV-1a of little endian bytes of 1.0 and 2.0
+}
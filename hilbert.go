@@ -0,0 +1,81 @@
+package kdbush
+
+// Computes Hilbert curve key for every point, normalized to the bounding box of the indexed points.
+// Points close on the curve are close in space, locality is better than of Morton keys.
+// Returns slice of keys, where key i belongs to the point i of the original points input slice.
+// Input:
+// precisionBits - number of bits per axis, from 1 to 32. Values out of this range are clamped.
+func (bush *KDBush) HilbertKeys(precisionBits int) []uint64 {
+	precisionBits = iMax(1, iMin(MaxMortonBits, precisionBits))
+	cells := float64(uint64(1)<<uint(precisionBits)) - 1
+	b := bush.bbox
+
+	keys := make([]uint64, bush.originalCount())
+	for i := 0; i < bush.size(); i++ {
+		idx := bush.idx(i)
+		x := quantize(bush.x(i), b[0], b[2], cells)
+		y := quantize(bush.y(i), b[1], b[3], cells)
+		keys[idx] = hilbert(x, y, uint(precisionBits))
+	}
+	return keys
+}
+
+// Same as NewBushWithOptions with WithHilbertLeafOrder option: points of every leaf are ordered along Hilbert curve,
+// the tree is built by median splits as usual
+func NewBushHilbert(points []Point, nodeSize int, opts ...Option) *KDBush {
+	return NewBushWithOptions(points, nodeSize, append(opts, WithHilbertLeafOrder())...)
+}
+
+// sorts points inside of every leaf of the sorted tree by their Hilbert keys
+func (bush *KDBush) orderLeavesHilbert() {
+	const bits = 16
+	cells := float64(uint64(1)<<bits) - 1
+	minX, minY, maxX, maxY := coordsBounds(bush.Coords)
+	keys := make([]uint64, len(bush.Idxs))
+	for i := range keys {
+		x := quantize(bush.Coords[2*i], minX, maxX, cells)
+		y := quantize(bush.Coords[2*i+1], minY, maxY, cells)
+		keys[i] = hilbert(x, y, bits)
+	}
+
+	var order func(left, right int)
+	order = func(left, right int) {
+		if right-left > bush.NodeSize {
+			m := floor(float64(left+right) / 2.0)
+			order(left, m-1)
+			order(m+1, right)
+			return
+		}
+		//insertion sort, leaves are small
+		for i := left + 1; i <= right; i++ {
+			for j := i; j > left && keys[j] < keys[j-1]; j-- {
+				keys[j], keys[j-1] = keys[j-1], keys[j]
+				swapItem(bush.Idxs, bush.Coords, j, j-1)
+			}
+		}
+	}
+	order(0, len(bush.Idxs)-1)
+}
+
+// distance along Hilbert curve of order bits to the cell x, y
+func hilbert(x, y uint32, bits uint) uint64 {
+	var d uint64
+	for s := uint32(1) << (bits - 1); s > 0; s >>= 1 {
+		var rx, ry uint32
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+		d += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+		//rotate the quadrant, only lower bits are used further, so flipping all bits is fine
+		if ry == 0 {
+			if rx == 1 {
+				x, y = ^x, ^y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
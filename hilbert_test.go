@@ -0,0 +1,63 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_HilbertKeys(t *testing.T) {
+	points := []Point{
+		&SimplePoint{X: 0, Y: 0},
+		&SimplePoint{X: 1, Y: 0},
+		&SimplePoint{X: 0, Y: 1},
+		&SimplePoint{X: 1, Y: 1},
+	}
+	bush := NewBush(points, 10)
+	assert.Equal(t, []uint64{0, 3, 1, 2}, bush.HilbertKeys(1))
+
+	//consecutive cells of the curve are neighbours
+	grid := []Point{}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			grid = append(grid, &SimplePoint{X: float64(x), Y: float64(y)})
+		}
+	}
+	keys := NewBush(grid, 10).HilbertKeys(3)
+	byKey := make([]int, 64)
+	for i, k := range keys {
+		byKey[k] = i
+	}
+	for k := 1; k < 64; k++ {
+		a, b := byKey[k-1], byKey[k]
+		dx, dy := a%8-b%8, a/8-b/8
+		assert.Equal(t, 1, dx*dx+dy*dy, "key %d", k)
+	}
+	assert.Len(t, NewBush(grid, 10).HilbertKeys(32), 64)
+}
+
+func TestNewBushHilbert(t *testing.T) {
+	points := benchmarkPoints()[:5000]
+	plain := NewBush(points, 16)
+	bush := NewBushHilbert(points, 16)
+	assert.NoError(t, bush.Verify())
+	assert.NotEqual(t, plain.Idxs, bush.Idxs)
+
+	q := &SimplePoint{X: 300, Y: 600}
+	assert.Equal(t, sortedInts(plain.Range(100, 200, 400, 500)), sortedInts(bush.Range(100, 200, 400, 500)))
+	assert.Equal(t, sortedInts(plain.Within(q, 80)), sortedInts(bush.Within(q, 80)))
+	assert.Equal(t, plain.Nearest(q, 10, 0), bush.Nearest(q, 10, 0))
+	for i, idx := range bush.Idxs {
+		assert.Equal(t, i, bush.TreePos(idx))
+	}
+
+	//keys are non-decreasing inside of the first leaf
+	keys := bush.HilbertKeys(16)
+	left, right := 0, len(bush.Idxs)-1
+	for right-left > 16 {
+		right = floor(float64(left+right)/2.0) - 1
+	}
+	for i := left + 1; i <= right; i++ {
+		assert.True(t, keys[bush.Idxs[i-1]] <= keys[bush.Idxs[i]])
+	}
+}
@@ -0,0 +1,49 @@
+package kdbush
+
+// Metadata of a query result, tells clients whether the result is partial
+type ResultInfo struct {
+	//The result is the same as the query would return without MaxNodesVisited, Deadline and Sample options
+	Exact bool
+	//Traversal was stopped by MaxNodesVisited or Deadline before all candidate nodes were searched
+	Truncated    bool
+	NodesVisited int //number of tree nodes visited by the query
+}
+
+func (cfg *queryConfig) info() ResultInfo {
+	truncated := cfg.truncated || cfg.timedOut
+	return ResultInfo{
+		Exact:        !truncated && !cfg.sampled,
+		Truncated:    truncated,
+		NodesVisited: cfg.visited,
+	}
+}
+
+// Same as RangeWithOptions, but returns metadata of the result instead of the truncated flag
+func (bush *KDBush) RangeInfo(minX, minY, maxX, maxY float64, opts ...QueryOption) ([]int, ResultInfo) {
+	cfg := newQueryConfig(opts)
+	result, _ := bush.rangeWith(minX, minY, maxX, maxY, cfg)
+	return result, cfg.info()
+}
+
+// Same as WithinWithOptions, but returns metadata of the result instead of the truncated flag
+func (bush *KDBush) WithinInfo(point Point, radius float64, opts ...QueryOption) ([]int, ResultInfo) {
+	cfg := newQueryConfig(opts)
+	result, _ := bush.withinWith(point, radius, cfg)
+	return result, cfg.info()
+}
+
+// Same as Query, but returns metadata of the result instead of the truncated flag
+func (bush *KDBush) QueryInfo(geom QueryGeom, opts ...QueryOption) ([]int, ResultInfo) {
+	cfg := newQueryConfig(opts)
+	result, _ := queryAs(bush, AsIndices(), geom, cfg)
+	return result, cfg.info()
+}
+
+// Same as Nearest, but returns metadata of the result.
+// After MaxNodesVisited or Deadline stops the search, the result is completed with the closest of already seen candidates,
+// so it could have points farther than the true k nearest.
+func (bush *KDBush) NearestInfo(point Point, k int, maxDist float64, opts ...QueryOption) ([]int, ResultInfo) {
+	cfg := newQueryConfig(opts)
+	result := bush.nearestWith(point, k, maxDist, cfg)
+	return result, cfg.info()
+}
@@ -0,0 +1,57 @@
+package kdbush
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_RangeInfo(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	result, info := bush.RangeInfo(20, 30, 50, 70)
+	assert.Equal(t, bush.Range(20, 30, 50, 70), result)
+	assert.True(t, info.Exact)
+	assert.False(t, info.Truncated)
+	assert.Greater(t, info.NodesVisited, 1)
+
+	result, info = bush.RangeInfo(2, 2, 99, 99, MaxNodesVisited(1))
+	assert.Len(t, result, 1)
+	assert.Equal(t, ResultInfo{Exact: false, Truncated: true, NodesVisited: 1}, info)
+
+	_, info = bush.RangeInfo(-1, -1, 101, 101)
+	assert.Equal(t, ResultInfo{Exact: true, NodesVisited: 1}, info, "all points are scanned without traversal")
+
+	_, info = bush.RangeInfo(20, 30, 50, 70, Sample(0.5, 1))
+	assert.False(t, info.Exact)
+	assert.False(t, info.Truncated)
+
+	q := &SimplePoint{X: 50, Y: 50}
+	result, info = bush.WithinInfo(q, 20)
+	assert.Equal(t, bush.Within(q, 20), result)
+	assert.True(t, info.Exact)
+	_, info = bush.WithinInfo(q, 40, MaxNodesVisited(3))
+	assert.Equal(t, ResultInfo{Truncated: true, NodesVisited: 3}, info)
+
+	result, info = bush.QueryInfo(Circle{X: 50, Y: 50, Radius: 20})
+	assert.ElementsMatch(t, bush.Within(q, 20), result)
+	assert.True(t, info.Exact)
+}
+
+func TestKDBush_NearestInfo(t *testing.T) {
+	bush := NewBush(getTestPoints(), 5)
+	q := &SimplePoint{X: 50, Y: 50}
+	result, info := bush.NearestInfo(q, 5, 0)
+	assert.Equal(t, bush.Nearest(q, 5, 0), result)
+	assert.True(t, info.Exact)
+	assert.Positive(t, info.NodesVisited)
+
+	result, info = bush.NearestInfo(q, 5, 0, MaxNodesVisited(1))
+	assert.True(t, info.Truncated)
+	assert.False(t, info.Exact)
+	assert.Equal(t, 1, info.NodesVisited)
+	assert.Len(t, result, 1, "only the median of the root is seen")
+
+	_, approximate := bush.KNNDeadline(q, 5, time.Now().Add(time.Hour), MaxNodesVisited(2))
+	assert.True(t, approximate)
+}
@@ -1,7 +1,10 @@
 package kdbush
 
 import (
+	"fmt"
 	"math"
+	sorting "sort"
+	"sync"
 )
 
 // Interface, that should be implemented by indexing structure
@@ -23,15 +26,46 @@ func (sp *SimplePoint) Coordinates() (float64, float64) {
 
 // A very fast static spatial index for 2D points based on a flat KD-tree.
 // Points only, no rectangles
-// static (no add, remove items)
+// static (no add items, removed items are only marked with tombstones)
 // 2 dimensional
-// indexing 16-40 times faster then  rtreego(https://github.com/dhconnelly/rtreego) (TODO: benchmark)
+// fast indexing and queries, see benchmarks against brute force and rtreego(https://github.com/dhconnelly/rtreego)
 type KDBush struct {
 	NodeSize int
 	Points   []Point
 
 	Idxs   []int     //array of indexes
 	Coords []float64 //array of coordinates
+
+	bbox  [4]float64 //bounding box of all points: minX, minY, maxX, maxY
+	input int        //number of points in the original input
+	pos   []int      //inverse of Idxs: position in the tree by original index, -1 if not indexed
+
+	clamped, dropped int //points fixed or skipped at build by bounds option
+
+	removed      []uint64 //bitset of removed original indexes
+	removedCount int
+
+	aux  map[string]interface{} //auxiliary per-point arrays by name
+	aggs map[string]*aggregate  //precomputed subtree aggregates of float64 aux arrays by name
+
+	projection Projection //projection of lng, lat input, nil if coordinates are not transformed
+
+	frozen bool //index is read-only, see Freeze
+
+	nodeBoxes []float64 //tight bounding boxes of the nodes, set by WithNodeBounds option
+
+	//groups of points with the same coordinates, set by WithDedup option if there are duplicates
+	dupRep     []int //representative of every original index, -1 if not indexed
+	dupStart   []int //members of the representative on tree position i are dupMembers[dupStart[i]:dupStart[i+1]]
+	dupMembers []int
+	collapsed  int
+
+	//compact storage of Idxs, Coords and pos, set by WithCompactStorage option
+	coords32 []float32
+	idxs32   []uint32
+	pos32    []uint32 //math.MaxUint32 if not indexed
+
+	splitAxes []uint8 //split axis by position of the node median, set by WithSpreadSplit option, nil means alternating axes
 }
 
 // Create new index from points
@@ -42,14 +76,114 @@ type KDBush struct {
 // nodeSize  - size of the KD-tree node, 64 by default. Higher means faster indexing but slower search, and vise versa.
 func NewBush(points []Point, nodeSize int) *KDBush {
 	b := KDBush{}
-	b.buildIndex(points, nodeSize)
+	b.buildIndex(points, nodeSize, &buildConfig{})
+	return &b
+}
+
+// Create new index from coordinate pairs, without Point interface
+// Points field of the index is nil, query results are indices in pairs slice
+func NewBushFromPairs(pairs [][2]float64, nodeSize int) *KDBush {
+	b := KDBush{NodeSize: nodeSize, input: len(pairs)}
+	b.Idxs = make([]int, len(pairs))
+	b.Coords = make([]float64, 2*len(pairs))
+	for i, p := range pairs {
+		b.Idxs[i] = i
+		b.Coords[2*i] = p[0]
+		b.Coords[2*i+1] = p[1]
+	}
+	b.sortIndex()
 	return &b
 }
 
+// Create new index from slice of coordinates, every item should have exactly 2 values: x and y
+// Returns error if any item has another length
+func NewBushFromSlices(coords [][]float64, nodeSize int) (*KDBush, error) {
+	b := KDBush{NodeSize: nodeSize, input: len(coords)}
+	b.Idxs = make([]int, len(coords))
+	b.Coords = make([]float64, 2*len(coords))
+	for i, c := range coords {
+		if len(c) != 2 {
+			return nil, fmt.Errorf("kdbush: item %d has %d coordinates, expected 2", i, len(c))
+		}
+		b.Idxs[i] = i
+		b.Coords[2*i] = c[0]
+		b.Coords[2*i+1] = c[1]
+	}
+	b.sortIndex()
+	return &b, nil
+}
+
+// Create new index from interleaved coordinates: x0, y0, x1, y1, ...
+// Coordinates are not copied: the index takes ownership of the slice and reorders it, so it shouldn't be used after the call.
+// Panics if the slice has odd length.
+func NewBushFromCoords(coords []float64, nodeSize int) *KDBush {
+	if len(coords)%2 != 0 {
+		panic(fmt.Sprintf("kdbush: odd number of coordinates %d", len(coords)))
+	}
+	return newBushOwningCoords(coords, nodeSize)
+}
+
+// Create new index from columns of x and y coordinates, e.g. read from Parquet or CSV.
+// Columns are copied into one interleaved slice without per point allocations.
+// Panics if the columns have different length.
+func NewBushFromXY(xs, ys []float64, nodeSize int) *KDBush {
+	if len(xs) != len(ys) {
+		panic(fmt.Sprintf("kdbush: %d x coordinates and %d y coordinates", len(xs), len(ys)))
+	}
+	coords := make([]float64, 2*len(xs))
+	for i := range xs {
+		coords[2*i] = xs[i]
+		coords[2*i+1] = ys[i]
+	}
+	return newBushOwningCoords(coords, nodeSize)
+}
+
+// builds the index on top of the interleaved coordinates slice
+func newBushOwningCoords(coords []float64, nodeSize int) *KDBush {
+	n := len(coords) / 2
+	bush := &KDBush{NodeSize: nodeSize, input: n, Coords: coords}
+	bush.Idxs = make([]int, n)
+	for i := range bush.Idxs {
+		bush.Idxs[i] = i
+	}
+	bush.sortIndex()
+	return bush
+}
+
+// Returns position of the point with original index idx in Idxs and Coords arrays,
+// or -1 if the point is not in the index. Constant time.
+func (bush *KDBush) TreePos(idx int) int {
+	if idx < 0 || idx >= bush.posLen() {
+		return -1
+	}
+	return bush.treePos(idx)
+}
+
 // Finds all items within the given bounding box and returns an array of indices that refer to the items in the original points input slice.
+// If the box contains all the points, they are returned without traversal, in tree order.
 func (bush *KDBush) Range(minX, minY, maxX, maxY float64) []int {
-	stack := []int{0, len(bush.Idxs) - 1, 0}
-	result := []int{}
+	return bush.RangeAppend([]int{}, minX, minY, maxX, maxY)
+}
+
+// Same as Range, but appends results to dst and returns the extended slice.
+// Reusing dst between queries, e.g. dst[:0], makes queries zero-allocation, when it has enough capacity.
+func (bush *KDBush) RangeAppend(dst []int, minX, minY, maxX, maxY float64) []int {
+	result := dst
+	if bush.CoversAll(minX, minY, maxX, maxY) {
+		if need := bush.size() - bush.removedCount; cap(result)-len(result) < need {
+			result = append(make([]int, 0, len(result)+need), result...)
+		}
+		for i := 0; i < bush.size(); i++ {
+			idx := bush.idx(i)
+			if !bush.removedAt(i) {
+				result = append(result, idx)
+			}
+		}
+		return result
+	}
+
+	sp := stackPool.Get().(*[]int)
+	stack := append((*sp)[:0], 0, bush.size()-1, 0)
 	var x, y float64
 
 	for len(stack) > 0 {
@@ -62,10 +196,10 @@ func (bush *KDBush) Range(minX, minY, maxX, maxY float64) []int {
 
 		if right-left <= bush.NodeSize {
 			for i := left; i <= right; i++ {
-				x = bush.Coords[2*i]
-				y = bush.Coords[2*i+1]
-				if x >= minX && x <= maxX && y >= minY && y <= maxY {
-					result = append(result, bush.Idxs[i])
+				x = bush.x(i)
+				y = bush.y(i)
+				if x >= minX && x <= maxX && y >= minY && y <= maxY && !bush.removedAt(i) {
+					result = append(result, bush.idx(i))
 				}
 			}
 			continue
@@ -73,13 +207,14 @@ func (bush *KDBush) Range(minX, minY, maxX, maxY float64) []int {
 
 		m := floor(float64(left+right) / 2.0)
 
-		x = bush.Coords[2*m]
-		y = bush.Coords[2*m+1]
+		x = bush.x(m)
+		y = bush.y(m)
 
-		if x >= minX && x <= maxX && y >= minY && y <= maxY {
-			result = append(result, bush.Idxs[m])
+		if x >= minX && x <= maxX && y >= minY && y <= maxY && !bush.removedAt(m) {
+			result = append(result, bush.idx(m))
 		}
 
+		axis = bush.splitAxis(m, axis)
 		nextAxis := (axis + 1) % 2
 
 		if (axis == 0 && minX <= x) || (axis != 0 && minY <= y) {
@@ -95,15 +230,31 @@ func (bush *KDBush) Range(minX, minY, maxX, maxY float64) []int {
 		}
 
 	}
+	putStack(sp, stack)
 	return result
 }
 
+// Returns true if the bounding box contains all points of the index, e.g. for "zoomed way out" viewports
+func (bush *KDBush) CoversAll(minX, minY, maxX, maxY float64) bool {
+	b := bush.bbox
+	return bush.size() > 0 && minX <= b[0] && minY <= b[1] && maxX >= b[2] && maxY >= b[3]
+}
+
 // Finds all items within a given radius from the query point and returns an array of indices.
 func (bush *KDBush) Within(point Point, radius float64) []int {
-	stack := []int{0, len(bush.Idxs) - 1, 0}
-	result := []int{}
-	r2 := radius * radius
+	return bush.WithinAppend([]int{}, point, radius)
+}
+
+// Same as Within, but appends results to dst and returns the extended slice, see RangeAppend
+func (bush *KDBush) WithinAppend(dst []int, point Point, radius float64) []int {
 	qx, qy := point.Coordinates()
+	if bush.nodeBoxes != nil {
+		return bush.withinNodeBoxes(dst, qx, qy, radius)
+	}
+	sp := stackPool.Get().(*[]int)
+	stack := append((*sp)[:0], 0, bush.size()-1, 0)
+	result := dst
+	r2 := radius * radius
 
 	for len(stack) > 0 {
 		axis := stack[len(stack)-1]
@@ -115,22 +266,23 @@ func (bush *KDBush) Within(point Point, radius float64) []int {
 
 		if right-left <= bush.NodeSize {
 			for i := left; i <= right; i++ {
-				dst := sqrtDist(bush.Coords[2*i], bush.Coords[2*i+1], qx, qy)
-				if dst <= r2 {
-					result = append(result, bush.Idxs[i])
+				d := sqrtDist(bush.x(i), bush.y(i), qx, qy)
+				if d <= r2 && !bush.removedAt(i) {
+					result = append(result, bush.idx(i))
 				}
 			}
 			continue
 		}
 
 		m := floor(float64(left+right) / 2.0)
-		x := bush.Coords[2*m]
-		y := bush.Coords[2*m+1]
+		x := bush.x(m)
+		y := bush.y(m)
 
-		if sqrtDist(x, y, qx, qy) <= r2 {
-			result = append(result, bush.Idxs[m])
+		if sqrtDist(x, y, qx, qy) <= r2 && !bush.removedAt(m) {
+			result = append(result, bush.idx(m))
 		}
 
+		axis = bush.splitAxis(m, axis)
 		nextAxis := (axis + 1) % 2
 
 		if (axis == 0 && (qx-radius <= x)) || (axis != 0 && (qy-radius <= y)) {
@@ -145,7 +297,95 @@ func (bush *KDBush) Within(point Point, radius float64) []int {
 			stack = append(stack, nextAxis)
 		}
 	}
+	putStack(sp, stack)
+	return result
+}
+
+// Same as Range, but calls fn for every found item instead of collecting them into a slice.
+// fn could return false to stop the search.
+func (bush *KDBush) RangeFunc(minX, minY, maxX, maxY float64, fn func(idx int) bool) {
+	bush.search(minX, minY, maxX, maxY, &queryConfig{}, func(i int) bool {
+		return fn(bush.idx(i))
+	})
+}
+
+// Same as Within, but calls fn for every found item instead of collecting them into a slice.
+// fn could return false to stop the search.
+func (bush *KDBush) WithinFunc(point Point, radius float64, fn func(idx int) bool) {
+	r2 := radius * radius
+	qx, qy := point.Coordinates()
+	bush.search(qx-radius, qy-radius, qx+radius, qy+radius, &queryConfig{}, func(i int) bool {
+		if sqrtDist(bush.x(i), bush.y(i), qx, qy) <= r2 {
+			return fn(bush.idx(i))
+		}
+		return true
+	})
+}
+
+// Same as Within, but returns found items with their distances to the query point
+func (bush *KDBush) WithinDist(point Point, radius float64) []ItemDist {
+	result := []ItemDist{}
+	r2 := radius * radius
+	qx, qy := point.Coordinates()
+	bush.search(qx-radius, qy-radius, qx+radius, qy+radius, &queryConfig{}, func(i int) bool {
+		if d := sqrtDist(bush.x(i), bush.y(i), qx, qy); d <= r2 {
+			result = append(result, ItemDist{bush.idx(i), math.Sqrt(d)})
+		}
+		return true
+	})
+	return result
+}
+
+// Same as WithinDist, but results are sorted by distance ascending, items at the same distance - by index
+func (bush *KDBush) WithinDistSorted(point Point, radius float64) []ItemDist {
+	result := bush.WithinDist(point, radius)
+	sorting.Slice(result, func(i, j int) bool {
+		if result[i].Dist != result[j].Dist {
+			return result[i].Dist < result[j].Dist
+		}
+		return result[i].Index < result[j].Index
+	})
+	return result
+}
+
+// Finds all items within a given radius from the query point, that are also inside of the bounding box, e.g. of the map viewport.
+// Both constraints prune the tree in one traversal, only nodes intersecting both the circle and the box are visited.
+func (bush *KDBush) WithinRange(point Point, radius float64, minX, minY, maxX, maxY float64) []int {
+	result := []int{}
+	qx, qy := point.Coordinates()
+	r2 := radius * radius
+	//only the part of the box, that could intersect the circle
+	minX, minY = math.Max(minX, qx-radius), math.Max(minY, qy-radius)
+	maxX, maxY = math.Min(maxX, qx+radius), math.Min(maxY, qy+radius)
+	inside := func(i int) bool {
+		x, y := bush.x(i), bush.y(i)
+		return x >= minX && x <= maxX && y >= minY && y <= maxY && sqrtDist(x, y, qx, qy) <= r2 && !bush.removedAt(i)
+	}
+	stack := []treeNode{bush.rootNode()}
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
 
+		if n.right < n.left || n.minX > maxX || n.maxX < minX || n.minY > maxY || n.maxY < minY || boxDistSq(qx, qy, n) > r2 {
+			continue
+		}
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				if inside(i) {
+					result = append(result, bush.idx(i))
+				}
+			}
+			continue
+		}
+
+		m := floor(float64(n.left+n.right) / 2.0)
+		if inside(m) {
+			result = append(result, bush.idx(m))
+		}
+		l, r := bush.children(n, m)
+		stack = append(stack, l, r)
+	}
 	return result
 }
 
@@ -155,41 +395,157 @@ func (bush *KDBush) Within(point Point, radius float64) []int {
 /// Sorting stuff
 ////////////////////////////////////////////////////////////////
 
-func (bush *KDBush) buildIndex(points []Point, nodeSize int) {
-	bush.NodeSize = nodeSize
+func (bush *KDBush) buildIndex(points []Point, nodeSize int, cfg *buildConfig) {
+	points = copyPoints(points, cfg)
 	bush.Points = points
+	bush.buildFrom(len(points), func(i int) (float64, float64) { return points[i].Coordinates() }, nodeSize, cfg)
+}
 
-	bush.Idxs = make([]int, len(points))
-	bush.Coords = make([]float64, 2*len(points))
+// builds the index from n points, coordinates of the point i are returned by at
+func (bush *KDBush) buildFrom(n int, at func(i int) (float64, float64), nodeSize int, cfg *buildConfig) {
+	if cfg.nodeSize > 0 {
+		nodeSize = cfg.nodeSize
+	}
+	if len(cfg.hooks) > 0 {
+		defer cfg.runHooks(n)()
+	}
+	bush.NodeSize = nodeSize
+	bush.input = n
+	bush.projection = cfg.projection
 
-	for i, v := range points {
-		bush.Idxs[i] = i
-		x, y := v.Coordinates()
-		bush.Coords[i*2] = x
-		bush.Coords[i*2+1] = y
+	bush.Idxs = make([]int, 0, n)
+	bush.Coords = make([]float64, 0, 2*n)
+
+	for i := 0; i < n; i++ {
+		x, y := at(i)
+		if cfg.skipInvalid && !isFinite(x, y) {
+			bush.dropped++
+			continue
+		}
+		if cfg.bounded {
+			var keep bool
+			if x, y, keep = cfg.bound(bush, x, y); !keep {
+				continue
+			}
+		}
+		if cfg.projection != nil {
+			x, y = cfg.projection.Forward(x, y)
+		}
+		if cfg.compact {
+			x, y = float64(float32(x)), float64(float32(y))
+		}
+		bush.Idxs = append(bush.Idxs, i)
+		bush.Coords = append(bush.Coords, x, y)
+	}
+
+	if cfg.dedup {
+		bush.collapseDuplicates()
+	}
+
+	if cfg.spreadSplit {
+		bush.splitAxes = make([]uint8, len(bush.Idxs))
 	}
+	if cfg.spreadSplit || !cfg.presorted || bush.verifyOrder(0, len(bush.Idxs)-1, 0) != nil {
+		s := sorter{bush.Idxs, bush.Coords, bush.NodeSize, cfg.sortThreshold, nil, bush.splitAxes}
+		if s.threshold <= 0 {
+			s.threshold = DefaultSortThreshold
+		}
+		if cfg.workers > 1 {
+			s.workers = make(chan struct{}, cfg.workers-1)
+		}
+		s.sort(0, len(bush.Idxs)-1, 0)
+	}
+	if cfg.hilbertLeaves {
+		bush.orderLeavesHilbert()
+	}
+	bush.derive()
+	bush.groupDuplicates()
+	if cfg.nodeBounds {
+		bush.computeNodeBoxes()
+	}
+	if cfg.compact {
+		bush.compact()
+	}
+}
 
-	sort(bush.Idxs, bush.Coords, bush.NodeSize, 0, len(bush.Idxs)-1, 0)
+// sorts already filled Idxs and Coords
+func (bush *KDBush) sortIndex() {
+	s := sorter{bush.Idxs, bush.Coords, bush.NodeSize, DefaultSortThreshold, nil, nil}
+	s.sort(0, len(bush.Idxs)-1, 0)
+	bush.derive()
 }
 
-func sort(Idxs []int, Coords []float64, nodeSize int, left, right, depth int) {
-	if (right - left) <= nodeSize {
-		return
+// computes data derived from sorted Idxs and Coords
+func (bush *KDBush) derive() {
+	bush.bbox[0], bush.bbox[1], bush.bbox[2], bush.bbox[3] = coordsBounds(bush.Coords)
+	bush.pos = make([]int, bush.originalCount())
+	for i := range bush.pos {
+		bush.pos[i] = -1
 	}
+	for i, idx := range bush.Idxs {
+		bush.pos[idx] = i
+	}
+}
 
-	m := floor(float64(left+right) / 2.0)
+// Subarrays longer than this are sampled to choose the pivot of selection (Floyd-Rivest)
+const DefaultSortThreshold = 600
+
+// KD-sort of Idxs and Coords
+type sorter struct {
+	Idxs      []int
+	Coords    []float64
+	nodeSize  int
+	threshold int           //sampling threshold of selection
+	workers   chan struct{} //free slots for parallel sorting of subtrees, nil if sorting is sequential
+	axes      []uint8       //chosen split axes by median position, nil if axes alternate
+}
 
-	sselect(Idxs, Coords, m, left, right, depth%2)
+// minimal size of subtree, that is sorted by another worker
+const parallelSortMin = 4096
 
-	sort(Idxs, Coords, nodeSize, left, m-1, depth+1)
-	sort(Idxs, Coords, nodeSize, m+1, right, depth+1)
+func (s *sorter) sort(left, right, depth int) {
+	if (right - left) <= s.nodeSize {
+		return
+	}
+
+	m := floor(float64(left+right) / 2.0)
 
+	axis := depth % 2
+	if s.axes != nil {
+		//split by the axis with the largest spread
+		minX, minY, maxX, maxY := coordsBounds(s.Coords[2*left : 2*right+2])
+		axis = 0
+		if maxY-minY > maxX-minX {
+			axis = 1
+		}
+		s.axes[m] = uint8(axis)
+	}
+	sselect(s.Idxs, s.Coords, m, left, right, axis, s.threshold)
+
+	//subtrees don't overlap, so one of them could be sorted by a free worker
+	if s.workers != nil && m-left > parallelSortMin {
+		select {
+		case s.workers <- struct{}{}:
+			done := make(chan struct{})
+			go func() {
+				s.sort(left, m-1, depth+1)
+				<-s.workers
+				close(done)
+			}()
+			s.sort(m+1, right, depth+1)
+			<-done
+			return
+		default:
+		}
+	}
+	s.sort(left, m-1, depth+1)
+	s.sort(m+1, right, depth+1)
 }
 
-func sselect(Idxs []int, Coords []float64, k, left, right, inc int) {
+func sselect(Idxs []int, Coords []float64, k, left, right, inc, threshold int) {
 	//whatever you want
 	for right > left {
-		if (right - left) > 600 {
+		if (right - left) > threshold {
 			n := right - left + 1
 			m := k - left + 1
 			z := math.Log(float64(n))
@@ -202,7 +558,7 @@ func sselect(Idxs []int, Coords []float64, k, left, right, inc int) {
 			sd := 0.5 * math.Sqrt(z*s*n_s/float64(n)) * sds
 			newLeft := iMax(left, floor(float64(k)-float64(m)*s/float64(n)+sd))
 			newRight := iMin(right, floor(float64(k)+float64(n-m)*s/float64(n)+sd))
-			sselect(Idxs, Coords, k, newLeft, newRight, inc)
+			sselect(Idxs, Coords, k, newLeft, newRight, inc, threshold)
 		}
 
 		t := Coords[2*k+inc]
@@ -276,6 +632,18 @@ func iMin(a, b int) int {
 	}
 }
 
+// traversal stacks of queries, reused to keep queries zero-allocation
+var stackPool = sync.Pool{New: func() interface{} {
+	s := make([]int, 0, 3*64)
+	return &s
+}}
+
+// returns the stack, that could be grown by the query, to the pool
+func putStack(sp *[]int, stack []int) {
+	*sp = stack[:0]
+	stackPool.Put(sp)
+}
+
 func floor(in float64) int {
 	out := math.Floor(in)
 	return int(out)
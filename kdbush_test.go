@@ -2,6 +2,7 @@ package kdbush
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -157,3 +158,197 @@ func index(slice []int, item int) int {
 	}
 	return -1
 }
+
+func TestNewBushFromPairs(t *testing.T) {
+	pairs := make([][2]float64, len(testPoints))
+	slices := make([][]float64, len(testPoints))
+	for i, p := range testPoints {
+		pairs[i] = [2]float64{p[0], p[1]}
+		slices[i] = p
+	}
+
+	bush := NewBushFromPairs(pairs, 10)
+	assert.Nil(t, bush.Points)
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+
+	bush, err := NewBushFromSlices(slices, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, testIdxs, bush.Idxs)
+		assert.Equal(t, testCoords, bush.Coords)
+	}
+
+	_, err = NewBushFromSlices([][]float64{{1, 2}, {1, 2, 3}}, 10)
+	assert.Error(t, err)
+}
+
+func TestNewBushFromCoords(t *testing.T) {
+	coords := make([]float64, 0, 2*len(testPoints))
+	xs, ys := make([]float64, len(testPoints)), make([]float64, len(testPoints))
+	for i, p := range testPoints {
+		coords = append(coords, p[0], p[1])
+		xs[i], ys[i] = p[0], p[1]
+	}
+
+	bush := NewBushFromCoords(coords, 10)
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+	assert.Same(t, &coords[0], &bush.Coords[0])
+
+	bush = NewBushFromXY(xs, ys, 10)
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+
+	assert.Panics(t, func() { NewBushFromCoords([]float64{1, 2, 3}, 10) })
+	assert.Panics(t, func() { NewBushFromXY(xs, ys[1:], 10) })
+}
+
+func TestKDBush_TreePos(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	for i, idx := range bush.Idxs {
+		assert.Equal(t, i, bush.TreePos(idx))
+	}
+	assert.Equal(t, -1, bush.TreePos(-1))
+	assert.Equal(t, -1, bush.TreePos(len(testPoints)))
+
+	dropped := NewBushWithOptions(dirtyPoints(), 10, WithDropOutOfBounds(-180, -90, 180, 90))
+	assert.Equal(t, -1, dropped.TreePos(1))
+	assert.NotEqual(t, -1, dropped.TreePos(4))
+}
+
+func TestKDBush_CoversAll(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.True(t, bush.CoversAll(-1, -1, 101, 101))
+	assert.False(t, bush.CoversAll(2, 1, 101, 101))
+	assert.False(t, NewBush(nil, 10).CoversAll(-1, -1, 1, 1))
+
+	assert.Equal(t, bush.Idxs, bush.Range(-1, -1, 101, 101))
+	bush.Remove(bush.Idxs[0])
+	assert.Equal(t, bush.Idxs[1:], bush.Range(-1, -1, 101, 101))
+}
+
+func BenchmarkKDBush_RangeAll(b *testing.B) {
+	bush := benchmarkBush()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bush.Range(-1, -1, benchExtent+1, benchExtent+1)
+	}
+}
+
+func BenchmarkKDBush_RangeAlmostAll(b *testing.B) {
+	bush := benchmarkBush()
+	minX := bush.bbox[0] + 1e-9
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bush.Range(minX, -1, benchExtent+1, benchExtent+1)
+	}
+}
+
+func TestKDBush_RangeFunc(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+
+	result := []int{}
+	bush.RangeFunc(20, 30, 50, 70, func(idx int) bool {
+		result = append(result, idx)
+		return true
+	})
+	assert.Equal(t, bush.Range(20, 30, 50, 70), result)
+
+	result = []int{}
+	bush.WithinFunc(&SimplePoint{X: 50, Y: 50}, 20, func(idx int) bool {
+		result = append(result, idx)
+		return true
+	})
+	assert.Equal(t, bush.Within(&SimplePoint{X: 50, Y: 50}, 20), result)
+
+	n := 0
+	bush.RangeFunc(0, 0, 100, 100, func(idx int) bool {
+		n++
+		return n < 3
+	})
+	assert.Equal(t, 3, n)
+}
+
+func BenchmarkKDBush_RangeFunc(b *testing.B) {
+	bush := benchmarkBush()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		bush.RangeFunc(500, 500, 520, 520, func(idx int) bool {
+			n++
+			return true
+		})
+	}
+}
+
+func TestKDBush_WithinDist(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	result := bush.WithinDist(q, 20)
+	within := bush.Within(q, 20)
+	if assert.Len(t, result, len(within)) {
+		for i, item := range result {
+			assert.Equal(t, within[i], item.Index)
+			x, y := points[item.Index].Coordinates()
+			assert.InDelta(t, math.Hypot(x-50, y-50), item.Dist, 1e-9)
+		}
+	}
+
+	sorted := bush.WithinDistSorted(q, 20)
+	assert.ElementsMatch(t, result, sorted)
+	for i := 1; i < len(sorted); i++ {
+		assert.True(t, sorted[i-1].Dist <= sorted[i].Dist)
+	}
+	nearest := bush.Nearest(q, 0, 20)
+	for i := range sorted {
+		assert.Equal(t, nearest[i], sorted[i].Index)
+	}
+}
+
+func TestKDBush_RangeAppend(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	result := bush.RangeAppend([]int{-1}, 20, 30, 50, 70)
+	assert.Equal(t, -1, result[0])
+	assert.Equal(t, bush.Range(20, 30, 50, 70), result[1:])
+	assert.Equal(t, bush.Within(q, 20), bush.WithinAppend(nil, q, 20))
+	assert.Equal(t, bush.Range(-1, -1, 101, 101), bush.RangeAppend(nil, -1, -1, 101, 101))
+
+	buf := make([]int, 0, len(testPoints))
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = bush.RangeAppend(buf[:0], 20, 30, 50, 70)
+		buf = bush.WithinAppend(buf, q, 20)
+	})
+	if !raceEnabled {
+		assert.Equal(t, 0.0, allocs)
+	}
+
+	withBoxes := NewBushWithOptions(getTestPoints(), 10, WithNodeBounds())
+	assert.Equal(t, sortedInts(bush.Within(q, 20)), sortedInts(withBoxes.WithinAppend(buf[:0], q, 20)))
+}
+
+func TestKDBush_WithinRange(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	for _, box := range [][4]float64{{0, 0, 100, 100}, {40, 40, 60, 100}, {55, 20, 80, 45}, {80, 80, 100, 100}} {
+		expected := []int{}
+		for _, idx := range bush.Within(q, 20) {
+			x, y := points[idx].Coordinates()
+			if x >= box[0] && y >= box[1] && x <= box[2] && y <= box[3] {
+				expected = append(expected, idx)
+			}
+		}
+		assert.Equal(t, sortedInts(expected), sortedInts(bush.WithinRange(q, 20, box[0], box[1], box[2], box[3])), "%v", box)
+	}
+
+	bush.RemoveRange(40, 40, 60, 60)
+	for _, idx := range bush.WithinRange(q, 20, 0, 0, 100, 100) {
+		assert.False(t, bush.IsRemoved(idx))
+	}
+}
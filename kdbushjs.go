@@ -0,0 +1,132 @@
+package kdbush
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// kdbush.js v4 ArrayBuffer layout, little endian:
+//
+//	magic 0xdb uint8, version<<4 | coords array type uint8, nodeSize uint16, numItems uint32
+//	ids numItems*uint16 if numItems < 65536, else numItems*uint32
+//	padding to 8 bytes, coords 2*numItems values of the array type
+const (
+	jsMagic       = 0xdb
+	jsVersion     = 1
+	jsHeaderSize  = 8
+	jsFloat64Type = 8
+	jsMaxU16Items = 65536
+)
+
+// sizes of JS typed arrays by array type: Int8, Uint8, Uint8Clamped, Int16, Uint16, Int32, Uint32, Float32, Float64
+var jsTypeSizes = [...]int{1, 1, 1, 2, 2, 4, 4, 4, 8}
+
+// Encodes the index into kdbush.js v4 format with Float64Array coordinates,
+// so it could be loaded in the browser with KDBush.from(buffer).
+// Returns error if the index has removed or dropped points, node size doesn't fit the format or axes don't alternate.
+func (bush *KDBush) Marshal() ([]byte, error) {
+	if bush.removedCount > 0 || bush.size() != bush.originalCount() {
+		return nil, errors.New("kdbush: index with removed or dropped points can't be encoded in kdbush.js format")
+	}
+	if bush.NodeSize < 1 || bush.NodeSize > math.MaxUint16 {
+		return nil, fmt.Errorf("kdbush: node size %d doesn't fit kdbush.js format", bush.NodeSize)
+	}
+	if bush.splitAxes != nil {
+		return nil, errors.New("kdbush: index built with WithSpreadSplit can't be encoded in kdbush.js format")
+	}
+	n := bush.size()
+	idSize := 2
+	if n >= jsMaxU16Items {
+		idSize = 4
+	}
+	coordsOffset := jsHeaderSize + n*idSize
+	coordsOffset += (8 - coordsOffset%8) % 8
+
+	data := make([]byte, coordsOffset+16*n)
+	data[0] = jsMagic
+	data[1] = jsVersion<<4 | jsFloat64Type
+	binary.LittleEndian.PutUint16(data[2:], uint16(bush.NodeSize))
+	binary.LittleEndian.PutUint32(data[4:], uint32(n))
+	for i := 0; i < n; i++ {
+		idx := bush.idx(i)
+		if idSize == 2 {
+			binary.LittleEndian.PutUint16(data[jsHeaderSize+2*i:], uint16(idx))
+		} else {
+			binary.LittleEndian.PutUint32(data[jsHeaderSize+4*i:], uint32(idx))
+		}
+	}
+	for i := 0; i < 2*n; i++ {
+		binary.LittleEndian.PutUint64(data[coordsOffset+8*i:], math.Float64bits(bush.coord(i/2, i%2)))
+	}
+	return data, nil
+}
+
+// Decodes the index from kdbush.js v4 format, written by Marshal or by KDBush.finish() in JavaScript.
+// Coordinates of any typed array are converted to float64.
+func UnmarshalKDBush(data []byte) (*KDBush, error) {
+	if len(data) < jsHeaderSize || data[0] != jsMagic {
+		return nil, ErrInvalidFormat
+	}
+	if version := data[1] >> 4; version != jsVersion {
+		return nil, fmt.Errorf("%w: unsupported kdbush.js version %d", ErrInvalidFormat, version)
+	}
+	arrayType := int(data[1] & 0x0f)
+	if arrayType >= len(jsTypeSizes) {
+		return nil, fmt.Errorf("%w: unknown array type %d", ErrInvalidFormat, arrayType)
+	}
+	nodeSize := int(binary.LittleEndian.Uint16(data[2:]))
+	n := int(binary.LittleEndian.Uint32(data[4:]))
+	idSize := 2
+	if n >= jsMaxU16Items {
+		idSize = 4
+	}
+	coordsOffset := jsHeaderSize + n*idSize
+	coordsOffset += (8 - coordsOffset%8) % 8
+	if len(data) < coordsOffset+2*n*jsTypeSizes[arrayType] {
+		return nil, fmt.Errorf("%w: data is too short", ErrInvalidFormat)
+	}
+
+	bush := &KDBush{NodeSize: nodeSize, input: n, Idxs: make([]int, n), Coords: make([]float64, 2*n)}
+	seen := make([]bool, n)
+	for i := range bush.Idxs {
+		idx := 0
+		if idSize == 2 {
+			idx = int(binary.LittleEndian.Uint16(data[jsHeaderSize+2*i:]))
+		} else {
+			idx = int(binary.LittleEndian.Uint32(data[jsHeaderSize+4*i:]))
+		}
+		if idx >= n || seen[idx] {
+			return nil, fmt.Errorf("%w: invalid id %d", ErrInvalidFormat, idx)
+		}
+		seen[idx] = true
+		bush.Idxs[i] = idx
+	}
+	for i := range bush.Coords {
+		bush.Coords[i] = jsValue(data[coordsOffset:], arrayType, i)
+	}
+	bush.derive()
+	return bush, nil
+}
+
+// reads value i of the typed array
+func jsValue(data []byte, arrayType, i int) float64 {
+	switch arrayType {
+	case 0:
+		return float64(int8(data[i]))
+	case 1, 2:
+		return float64(data[i])
+	case 3:
+		return float64(int16(binary.LittleEndian.Uint16(data[2*i:])))
+	case 4:
+		return float64(binary.LittleEndian.Uint16(data[2*i:]))
+	case 5:
+		return float64(int32(binary.LittleEndian.Uint32(data[4*i:])))
+	case 6:
+		return float64(binary.LittleEndian.Uint32(data[4*i:]))
+	case 7:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:])))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+}
@@ -0,0 +1,97 @@
+package kdbush
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Marshal(t *testing.T) {
+	//the same bytes as new KDBush(3) with added (1, 2), (3, 4), (5, 6) points in kdbush.js
+	bush := NewBushFromPairs([][2]float64{{1, 2}, {3, 4}, {5, 6}}, 64)
+	data, err := bush.Marshal()
+	assert.NoError(t, err)
+	expected := []byte{0xdb, 0x18, 64, 0, 3, 0, 0, 0, 0, 0, 1, 0, 2, 0, 0, 0}
+	for _, v := range []float64{1, 2, 3, 4, 5, 6} {
+		expected = binary.LittleEndian.AppendUint64(expected, math.Float64bits(v))
+	}
+	assert.Equal(t, expected, data)
+
+	bush.Remove(1)
+	_, err = bush.Marshal()
+	assert.Error(t, err)
+}
+
+func TestUnmarshalKDBush(t *testing.T) {
+	for _, bush := range []*KDBush{NewBush(getTestPoints(), 10), gridBush(70000)} {
+		data, err := bush.Marshal()
+		assert.NoError(t, err)
+		loaded, err := UnmarshalKDBush(data)
+		if assert.NoError(t, err) {
+			assert.Equal(t, bush.NodeSize, loaded.NodeSize)
+			assert.Equal(t, bush.Idxs, loaded.Idxs)
+			assert.Equal(t, bush.Coords, loaded.Coords)
+			assert.Equal(t, bush.Range(10, 10, 40, 40), loaded.Range(10, 10, 40, 40))
+		}
+	}
+
+	//Uint16Array coordinates
+	data := []byte{0xdb, 0x14, 16, 0, 2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 3, 0, 4, 0, 1, 0, 2, 0}
+	loaded, err := UnmarshalKDBush(data)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{1, 0}, loaded.Idxs)
+		assert.Equal(t, []float64{3, 4, 1, 2}, loaded.Coords)
+		assert.Equal(t, []int{0}, loaded.Range(0, 0, 2, 2))
+	}
+
+	_, err = UnmarshalKDBush(data[:20])
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+	_, err = UnmarshalKDBush([]byte{0xdb, 0x28, 16, 0, 0, 0, 0, 0})
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+	_, err = UnmarshalKDBush([]byte("KDBG1234"))
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+}
+
+func TestKDBush_MarshalIdWidth(t *testing.T) {
+	//kdbush.js: IndexArrayType = numItems < 65536 ? Uint16Array : Uint32Array, coords are padded to 8 bytes
+	for _, n := range []int{65500, 65535, 65536} {
+		bush := gridBush(n)
+		idSize := 2
+		if n >= 65536 {
+			idSize = 4
+		}
+		expected := []byte{0xdb, 0x18, 64, 0}
+		expected = binary.LittleEndian.AppendUint32(expected, uint32(n))
+		for _, idx := range bush.Idxs {
+			if idSize == 2 {
+				expected = binary.LittleEndian.AppendUint16(expected, uint16(idx))
+			} else {
+				expected = binary.LittleEndian.AppendUint32(expected, uint32(idx))
+			}
+		}
+		expected = append(expected, make([]byte, (8-n*idSize%8)%8)...)
+		for _, v := range bush.Coords {
+			expected = binary.LittleEndian.AppendUint64(expected, math.Float64bits(v))
+		}
+
+		data, err := bush.Marshal()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, data, "n %d", n)
+		loaded, err := UnmarshalKDBush(expected)
+		if assert.NoError(t, err, "n %d", n) {
+			assert.Equal(t, bush.Idxs, loaded.Idxs)
+			assert.Equal(t, bush.Coords, loaded.Coords)
+		}
+	}
+}
+
+func gridBush(n int) *KDBush {
+	pairs := make([][2]float64, n)
+	for i := range pairs {
+		pairs[i] = [2]float64{float64(i % 97), float64(i % 89)}
+	}
+	return NewBushFromPairs(pairs, 64)
+}
@@ -0,0 +1,17 @@
+// Package kdbushprom exports metrics of kdbush indexes as Prometheus collectors:
+// index size and memory, build duration, generations of Live index and a histogram of query latencies.
+//
+// Prometheus client is not a dependency of kdbush, the package is built only with prometheus tag:
+//
+//	go get github.com/prometheus/client_golang/prometheus
+//	go build -tags prometheus
+//
+// Register the collector and time queries by their kind:
+//
+//	c := kdbushprom.NewLive("pois", live)
+//	prometheus.MustRegister(c)
+//
+//	done := c.Time("nearest")
+//	result := live.Index().Nearest(q, 10, 0)
+//	done()
+package kdbushprom
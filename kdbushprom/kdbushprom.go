@@ -0,0 +1,127 @@
+//go:build prometheus
+
+package kdbushprom
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MadAppGang/kdbush"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector of index metrics, implements prometheus.Collector.
+// Index metrics are read on every scrape, query latencies are observed by Time and ObserveQuery.
+type Collector struct {
+	index func() *kdbush.KDBush
+	live  *kdbush.Live //nil for a static index
+
+	mu    sync.Mutex
+	build time.Duration //set by ObserveBuild
+
+	points, input, removed, memory, buildDuration *prometheus.Desc
+	generation, rebuilds, failedBuilds, staleness *prometheus.Desc
+
+	queries *prometheus.HistogramVec
+}
+
+// Creates collector of the index, returned by index on every scrape, e.g. the current one of swapped indexes.
+// Metric names are prefixed with namespace_kdbush_, or kdbush_ for empty namespace.
+func New(namespace string, index func() *kdbush.KDBush) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "kdbush", name), help, nil, nil)
+	}
+	return &Collector{
+		index:         index,
+		points:        desc("points", "Number of not removed points in the index."),
+		input:         desc("input_points", "Number of points in the original input of the index."),
+		removed:       desc("removed_points", "Number of points marked as removed."),
+		memory:        desc("memory_bytes", "Memory used by the index arrays."),
+		buildDuration: desc("build_duration_seconds", "Duration of the last build of the index."),
+		generation:    desc("generation", "Number of the current generation of the live index."),
+		rebuilds:      desc("rebuilds_total", "Number of successful rebuilds of the live index."),
+		failedBuilds:  desc("failed_rebuilds_total", "Number of failed rebuilds of the live index."),
+		staleness:     desc("staleness_seconds", "How long queries are served from the previous generation, 0 if not rebuilding."),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "kdbush",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of index queries by their kind.",
+			Buckets:   []float64{1e-6, 5e-6, 25e-6, 1e-4, 5e-4, 25e-4, 0.01, 0.05, 0.25, 1},
+		}, []string{"query"}),
+	}
+}
+
+// Creates collector of the live index, also exporting its generation and rebuilds.
+// Build duration is the latency of the last successful rebuild.
+func NewLive(namespace string, live *kdbush.Live) *Collector {
+	c := New(namespace, live.Index)
+	c.live = live
+	return c
+}
+
+// Sets build duration of the static index, e.g. measured around NewBush
+func (c *Collector) ObserveBuild(d time.Duration) {
+	c.mu.Lock()
+	c.build = d
+	c.mu.Unlock()
+}
+
+// Adds latency of the query of the given kind, e.g. "range" or "nearest", to the histogram
+func (c *Collector) ObserveQuery(query string, d time.Duration) {
+	c.queries.WithLabelValues(query).Observe(d.Seconds())
+}
+
+// Starts timing the query, returned function observes its latency:
+//
+//	defer c.Time("within")()
+func (c *Collector) Time(query string) func() {
+	start := time.Now()
+	return func() {
+		c.ObserveQuery(query, time.Since(start))
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.points
+	ch <- c.input
+	ch <- c.removed
+	ch <- c.memory
+	ch <- c.buildDuration
+	if c.live != nil {
+		ch <- c.generation
+		ch <- c.rebuilds
+		ch <- c.failedBuilds
+		ch <- c.staleness
+	}
+	c.queries.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	c.mu.Lock()
+	build := c.build
+	c.mu.Unlock()
+
+	if bush := c.index(); bush != nil {
+		stats := bush.Stats()
+		gauge(c.points, float64(stats.Points-stats.Removed))
+		gauge(c.input, float64(stats.Input))
+		gauge(c.removed, float64(stats.Removed))
+		gauge(c.memory, float64(bush.MemoryUsage()))
+	}
+	if c.live != nil {
+		m := c.live.Metrics()
+		if m.Rebuilds > 0 {
+			build = m.LastRebuild
+		}
+		gauge(c.generation, float64(m.Generation))
+		ch <- prometheus.MustNewConstMetric(c.rebuilds, prometheus.CounterValue, float64(m.Rebuilds))
+		ch <- prometheus.MustNewConstMetric(c.failedBuilds, prometheus.CounterValue, float64(m.FailedBuilds))
+		gauge(c.staleness, m.Staleness.Seconds())
+	}
+	gauge(c.buildDuration, build.Seconds())
+	c.queries.Collect(ch)
+}
@@ -0,0 +1,64 @@
+//go:build prometheus
+
+package kdbushprom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MadAppGang/kdbush"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	bush := kdbush.NewBushFromPairs([][2]float64{{1, 1}, {2, 2}, {3, 3}}, 2)
+	bush.Remove(1)
+	c := New("", func() *kdbush.KDBush { return bush })
+	c.ObserveBuild(1500 * time.Millisecond)
+	c.ObserveQuery("range", 3*time.Microsecond)
+	c.Time("range")()
+
+	expected := `
+# HELP kdbush_points Number of not removed points in the index.
+# TYPE kdbush_points gauge
+kdbush_points 2
+# HELP kdbush_removed_points Number of points marked as removed.
+# TYPE kdbush_removed_points gauge
+kdbush_removed_points 1
+# HELP kdbush_build_duration_seconds Duration of the last build of the index.
+# TYPE kdbush_build_duration_seconds gauge
+kdbush_build_duration_seconds 1.5
+`
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"kdbush_points", "kdbush_removed_points", "kdbush_build_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "kdbush_query_duration_seconds"), "one histogram of range queries")
+}
+
+func TestCollector_Live(t *testing.T) {
+	live := kdbush.NewLive(kdbush.NewBushFromPairs([][2]float64{{1, 1}}, 2))
+	assert.NoError(t, live.Rebuild(func() (*kdbush.KDBush, error) {
+		return kdbush.NewBushFromPairs([][2]float64{{1, 1}, {2, 2}}, 2), nil
+	}))
+	assert.Error(t, live.Rebuild(func() (*kdbush.KDBush, error) { return nil, errors.New("no data") }))
+	c := NewLive("pois", live)
+
+	expected := `
+# HELP pois_kdbush_points Number of not removed points in the index.
+# TYPE pois_kdbush_points gauge
+pois_kdbush_points 2
+# HELP pois_kdbush_generation Number of the current generation of the live index.
+# TYPE pois_kdbush_generation gauge
+pois_kdbush_generation 2
+# HELP pois_kdbush_rebuilds_total Number of successful rebuilds of the live index.
+# TYPE pois_kdbush_rebuilds_total counter
+pois_kdbush_rebuilds_total 1
+# HELP pois_kdbush_failed_rebuilds_total Number of failed rebuilds of the live index.
+# TYPE pois_kdbush_failed_rebuilds_total counter
+pois_kdbush_failed_rebuilds_total 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"pois_kdbush_points", "pois_kdbush_generation", "pois_kdbush_rebuilds_total", "pois_kdbush_failed_rebuilds_total"))
+}
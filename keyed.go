@@ -0,0 +1,66 @@
+package kdbush
+
+import (
+	sorting "sort"
+)
+
+// Point with its identifier
+type KeyedPoint struct {
+	Key   int64
+	Point Point
+}
+
+// Index, that returns int64 keys of the points instead of indices.
+// Keys[idx] is the key of the point with index idx, so Keys could be used with results of all other methods.
+type KeyedBush struct {
+	*KDBush
+	Keys []int64
+}
+
+// Creates index from the keyed map of points, points are indexed in ascending order of keys
+func NewKeyedBush(points map[int64]Point, nodeSize int, opts ...Option) *KeyedBush {
+	items := make([]KeyedPoint, 0, len(points))
+	for key, p := range points {
+		items = append(items, KeyedPoint{key, p})
+	}
+	sorting.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return NewKeyedBushFromPairs(items, nodeSize, opts...)
+}
+
+// Creates index from key and point pairs, points are indexed in the order of items.
+// Keys are not checked for uniqueness.
+func NewKeyedBushFromPairs(items []KeyedPoint, nodeSize int, opts ...Option) *KeyedBush {
+	keys := make([]int64, len(items))
+	points := make([]Point, len(items))
+	for i, item := range items {
+		keys[i], points[i] = item.Key, item.Point
+	}
+	return &KeyedBush{KDBush: NewBushWithOptions(points, nodeSize, opts...), Keys: keys}
+}
+
+// Results are keys of the points
+func (b *KeyedBush) AsKeys() ResultKind[int64] {
+	return AsIDs(b.Keys)
+}
+
+// Same as Range, but returns keys instead of indices
+func (b *KeyedBush) RangeKeys(minX, minY, maxX, maxY float64, opts ...QueryOption) []int64 {
+	result, _ := RangeAs(b.KDBush, b.AsKeys(), minX, minY, maxX, maxY, opts...)
+	return result
+}
+
+// Same as Within, but returns keys instead of indices
+func (b *KeyedBush) WithinKeys(point Point, radius float64, opts ...QueryOption) []int64 {
+	result, _ := WithinAs(b.KDBush, b.AsKeys(), point, radius, opts...)
+	return result
+}
+
+// Same as Nearest, but returns keys instead of indices
+func (b *KeyedBush) NearestKeys(point Point, k int, maxDist float64, opts ...QueryOption) []int64 {
+	idxs := b.Nearest(point, k, maxDist, opts...)
+	keys := make([]int64, len(idxs))
+	for i, idx := range idxs {
+		keys[i] = b.Keys[idx]
+	}
+	return keys
+}
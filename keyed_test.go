@@ -0,0 +1,33 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedBush(t *testing.T) {
+	points := map[int64]Point{}
+	for i, p := range testPoints {
+		points[int64(i)*1000+7] = &SimplePoint{X: p[0], Y: p[1]}
+	}
+	bush := NewKeyedBush(points, 10)
+	plain := NewBush(getTestPoints(), 10)
+	assert.Equal(t, testIdxs, bush.Idxs)
+
+	toKeys := func(idxs []int) []int64 {
+		keys := []int64{}
+		for _, idx := range idxs {
+			keys = append(keys, int64(idx)*1000+7)
+		}
+		return keys
+	}
+	q := &SimplePoint{X: 50, Y: 50}
+	assert.Equal(t, toKeys(plain.Range(20, 30, 50, 70)), bush.RangeKeys(20, 30, 50, 70))
+	assert.Equal(t, toKeys(plain.Within(q, 20)), bush.WithinKeys(q, 20))
+	assert.Equal(t, toKeys(plain.Nearest(q, 5, 0)), bush.NearestKeys(q, 5, 0))
+
+	pairs := NewKeyedBushFromPairs([]KeyedPoint{{-5, &SimplePoint{X: 1, Y: 1}}, {1 << 40, &SimplePoint{X: 5, Y: 5}}}, 10)
+	assert.Equal(t, []int64{1 << 40}, pairs.RangeKeys(4, 4, 6, 6))
+	assert.Equal(t, []int64{-5, 1 << 40}, pairs.NearestKeys(&SimplePoint{}, 0, 0))
+}
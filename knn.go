@@ -0,0 +1,411 @@
+package kdbush
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// number of tree nodes visited between deadline checks of nearest queries
+const deadlineCheckNodes = 32
+
+// item of the best-first search queue: a tree node or a point of the tree (pos >= 0)
+type knnItem struct {
+	node treeNode
+	pos  int
+	idx  int //original index of the point
+	dist float64
+}
+
+// min-heap of knnItem by distance.
+// Nodes go before points at the same distance, so all tied points are queued before any of them is visited,
+// and tied points are visited in order of original indices.
+type knnQueue []knnItem
+
+func (q knnQueue) Len() int { return len(q) }
+
+func (q knnQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.dist != b.dist {
+		return a.dist < b.dist
+	}
+	if (a.pos < 0) != (b.pos < 0) {
+		return a.pos < 0
+	}
+	return a.idx < b.idx
+}
+
+func (q knnQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *knnQueue) Push(x interface{}) { *q = append(*q, x.(knnItem)) }
+
+func (q *knnQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Best-first traversal of the tree: visits points in order of increasing distance.
+// nodeDist should return lower bound of distances from the query to the points of the node,
+// pointDist - distance to the point on position i. Visit returns false to stop the traversal.
+// Points farther than maxDist are never visited. Points at the same distance are visited in order of original indices.
+// After the cfg deadline passes, only points already in the queue are visited and cfg.timedOut is set,
+// the same after cfg.maxNodes nodes are expanded, then cfg.truncated is set.
+func (bush *KDBush) nearest(nodeDist func(n treeNode) float64, pointDist func(i int) float64,
+	maxDist float64, cfg *queryConfig, visit func(i int, dist float64) bool) {
+	it := bush.newNearestIter(nodeDist, pointDist, maxDist, cfg)
+	for {
+		i, d, ok := it.next()
+		if !ok || !visit(i, d) {
+			return
+		}
+	}
+}
+
+// state of the best-first traversal, points are found lazily by next
+type nearestIter struct {
+	bush      *KDBush
+	nodeDist  func(n treeNode) float64
+	pointDist func(i int) float64
+	maxDist   float64
+	cfg       *queryConfig
+
+	q        knnQueue
+	root     bool //root node is not expanded yet
+	nodes    int  //number of expanded nodes
+	draining bool //deadline passed, only queued points are returned
+}
+
+func (bush *KDBush) newNearestIter(nodeDist func(n treeNode) float64, pointDist func(i int) float64,
+	maxDist float64, cfg *queryConfig) *nearestIter {
+	cfg.prepare(bush)
+	return &nearestIter{bush: bush, nodeDist: nodeDist, pointDist: pointDist, maxDist: maxDist, cfg: cfg, root: bush.size() > 0}
+}
+
+// returns position and distance of the next point, ok is false when there are no more points
+func (it *nearestIter) next() (pos int, dist float64, ok bool) {
+	for {
+		//all points closer than any node left in the queue are final
+		if it.q.Len() > 0 && (it.q[0].pos >= 0 || it.draining) {
+			if item := heap.Pop(&it.q).(knnItem); item.pos >= 0 {
+				return item.pos, item.dist, true
+			}
+			continue
+		}
+		var node treeNode
+		switch {
+		case it.root:
+			node, it.root = it.bush.rootNode(), false
+		case it.q.Len() == 0 || it.draining:
+			return 0, 0, false
+		case !it.cfg.deadline.IsZero() && it.nodes%deadlineCheckNodes == 0 && time.Now().After(it.cfg.deadline):
+			it.cfg.timedOut = true
+			it.draining = true
+			continue
+		case it.cfg.maxNodes > 0 && it.nodes >= it.cfg.maxNodes:
+			it.cfg.truncated = true
+			it.draining = true
+			continue
+		default:
+			node = heap.Pop(&it.q).(knnItem).node
+		}
+		it.expand(node)
+	}
+}
+
+// queues the points and children of the node
+func (it *nearestIter) expand(node treeNode) {
+	bush, cfg := it.bush, it.cfg
+	it.nodes++
+	cfg.visited++
+	if node.right-node.left <= bush.NodeSize {
+		for i := node.left; i <= node.right; i++ {
+			if cfg.accepts(bush, i) {
+				if d := it.pointDist(i); d <= it.maxDist {
+					heap.Push(&it.q, knnItem{pos: i, idx: bush.idx(i), dist: d})
+				}
+			}
+		}
+		return
+	}
+	m := floor(float64(node.left+node.right) / 2.0)
+	if cfg.accepts(bush, m) {
+		if d := it.pointDist(m); d <= it.maxDist {
+			heap.Push(&it.q, knnItem{pos: m, idx: bush.idx(m), dist: d})
+		}
+	}
+	l, r := bush.children(node, m)
+	for _, c := range [2]treeNode{l, r} {
+		if c.left <= c.right && !cfg.excludesBox(c.minX, c.minY, c.maxX, c.maxY) {
+			if d := it.nodeDist(c); d <= it.maxDist {
+				heap.Push(&it.q, knnItem{node: c, pos: -1, dist: d})
+			}
+		}
+	}
+}
+
+// Returns iterator over points in order of increasing distance from the query point, for searches, that don't know k in advance,
+// e.g. "neighbors until their total capacity is enough". Every call returns the next point with its Euclidean distance,
+// ok is false when all points are returned. Points at the same distance are returned in order of original indices.
+// Tree is traversed lazily, so every call does only the work needed to find the next point.
+// The index shouldn't be changed while the iterator is used.
+func (bush *KDBush) NearestIter(point Point, opts ...QueryOption) func() (idx int, dist float64, ok bool) {
+	qx, qy := point.Coordinates()
+	it := bush.newNearestIter(
+		func(n treeNode) float64 { return boxDistSq(qx, qy, n) },
+		func(i int) float64 { return sqrtDist(bush.x(i), bush.y(i), qx, qy) },
+		math.Inf(1), newQueryConfig(opts))
+	return func() (int, float64, bool) {
+		i, d, ok := it.next()
+		if !ok {
+			return -1, 0, false
+		}
+		return bush.idx(i), math.Sqrt(d), true
+	}
+}
+
+// Finds k nearest points to the query point within maxDist and returns their indices sorted by distance.
+// k <= 0 means no limit on number of results, maxDist <= 0 means no limit on distance.
+// Points at the same distance are sorted by original index, so exactly k points are chosen deterministically,
+// use WithTies option to get all points tied with the k-th one.
+func (bush *KDBush) Nearest(point Point, k int, maxDist float64, opts ...QueryOption) []int {
+	return bush.nearestWith(point, k, maxDist, newQueryConfig(opts))
+}
+
+// Finds k nearest points to the indexed point with original index idx, skipping the point itself,
+// e.g. "nearest competitors" of every shop. Returns empty result if idx is not in the index.
+func (bush *KDBush) NearestOther(idx, k int, maxDist float64, opts ...QueryOption) []int {
+	i := bush.TreePos(idx)
+	if i < 0 {
+		return []int{}
+	}
+	return bush.Nearest(&SimplePoint{X: bush.x(i), Y: bush.y(i)}, k, maxDist, append(opts, ExcludeSelf(idx))...)
+}
+
+func (bush *KDBush) nearestWith(point Point, k int, maxDist float64, cfg *queryConfig) []int {
+	result := []int{}
+	bush.nearestEach(point, k, maxDist, cfg, func(i int, d float64) bool {
+		result = append(result, bush.idx(i))
+		return true
+	})
+	return result
+}
+
+// calls visit with position and squared distance of every result of Nearest in order, until it returns false
+func (bush *KDBush) nearestEach(point Point, k int, maxDist float64, cfg *queryConfig, visit func(i int, d float64) bool) {
+	qx, qy := point.Coordinates()
+	maxDistSq := math.Inf(1)
+	if maxDist > 0 {
+		maxDistSq = maxDist * maxDist
+	}
+	if cfg.score != nil {
+		for _, idx := range bush.nearestScored(qx, qy, k, maxDistSq, cfg) {
+			i := bush.treePos(idx)
+			if !visit(i, sqrtDist(bush.x(i), bush.y(i), qx, qy)) {
+				return
+			}
+		}
+		return
+	}
+	found := 0
+	bush.nearest(
+		func(n treeNode) float64 { return boxDistSq(qx, qy, n) },
+		func(i int) float64 { return sqrtDist(bush.x(i), bush.y(i), qx, qy) },
+		maxDistSq, cfg,
+		func(i int, d float64) bool {
+			if !cfg.kept(k, found, d) {
+				return false
+			}
+			found++
+			return visit(i, d)
+		})
+}
+
+// Point of nearest neighbors result
+type Neighbor struct {
+	Idx    int     //index in the original points input slice
+	DistSq float64 //squared Euclidean distance to the query point
+	Point  Point   //the original point, nil if the index was built from coordinates
+}
+
+func (bush *KDBush) neighbor(i int, d float64) Neighbor {
+	n := Neighbor{Idx: bush.idx(i), DistSq: d}
+	if bush.Points != nil {
+		n.Point = bush.Points[n.Idx]
+	}
+	return n
+}
+
+// Same as Nearest, but returns neighbors with their distances and points
+func (bush *KDBush) NearestNeighbors(point Point, k int, maxDist float64, opts ...QueryOption) []Neighbor {
+	result := []Neighbor{}
+	bush.nearestEach(point, k, maxDist, newQueryConfig(opts), func(i int, d float64) bool {
+		result = append(result, bush.neighbor(i, d))
+		return true
+	})
+	return result
+}
+
+// Same as NearestNeighbors, but passes neighbors to visit in order of distance instead of building the result slice.
+// Visit returns false to stop the search.
+func (bush *KDBush) NearestEach(point Point, k int, maxDist float64, visit func(n Neighbor) bool, opts ...QueryOption) {
+	bush.nearestEach(point, k, maxDist, newQueryConfig(opts), func(i int, d float64) bool {
+		return visit(bush.neighbor(i, d))
+	})
+}
+
+// Same as Nearest, but returns the best k points found when the deadline passes.
+// approximate is true if the search was not completed (also by MaxNodesVisited option), then some of the results could be farther than the true k nearest.
+func (bush *KDBush) KNNDeadline(point Point, k int, deadline time.Time, opts ...QueryOption) (result []int, approximate bool) {
+	cfg := newQueryConfig(append(opts, Deadline(deadline)))
+	result = bush.nearestWith(point, k, 0, cfg)
+	return result, cfg.timedOut || cfg.truncated
+}
+
+// Finds nearest points from k distinct groups set by SetGroups: the closest point of every group, sorted by distance.
+// Points of already found groups are skipped during traversal, so the search stops as soon as k groups are found.
+// Without groups every point is a group of its own. k <= 0 means no limit on number of groups.
+func (bush *KDBush) KNNDistinctGroups(point Point, k int, opts ...QueryOption) []int {
+	groups, _ := GetAux[int64](bush, GroupAux)
+	if groups == nil {
+		return bush.Nearest(point, k, 0, opts...)
+	}
+	seen := map[int64]bool{}
+	where := func(idx int) bool { return !seen[groups[idx]] }
+	cfg := newQueryConfig(opts)
+	if prev := cfg.where; prev != nil {
+		where = func(idx int) bool { return !seen[groups[idx]] && prev(idx) }
+	}
+	cfg.where = where
+
+	result := []int{}
+	qx, qy := point.Coordinates()
+	bush.nearest(
+		func(n treeNode) float64 { return boxDistSq(qx, qy, n) },
+		func(i int) float64 { return sqrtDist(bush.x(i), bush.y(i), qx, qy) },
+		math.Inf(1), cfg,
+		func(i int, d float64) bool {
+			//points of the group could be queued before the first of them was visited
+			idx := bush.idx(i)
+			if seen[groups[idx]] {
+				return true
+			}
+			seen[groups[idx]] = true
+			result = append(result, idx)
+			return k <= 0 || len(result) < k
+		})
+	return result
+}
+
+// Finds the closest nPerCat points of every category within the radius, e.g. 3 nearest pharmacies and 3 nearest ATMs in one call.
+// categories[idx] is the category of the point idx. Returns indices of every found category sorted by distance,
+// points at the same distance are sorted by index. Every category keeps a bounded heap of its best points during the traversal.
+func (bush *KDBush) WithinTopPerCategory(point Point, radius float64, categories []int, nPerCat int) map[int][]int {
+	result := map[int][]int{}
+	if nPerCat <= 0 {
+		return result
+	}
+	qx, qy := point.Coordinates()
+	r2 := radius * radius
+	best := map[int]*scoreQueue{}
+	bush.search(qx-radius, qy-radius, qx+radius, qy+radius, &queryConfig{}, func(i int) bool {
+		d := sqrtDist(bush.x(i), bush.y(i), qx, qy)
+		if d > r2 {
+			return true
+		}
+		idx := bush.idx(i)
+		cat := categories[idx]
+		q := best[cat]
+		if q == nil {
+			q = &scoreQueue{}
+			best[cat] = q
+		}
+		if q.Len() < nPerCat {
+			heap.Push(q, scored{idx, d})
+		} else if top := (*q)[0]; d < top.score || d == top.score && idx < top.idx {
+			(*q)[0] = scored{idx, d}
+			heap.Fix(q, 0)
+		}
+		return true
+	})
+
+	for cat, q := range best {
+		ids := make([]int, q.Len())
+		for i := len(ids) - 1; i >= 0; i-- {
+			ids[i] = heap.Pop(q).(scored).idx
+		}
+		result[cat] = ids
+	}
+	return result
+}
+
+// Same as Nearest, but finds only points, that satisfy the predicate, e.g. "open restaurants only"
+func (bush *KDBush) NearestWhere(point Point, k int, maxDist float64, pred func(idx int) bool) []int {
+	return bush.Nearest(point, k, maxDist, Where(pred))
+}
+
+// Nearest with ScoreWith option: keeps k best scored points, visiting points in order of distance
+func (bush *KDBush) nearestScored(qx, qy float64, k int, maxDistSq float64, cfg *queryConfig) []int {
+	best := &scoreQueue{}
+	bush.nearest(
+		func(n treeNode) float64 { return boxDistSq(qx, qy, n) },
+		func(i int) float64 { return sqrtDist(bush.x(i), bush.y(i), qx, qy) },
+		maxDistSq, cfg,
+		func(i int, d float64) bool {
+			full := k > 0 && best.Len() >= k
+			if full && cfg.scoreBound != nil && cfg.scoreBound(d) >= (*best)[0].score {
+				return false
+			}
+			idx := bush.idx(i)
+			s := cfg.score(idx, d)
+			if !full {
+				heap.Push(best, scored{idx, s})
+			} else if s < (*best)[0].score {
+				(*best)[0] = scored{idx, s}
+				heap.Fix(best, 0)
+			}
+			return true
+		})
+
+	result := make([]int, best.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(best).(scored).idx
+	}
+	return result
+}
+
+type scored struct {
+	idx   int
+	score float64
+}
+
+// max-heap of scored points, the worst one is on top, of equally scored points the one with the biggest index
+type scoreQueue []scored
+
+func (q scoreQueue) Len() int { return len(q) }
+
+func (q scoreQueue) Less(i, j int) bool {
+	if q[i].score != q[j].score {
+		return q[i].score > q[j].score
+	}
+	return q[i].idx > q[j].idx
+}
+
+func (q scoreQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *scoreQueue) Push(x interface{}) { *q = append(*q, x.(scored)) }
+
+func (q *scoreQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// squared distance from the point to the box of the node, 0 if the point is inside
+func boxDistSq(x, y float64, n treeNode) float64 {
+	dx := math.Max(0, math.Max(n.minX-x, x-n.maxX))
+	dy := math.Max(0, math.Max(n.minY-y, y-n.maxY))
+	return dx*dx + dy*dy
+}
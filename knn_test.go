@@ -0,0 +1,304 @@
+package kdbush
+
+import (
+	sorting "sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Nearest(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	result := bush.Nearest(q, 5, 0)
+	assert.Equal(t, []int{6, 20, 18, 25, 92}, result)
+
+	last := 0.0
+	all := bush.Nearest(q, 0, 0)
+	assert.Len(t, all, len(points))
+	for _, idx := range all {
+		x, y := points[idx].Coordinates()
+		d := sqrtDist(x, y, 50, 50)
+		assert.True(t, d >= last)
+		last = d
+	}
+
+	within := bush.Nearest(q, 0, 20)
+	assert.ElementsMatch(t, bush.Within(q, 20), within)
+
+	bush.Remove(20)
+	assert.Equal(t, []int{6, 18}, bush.Nearest(q, 2, 0))
+	assert.Empty(t, NewBushFromPairs(nil, 10).Nearest(q, 2, 0))
+}
+
+func TestKDBush_ScoreWith(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	//relevance boost for even indexes
+	score := func(idx int, distSq float64) float64 {
+		if idx%2 == 0 {
+			return distSq / 4
+		}
+		return distSq
+	}
+	expected := make([]int, len(points))
+	for i := range expected {
+		expected[i] = i
+	}
+	scoreOf := func(idx int) float64 {
+		x, y := points[idx].Coordinates()
+		return score(idx, sqrtDist(x, y, 50, 50))
+	}
+	sorting.SliceStable(expected, func(i, j int) bool { return scoreOf(expected[i]) < scoreOf(expected[j]) })
+
+	visited := 0
+	counting := func(idx int, distSq float64) float64 {
+		visited++
+		return score(idx, distSq)
+	}
+
+	result := bush.Nearest(q, 5, 0, ScoreWith(counting, nil))
+	assert.Equal(t, expected[:5], result)
+	assert.Equal(t, len(points), visited)
+
+	visited = 0
+	result = bush.Nearest(q, 5, 0, ScoreWith(counting, func(distSq float64) float64 { return distSq / 4 }))
+	assert.Equal(t, expected[:5], result)
+	assert.True(t, visited < len(points)/2, "visited %d", visited)
+}
+
+func TestKDBush_NearestTies(t *testing.T) {
+	//duplicates and points at the same distance from the query
+	points := []Point{
+		&SimplePoint{X: 5, Y: 5},
+		&SimplePoint{X: 1, Y: 0},
+		&SimplePoint{X: 0, Y: 1},
+		&SimplePoint{X: -1, Y: 0},
+		&SimplePoint{X: 1, Y: 0},
+		&SimplePoint{X: 0, Y: -1},
+		&SimplePoint{X: 0, Y: 0.5},
+	}
+	for _, nodeSize := range []int{1, 2, 64} {
+		bush := NewBush(points, nodeSize)
+		q := &SimplePoint{X: 0, Y: 0}
+
+		assert.Equal(t, []int{6, 1, 2}, bush.Nearest(q, 3, 0))
+		assert.Equal(t, []int{6, 1, 2, 3, 4, 5}, bush.Nearest(q, 3, 0, WithTies()))
+		assert.Equal(t, []int{6}, bush.Nearest(q, 1, 0, WithTies()))
+		assert.Equal(t, []int{6, 1, 2, 3, 4, 5, 0}, bush.Nearest(q, 0, 0, WithTies()))
+	}
+
+	geo := NewBush([]Point{LngLat{10, 0}, LngLat{0, 10}, LngLat{-10, 0}, LngLat{0, 20}}, 1)
+	assert.Equal(t, 0, geo.GeoNearest(LngLat{0, 0}, 1, 0, Kilometers)[0].Index)
+	assert.Len(t, geo.GeoNearest(LngLat{0, 0}, 1, 0, Kilometers, WithTies()), 3)
+}
+
+func TestKDBush_NearestWhere(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	q := &SimplePoint{X: 50, Y: 50}
+	even := func(idx int) bool { return idx%2 == 0 }
+
+	expected := []int{}
+	for _, idx := range bush.Nearest(q, 0, 30) {
+		if even(idx) && len(expected) < 5 {
+			expected = append(expected, idx)
+		}
+	}
+	assert.Len(t, expected, 5)
+	assert.Equal(t, expected, bush.NearestWhere(q, 5, 30, even))
+	assert.Empty(t, bush.NearestWhere(q, 5, 30, func(int) bool { return false }))
+
+	//the same predicate works with other queries
+	result, _ := bush.RangeWithOptions(20, 30, 50, 70, Where(even))
+	for _, idx := range result {
+		assert.True(t, even(idx))
+	}
+}
+
+func TestKDBush_NearestOther(t *testing.T) {
+	points := append(getTestPoints(), &SimplePoint{X: 54, Y: 1})
+	bush := NewBush(points, 10)
+	self := 0 //{54, 1}, the last point is a duplicate of it
+
+	x, y := points[self].Coordinates()
+	all := bush.Nearest(&SimplePoint{X: x, Y: y}, 6, 0)
+	assert.Contains(t, all[:2], self)
+	others := bush.NearestOther(self, 5, 0)
+	assert.Equal(t, 5, len(others))
+	assert.NotContains(t, others, self)
+	assert.Contains(t, others, len(points)-1, "other points at the same location are kept")
+	for _, idx := range others {
+		assert.Contains(t, all, idx)
+	}
+	assert.Empty(t, bush.NearestOther(-1, 5, 0))
+	assert.Empty(t, bush.NearestOther(len(points), 5, 0))
+
+	within, _ := bush.WithinWithOptions(points[self], 20, ExcludeSelf(self))
+	assert.NotContains(t, within, self)
+	assert.Equal(t, len(bush.Within(points[self], 20))-1, len(within))
+}
+
+func TestKDBush_KNNDeadline(t *testing.T) {
+	bush := benchmarkBush()
+	q := &SimplePoint{X: 500, Y: 500}
+
+	result, approximate := bush.KNNDeadline(q, 100, time.Now().Add(time.Minute))
+	assert.False(t, approximate)
+	assert.Equal(t, bush.Nearest(q, 100, 0), result)
+
+	//already passed deadline stops at the first check, candidates seen so far are returned
+	result, approximate = bush.KNNDeadline(q, 100, time.Now().Add(-time.Second))
+	assert.True(t, approximate)
+	assert.NotEmpty(t, result)
+	assert.True(t, len(result) <= 100)
+	for i := 1; i < len(result); i++ {
+		assert.True(t, pointDistSq(bush, result[i-1], q) <= pointDistSq(bush, result[i], q))
+	}
+}
+
+func pointDistSq(bush *KDBush, idx int, q *SimplePoint) float64 {
+	pos := bush.TreePos(idx)
+	return sqrtDist(bush.Coords[2*pos], bush.Coords[2*pos+1], q.X, q.Y)
+}
+
+func TestKDBush_KNNDistinctGroups(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+	assert.Equal(t, bush.Nearest(q, 5, 0), bush.KNNDistinctGroups(q, 5))
+
+	groups := make([]int64, len(points))
+	for i := range groups {
+		groups[i] = int64(i % 7)
+	}
+	assert.NoError(t, bush.SetGroups(groups))
+
+	expected := []int{}
+	seen := map[int64]bool{}
+	for _, idx := range bush.Nearest(q, 0, 0) {
+		if !seen[groups[idx]] && len(expected) < 5 {
+			seen[groups[idx]] = true
+			expected = append(expected, idx)
+		}
+	}
+	assert.Equal(t, expected, bush.KNNDistinctGroups(q, 5))
+	assert.Len(t, bush.KNNDistinctGroups(q, 0), 7)
+
+	//combined with other filters
+	odd := func(idx int) bool { return idx%2 == 1 }
+	for _, idx := range bush.KNNDistinctGroups(q, 3, Where(odd)) {
+		assert.True(t, odd(idx))
+	}
+}
+
+func TestKDBush_WithinTopPerCategory(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+	categories := make([]int, len(points))
+	for i := range categories {
+		categories[i] = i % 4
+	}
+
+	expected := map[int][]int{}
+	for _, idx := range bush.Nearest(q, 0, 30) {
+		if c := categories[idx]; len(expected[c]) < 3 {
+			expected[c] = append(expected[c], idx)
+		}
+	}
+	assert.Len(t, expected, 4)
+	assert.Equal(t, expected, bush.WithinTopPerCategory(q, 30, categories, 3))
+
+	//categories with less points than asked
+	few := bush.WithinTopPerCategory(q, 5, categories, 100)
+	for c, ids := range few {
+		for _, idx := range ids {
+			assert.Equal(t, c, categories[idx])
+		}
+	}
+	total := 0
+	for _, ids := range few {
+		total += len(ids)
+	}
+	assert.Equal(t, len(bush.Within(q, 5)), total)
+
+	assert.Empty(t, bush.WithinTopPerCategory(q, 30, categories, 0))
+	assert.Empty(t, bush.WithinTopPerCategory(&SimplePoint{X: 500, Y: 500}, 30, categories, 3))
+}
+
+func TestKDBush_NearestIter(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+	bush.Remove(bush.Nearest(q, 1, 0)[0])
+
+	next := bush.NearestIter(q)
+	all := []int{}
+	prev := 0.0
+	for {
+		idx, dist, ok := next()
+		if !ok {
+			break
+		}
+		x, y := points[idx].Coordinates()
+		assert.InDelta(t, Euclidean.Distance(x, y, 50, 50), dist, 1e-9)
+		assert.GreaterOrEqual(t, dist, prev)
+		prev = dist
+		all = append(all, idx)
+	}
+	assert.Equal(t, bush.Nearest(q, 0, 0), all)
+	_, _, ok := next()
+	assert.False(t, ok)
+
+	//stop by a condition, that doesn't know k
+	next = bush.NearestIter(q, Where(func(idx int) bool { return idx%2 == 0 }))
+	sum := 0
+	found := []int{}
+	for sum < 100 {
+		idx, _, ok := next()
+		if !ok {
+			break
+		}
+		sum += idx
+		found = append(found, idx)
+	}
+	assert.Equal(t, bush.Nearest(q, len(found), 0, Where(func(idx int) bool { return idx%2 == 0 })), found)
+
+	_, _, ok = NewBush(nil, 10).NearestIter(q)()
+	assert.False(t, ok)
+}
+
+func TestKDBush_NearestNeighbors(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	neighbors := bush.NearestNeighbors(q, 5, 0)
+	expected := bush.Nearest(q, 5, 0)
+	assert.Len(t, neighbors, 5)
+	for i, n := range neighbors {
+		assert.Equal(t, expected[i], n.Idx)
+		assert.Equal(t, points[n.Idx], n.Point)
+		x, y := points[n.Idx].Coordinates()
+		assert.Equal(t, sqrtDist(x, y, 50, 50), n.DistSq)
+	}
+
+	visited := []Neighbor{}
+	bush.NearestEach(q, 5, 0, func(n Neighbor) bool {
+		visited = append(visited, n)
+		return len(visited) < 3
+	})
+	assert.Equal(t, neighbors[:3], visited)
+
+	//options and indexes without points
+	pairs := NewBushFromPairs([][2]float64{{0, 0}, {1, 1}, {2, 2}}, 10)
+	assert.Equal(t, []Neighbor{{Idx: 2, DistSq: 0}, {Idx: 1, DistSq: 2}},
+		pairs.NearestNeighbors(&SimplePoint{X: 2, Y: 2}, 2, 0))
+	assert.Equal(t, []Neighbor{{Idx: 1, DistSq: 2}},
+		pairs.NearestNeighbors(&SimplePoint{X: 2, Y: 2}, 2, 0, Where(func(idx int) bool { return idx == 1 })))
+}
@@ -0,0 +1,124 @@
+package kdbush
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Live holds the current generation of the index and swaps it atomically on rebuild.
+//
+// Stale-while-rebuild semantics:
+//   - Index returns the current generation and never blocks, also during rebuild.
+//   - While rebuild is running, queries are served from the previous generation, staleness is reported by Metrics.
+//   - New generation becomes visible to all Index calls at once, when the build is finished. Failed build keeps the previous one.
+//   - Rebuilds are serialized, generations are numbered in the order they become visible.
+//
+// Generations should be treated as read only, e.g. don't Remove points from the index, returned by Index.
+type Live struct {
+	current atomic.Pointer[liveGeneration]
+	//keeps the pointer, loaded by every query, out of the cache line of mutexes and metrics, written by rebuilds
+	_ [cacheLineSize]byte
+
+	mu sync.Mutex //serializes rebuilds
+
+	metricsMu    sync.Mutex
+	pending      []time.Time //start times of not finished rebuilds
+	lastDuration time.Duration
+	rebuilds     uint64
+	failures     uint64
+}
+
+// size of CPU cache line, used to pad fields written and read by different goroutines
+const cacheLineSize = 64
+
+type liveGeneration struct {
+	bush    *KDBush
+	number  uint64
+	builtAt time.Time
+}
+
+// Metrics of the Live index
+type LiveMetrics struct {
+	Generation   uint64        //number of the current generation, 1 for the initial index
+	Age          time.Duration //time since the current generation became visible
+	Rebuilding   bool          //rebuild is in progress, queries are served from the previous generation
+	Staleness    time.Duration //how long queries are served from the previous generation, 0 if not rebuilding
+	LastRebuild  time.Duration //build time of the last successful rebuild, not counting waiting for other rebuilds
+	Rebuilds     uint64        //number of successful rebuilds
+	FailedBuilds uint64        //number of failed rebuilds
+}
+
+// Creates live index with the initial generation
+func NewLive(bush *KDBush) *Live {
+	l := &Live{}
+	l.current.Store(&liveGeneration{bush: bush, number: 1, builtAt: time.Now()})
+	return l
+}
+
+// Returns the current generation of the index, never blocks
+func (l *Live) Index() *KDBush {
+	return l.current.Load().bush
+}
+
+// Builds new generation and swaps it in, queries are served from the previous generation meanwhile.
+// If build returns error, the previous generation is kept.
+func (l *Live) Rebuild(build func() (*KDBush, error)) error {
+	requested := time.Now()
+	l.metricsMu.Lock()
+	l.pending = append(l.pending, requested)
+	l.metricsMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start := time.Now()
+	bush, err := build()
+
+	l.metricsMu.Lock()
+	defer l.metricsMu.Unlock()
+	for i, t := range l.pending {
+		if t.Equal(requested) {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		l.failures++
+		return err
+	}
+	l.swap(bush)
+	l.lastDuration = time.Since(start)
+	l.rebuilds++
+	return nil
+}
+
+// Swaps in already built index as the new generation
+func (l *Live) Swap(bush *KDBush) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.swap(bush)
+}
+
+func (l *Live) swap(bush *KDBush) {
+	l.current.Store(&liveGeneration{bush: bush, number: l.current.Load().number + 1, builtAt: time.Now()})
+}
+
+// Returns current metrics of the live index
+func (l *Live) Metrics() LiveMetrics {
+	gen := l.current.Load()
+	l.metricsMu.Lock()
+	defer l.metricsMu.Unlock()
+	m := LiveMetrics{
+		Generation:   gen.number,
+		Age:          time.Since(gen.builtAt),
+		Rebuilding:   len(l.pending) > 0,
+		LastRebuild:  l.lastDuration,
+		Rebuilds:     l.rebuilds,
+		FailedBuilds: l.failures,
+	}
+	//staleness is counted from the oldest rebuild, that is still not finished
+	for _, t := range l.pending {
+		m.Staleness = max(m.Staleness, time.Since(t))
+	}
+	return m
+}
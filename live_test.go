@@ -0,0 +1,97 @@
+package kdbush
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLive(t *testing.T) {
+	first := NewBush(getTestPoints(), 10)
+	live := NewLive(first)
+	assert.Equal(t, first, live.Index())
+	assert.Equal(t, uint64(1), live.Metrics().Generation)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	second := NewBush(getTestPoints()[:50], 10)
+	done := make(chan error)
+	go func() {
+		done <- live.Rebuild(func() (*KDBush, error) {
+			close(started)
+			<-release
+			return second, nil
+		})
+	}()
+
+	//old generation is served during rebuild
+	<-started
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, first, live.Index())
+	m := live.Metrics()
+	assert.True(t, m.Rebuilding)
+	assert.True(t, m.Staleness >= 5*time.Millisecond)
+	assert.Equal(t, uint64(1), m.Generation)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, second, live.Index())
+	m = live.Metrics()
+	assert.False(t, m.Rebuilding)
+	assert.Equal(t, time.Duration(0), m.Staleness)
+	assert.Equal(t, uint64(2), m.Generation)
+	assert.Equal(t, uint64(1), m.Rebuilds)
+	assert.True(t, m.LastRebuild >= 5*time.Millisecond)
+
+	//failed build keeps the current generation
+	err := live.Rebuild(func() (*KDBush, error) { return nil, errors.New("broken input") })
+	assert.Error(t, err)
+	assert.Equal(t, second, live.Index())
+	assert.Equal(t, uint64(1), live.Metrics().FailedBuilds)
+
+	live.Swap(first)
+	assert.Equal(t, first, live.Index())
+	assert.Equal(t, uint64(3), live.Metrics().Generation)
+}
+
+func TestLive_QueuedRebuilds(t *testing.T) {
+	live := NewLive(NewBush(getTestPoints(), 10))
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 2)
+	go func() {
+		done <- live.Rebuild(func() (*KDBush, error) {
+			close(started)
+			<-release
+			return NewBush(getTestPoints()[:50], 10), nil
+		})
+	}()
+	<-started
+	time.Sleep(20 * time.Millisecond)
+
+	//the second rebuild waits for the first one
+	secondStarted, secondRelease := make(chan struct{}), make(chan struct{})
+	go func() {
+		done <- live.Rebuild(func() (*KDBush, error) {
+			close(secondStarted)
+			<-secondRelease
+			return NewBush(getTestPoints()[:20], 10), nil
+		})
+	}()
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, live.Metrics().Staleness >= 20*time.Millisecond)
+
+	close(release)
+	<-secondStarted
+	m := live.Metrics()
+	assert.Equal(t, uint64(2), m.Generation)
+	assert.True(t, m.Rebuilding)
+	assert.True(t, m.Staleness < 20*time.Millisecond, "staleness %v is counted from the pending rebuild", m.Staleness)
+
+	close(secondRelease)
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+	m = live.Metrics()
+	assert.Equal(t, uint64(3), m.Generation)
+	assert.True(t, m.LastRebuild < 20*time.Millisecond, "last rebuild %v doesn't count waiting", m.LastRebuild)
+}
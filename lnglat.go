@@ -0,0 +1,48 @@
+package kdbush
+
+import (
+	"fmt"
+	"math"
+)
+
+// Geographic point in degrees, used by all geographic APIs, so longitude and latitude can't be swapped.
+// Implements Point interface: X is longitude, Y is latitude.
+type LngLat struct {
+	Lng, Lat float64
+}
+
+// Creates geographic point, longitude is normalized to [-180, 180).
+// Returns error if latitude is out of [-90, 90] or any coordinate is not finite.
+func NewLngLat(lng, lat float64) (LngLat, error) {
+	p := LngLat{Lng: lng, Lat: lat}
+	if err := p.Validate(); err != nil {
+		return LngLat{}, err
+	}
+	return p.Normalize(), nil
+}
+
+// LngLat's implementation of Point interface
+func (p LngLat) Coordinates() (float64, float64) {
+	return p.Lng, p.Lat
+}
+
+// Returns error if latitude is out of [-90, 90] or any coordinate is not finite.
+// Latitude out of range usually means, that coordinates are swapped.
+func (p LngLat) Validate() error {
+	if math.IsNaN(p.Lng) || math.IsInf(p.Lng, 0) || math.IsNaN(p.Lat) || math.IsInf(p.Lat, 0) {
+		return fmt.Errorf("kdbush: invalid coordinates %v, %v", p.Lng, p.Lat)
+	}
+	if p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("kdbush: latitude %v is out of range, are coordinates swapped?", p.Lat)
+	}
+	return nil
+}
+
+// Returns the point with longitude normalized to [-180, 180)
+func (p LngLat) Normalize() LngLat {
+	return LngLat{Lng: normLng(p.Lng), Lat: p.Lat}
+}
+
+func (p LngLat) String() string {
+	return fmt.Sprintf("(lng %g, lat %g)", p.Lng, p.Lat)
+}
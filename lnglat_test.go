@@ -0,0 +1,29 @@
+package kdbush
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLngLat(t *testing.T) {
+	p, err := NewLngLat(190, 45)
+	assert.NoError(t, err)
+	assert.Equal(t, LngLat{Lng: -170, Lat: 45}, p)
+
+	_, err = NewLngLat(45, 190)
+	assert.Error(t, err)
+	_, err = NewLngLat(math.NaN(), 0)
+	assert.Error(t, err)
+	_, err = NewLngLat(0, math.Inf(1))
+	assert.Error(t, err)
+
+	x, y := LngLat{Lng: 10, Lat: 20}.Coordinates()
+	assert.Equal(t, 10.0, x)
+	assert.Equal(t, 20.0, y)
+	assert.Equal(t, "(lng 10, lat 20)", LngLat{Lng: 10, Lat: 20}.String())
+
+	bush := NewBush([]Point{LngLat{Lng: 2.35, Lat: 48.85}, LngLat{Lng: -0.13, Lat: 51.51}}, 10)
+	assert.Equal(t, []int{1}, bush.Range(-1, 50, 0, 52))
+}
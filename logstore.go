@@ -0,0 +1,416 @@
+package kdbush
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Files of LogStore in its directory
+const (
+	logStoreSnapshot = "index.kdb"
+	logStoreLog      = "index.log"
+)
+
+// Both files start with a header: magic "KDBS" for the snapshot or "KDBL" for the log, generation uint64.
+// Compaction writes the snapshot and the empty log of the next generation, every file is written to a temporary file
+// and renamed, the snapshot first. Log of an older generation than the snapshot, left by a crash between the renames,
+// has only operations, that are already in the snapshot, so it's discarded on open.
+const (
+	logStoreSnapshotMagic = "KDBS"
+	logStoreLogMagic      = "KDBL"
+	logStoreHeaderSize    = 12
+)
+
+// operations of the delta log
+const (
+	logOpRemove uint8 = iota + 1
+	logOpInsert
+)
+
+// size of a log record: op uint8, original index uint64, x, y float64 (zero for remove), crc32 of all of them uint32
+const logRecordSize = 29
+
+// Options of LogStore
+type LogStoreOptions struct {
+	CompactAfter int  //compact automatically after this number of logged operations, 0 means never
+	NoSync       bool //don't fsync the log after every operation, faster, but last operations could be lost on power failure
+}
+
+// LogStore keeps the index crash safe on disk: a full serialized snapshot plus an append-only log of changes.
+// Every change is appended to the log before it's applied and acknowledged, so it survives restarts.
+// Compaction writes a new snapshot with all changes and starts a new empty log.
+// Inserted points get the next original indices, the index is rebuilt with them, when Index is called
+// or on compaction. Rebuilt index keeps node size, split axes strategy and compact storage of the original one,
+// auxiliary arrays are not kept.
+// Safe for concurrent use, queries on Index should not run concurrently with changes.
+type LogStore struct {
+	dir  string
+	opts LogStoreOptions
+
+	mu      sync.Mutex
+	bush    *KDBush
+	pending [][2]float64 //inserted points, that are not in bush yet
+	log     *os.File
+	logSize int64  //size of the valid part of the log
+	gen     uint64 //generation of the snapshot and the log
+	ops     int    //operations in the log
+}
+
+// Creates new store in the directory with the index as the initial snapshot, replaces existing store
+func CreateLogStore(dir string, bush *KDBush, opts LogStoreOptions) (*LogStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &LogStore{dir: dir, opts: opts, bush: bush}
+	gen := uint64(1)
+	if f, err := os.Open(filepath.Join(dir, logStoreSnapshot)); err == nil {
+		//the new store must be newer than logs of the replaced one
+		if old, err := readLogStoreHeader(f, logStoreSnapshotMagic); err == nil {
+			gen = old + 1
+		}
+		f.Close()
+	}
+	if err := s.writeSnapshot(gen); err != nil {
+		return nil, err
+	}
+	if err := s.startLog(gen); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Opens the store: loads the snapshot and replays the log on it.
+// Incomplete or corrupted record at the end of the log, left by a crash during write, is discarded.
+func OpenLogStore(dir string, opts LogStoreOptions) (*LogStore, error) {
+	f, err := os.Open(filepath.Join(dir, logStoreSnapshot))
+	if err != nil {
+		return nil, err
+	}
+	gen, err := readLogStoreHeader(f, logStoreSnapshotMagic)
+	var bush *KDBush
+	if err == nil {
+		bush, err = Load(f)
+	}
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &LogStore{dir: dir, opts: opts, bush: bush, gen: gen}
+	log, err := os.OpenFile(filepath.Join(dir, logStoreLog), os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, s.startLog(gen)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logGen, err := readLogStoreHeader(log, logStoreLogMagic)
+	if err == nil && logGen > gen {
+		err = fmt.Errorf("%w: log generation %d is newer than snapshot generation %d", ErrInvalidFormat, logGen, gen)
+	}
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	if logGen < gen {
+		//crash after the new snapshot was written, the log is already in it
+		log.Close()
+		return s, s.startLog(gen)
+	}
+
+	s.log = log
+	valid, err := s.replay()
+	if err == nil {
+		//drop the broken tail, new records are appended after valid ones
+		err = log.Truncate(valid)
+	}
+	if err == nil {
+		_, err = log.Seek(valid, io.SeekStart)
+	}
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	s.logSize = valid
+	return s, nil
+}
+
+// reads the header of the store file, returns its generation
+func readLogStoreHeader(r io.Reader, magic string) (uint64, error) {
+	var header [logStoreHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if string(header[:4]) != magic {
+		return 0, ErrInvalidFormat
+	}
+	return binary.LittleEndian.Uint64(header[4:]), nil
+}
+
+// applies all valid records of the log, returns size of valid part of the log
+func (s *LogStore) replay() (int64, error) {
+	r := bufio.NewReader(s.log)
+	var rec [logRecordSize]byte
+	valid := int64(logStoreHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return valid, nil
+			}
+			return 0, err
+		}
+		if crc32.ChecksumIEEE(rec[:25]) != binary.LittleEndian.Uint32(rec[25:]) {
+			return valid, nil
+		}
+		idx := int(binary.LittleEndian.Uint64(rec[1:]))
+		switch rec[0] {
+		case logOpRemove:
+			if s.pendingIdx(idx) {
+				s.rebuild()
+			}
+			s.bush.Remove(idx)
+		case logOpInsert:
+			if idx != s.nextIdx() {
+				return valid, nil
+			}
+			x := math.Float64frombits(binary.LittleEndian.Uint64(rec[9:]))
+			y := math.Float64frombits(binary.LittleEndian.Uint64(rec[17:]))
+			s.pending = append(s.pending, [2]float64{x, y})
+		default:
+			return valid, nil
+		}
+		valid += logRecordSize
+		s.ops++
+	}
+}
+
+// Returns the index with all changes applied, rebuilds it if points were inserted since the last call
+func (s *LogStore) Index() *KDBush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		s.rebuild()
+	}
+	return s.bush
+}
+
+// Adds the point, logs it and returns its original index in the index
+func (s *LogStore) Insert(x, y float64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := s.nextIdx()
+	if err := s.append(logOpInsert, idx, x, y); err != nil {
+		return -1, err
+	}
+	s.pending = append(s.pending, [2]float64{x, y})
+	return idx, s.autoCompact()
+}
+
+// Logs removal of the point and marks it as removed, returns false if the point is not in the index or already removed.
+// Returns ErrFrozen if the index is frozen.
+func (s *LogStore) Remove(idx int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pendingIdx(idx) {
+		s.rebuild()
+	}
+	if s.bush.Frozen() {
+		return false, ErrFrozen
+	}
+	if s.bush.TreePos(idx) < 0 || s.bush.IsRemoved(idx) {
+		return false, nil
+	}
+	if err := s.append(logOpRemove, idx, 0, 0); err != nil {
+		return false, err
+	}
+	s.bush.Remove(idx)
+	return true, s.autoCompact()
+}
+
+// original index of the next inserted point
+func (s *LogStore) nextIdx() int {
+	return s.bush.originalCount() + len(s.pending)
+}
+
+// tells whether idx is an inserted point, that is not in bush yet
+func (s *LogStore) pendingIdx(idx int) bool {
+	return idx >= s.bush.originalCount() && idx < s.nextIdx()
+}
+
+// builds the index from points of the current one and inserted points, original indices and tombstones are kept
+func (s *LogStore) rebuild() {
+	old := s.bush
+	n := old.originalCount()
+	cfg := &buildConfig{skipInvalid: true, spreadSplit: old.splitAxes != nil, compact: old.Compact()}
+	bush := &KDBush{}
+	bush.buildFrom(n+len(s.pending), func(idx int) (float64, float64) {
+		if idx >= n {
+			p := s.pending[idx-n]
+			return p[0], p[1]
+		}
+		if i := old.treePos(idx); i >= 0 {
+			return old.x(i), old.y(i)
+		}
+		//points, that are not in the index, stay out of it
+		return math.NaN(), math.NaN()
+	}, old.NodeSize, cfg)
+	//coordinates are already projected, so the projection is set only for queries
+	bush.projection = old.projection
+	for idx := 0; idx < n; idx++ {
+		if old.IsRemoved(idx) {
+			bush.markRemoved(idx)
+		}
+	}
+	s.bush = bush
+	s.pending = nil
+}
+
+func (s *LogStore) append(op uint8, idx int, x, y float64) error {
+	var rec [logRecordSize]byte
+	rec[0] = op
+	binary.LittleEndian.PutUint64(rec[1:], uint64(idx))
+	binary.LittleEndian.PutUint64(rec[9:], math.Float64bits(x))
+	binary.LittleEndian.PutUint64(rec[17:], math.Float64bits(y))
+	binary.LittleEndian.PutUint32(rec[25:], crc32.ChecksumIEEE(rec[:25]))
+	_, err := s.log.Write(rec[:])
+	if err == nil && !s.opts.NoSync {
+		err = s.log.Sync()
+	}
+	if err != nil {
+		//drop the partially written record, so the following records are not appended after a torn one
+		if terr := s.log.Truncate(s.logSize); terr == nil {
+			s.log.Seek(s.logSize, io.SeekStart)
+		}
+		return err
+	}
+	s.logSize += logRecordSize
+	s.ops++
+	return nil
+}
+
+func (s *LogStore) autoCompact() error {
+	if s.opts.CompactAfter > 0 && s.ops >= s.opts.CompactAfter {
+		return s.compact()
+	}
+	return nil
+}
+
+// Number of operations in the log since the last compaction
+func (s *LogStore) LogSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ops
+}
+
+// Writes a new snapshot with all changes and starts a new empty log
+func (s *LogStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+func (s *LogStore) compact() error {
+	if len(s.pending) > 0 {
+		s.rebuild()
+	}
+	gen := s.gen + 1
+	if err := s.writeSnapshot(gen); err != nil {
+		return err
+	}
+	return s.startLog(gen)
+}
+
+// writes the snapshot of the generation to a temporary file and renames it,
+// so the old snapshot is valid until the new one is complete
+func (s *LogStore) writeSnapshot(gen uint64) error {
+	f, tmp, err := s.createTemp(logStoreSnapshot, logStoreSnapshotMagic, gen)
+	if err != nil {
+		return err
+	}
+	err = s.bush.Save(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(s.dir, logStoreSnapshot))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// replaces the log with the empty log of the generation, the new file is renamed into place after it's synced
+func (s *LogStore) startLog(gen uint64) error {
+	f, tmp, err := s.createTemp(logStoreLog, logStoreLogMagic, gen)
+	if err == nil {
+		err = f.Sync()
+		if err == nil {
+			err = os.Rename(tmp, filepath.Join(s.dir, logStoreLog))
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}
+	if err == nil {
+		err = syncDir(s.dir)
+	}
+	if err != nil {
+		return err
+	}
+	if s.log != nil {
+		s.log.Close()
+	}
+	s.log, s.logSize, s.gen, s.ops = f, logStoreHeaderSize, gen, 0
+	return nil
+}
+
+// creates temporary file for the store file with the header written
+func (s *LogStore) createTemp(name, magic string, gen uint64) (*os.File, string, error) {
+	tmp := filepath.Join(s.dir, name+".tmp")
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, "", err
+	}
+	var header [logStoreHeaderSize]byte
+	copy(header[:], magic)
+	binary.LittleEndian.PutUint64(header[4:], gen)
+	if _, err := f.Write(header[:]); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return nil, "", err
+	}
+	return f, tmp, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return err
+	}
+	return nil
+}
+
+// Closes the log, the store should not be used after that
+func (s *LogStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
@@ -0,0 +1,160 @@
+package kdbush
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := CreateLogStore(dir, NewBush(getTestPoints(), 10), LogStoreOptions{})
+	assert.NoError(t, err)
+
+	for _, idx := range []int{3, 7, 42} {
+		ok, err := s.Remove(idx)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+	}
+	ok, err := s.Remove(3)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.LogSize())
+
+	//simulate crash: close without compaction and leave a torn record at the end
+	assert.NoError(t, s.Close())
+	f, err := os.OpenFile(filepath.Join(dir, logStoreLog), os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	f.Write([]byte{logOpRemove, 1, 0, 0})
+	f.Close()
+
+	s, err = OpenLogStore(dir, LogStoreOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	bush := s.Index()
+	assert.Equal(t, 3, bush.RemovedCount())
+	assert.True(t, bush.IsRemoved(3) && bush.IsRemoved(7) && bush.IsRemoved(42))
+	assert.Equal(t, 3, s.LogSize())
+
+	//records after the torn one are appended to the valid part
+	_, err = s.Remove(50)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Compact())
+	assert.Equal(t, 0, s.LogSize())
+	assert.NoError(t, s.Close())
+
+	s, err = OpenLogStore(dir, LogStoreOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, s.Index().RemovedCount())
+		assert.True(t, s.Index().IsRemoved(50))
+		assert.NoError(t, s.Close())
+	}
+
+	_, err = OpenLogStore(filepath.Join(dir, "missing"), LogStoreOptions{})
+	assert.Error(t, err)
+}
+
+func TestLogStore_CompactAfter(t *testing.T) {
+	dir := t.TempDir()
+	s, err := CreateLogStore(dir, NewBush(getTestPoints(), 10), LogStoreOptions{CompactAfter: 2, NoSync: true})
+	assert.NoError(t, err)
+	defer s.Close()
+
+	s.Remove(1)
+	assert.Equal(t, 1, s.LogSize())
+	s.Remove(2)
+	assert.Equal(t, 0, s.LogSize())
+	info, err := os.Stat(filepath.Join(dir, logStoreLog))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(logStoreHeaderSize), info.Size())
+}
+
+func TestLogStore_Insert(t *testing.T) {
+	dir := t.TempDir()
+	points := getTestPoints()
+	s, err := CreateLogStore(dir, NewBush(points, 10), LogStoreOptions{})
+	assert.NoError(t, err)
+
+	idx, err := s.Insert(1000, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, len(points), idx)
+	idx2, err := s.Insert(1001, 1001)
+	assert.NoError(t, err)
+	assert.Equal(t, len(points)+1, idx2)
+	ok, err := s.Remove(idx2)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	_, err = s.Remove(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{idx}, s.Index().Range(999, 999, 1002, 1002))
+	assert.NoError(t, s.Close())
+
+	for _, compact := range []bool{false, true} {
+		s, err = OpenLogStore(dir, LogStoreOptions{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		bush := s.Index()
+		assert.Equal(t, []int{idx}, bush.Range(999, 999, 1002, 1002))
+		assert.True(t, bush.IsRemoved(5) && bush.IsRemoved(idx2))
+		assert.Equal(t, 2, bush.RemovedCount())
+		expected := NewBush(points, 10)
+		expected.Remove(5)
+		assert.ElementsMatch(t, expected.Within(&SimplePoint{X: 50, Y: 50}, 30), bush.Within(&SimplePoint{X: 50, Y: 50}, 30))
+		if compact {
+			assert.NoError(t, s.Compact())
+			assert.Equal(t, 0, s.LogSize())
+		}
+		assert.NoError(t, s.Close())
+	}
+}
+
+func TestLogStore_StaleLog(t *testing.T) {
+	dir := t.TempDir()
+	s, err := CreateLogStore(dir, NewBush(getTestPoints(), 10), LogStoreOptions{})
+	assert.NoError(t, err)
+	_, err = s.Insert(1000, 1000)
+	assert.NoError(t, err)
+	s.Remove(3)
+	old, err := os.ReadFile(filepath.Join(dir, logStoreLog))
+	assert.NoError(t, err)
+
+	//simulate crash after the new snapshot is renamed, but before the new log is
+	assert.NoError(t, s.Compact())
+	assert.NoError(t, s.Close())
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, logStoreLog), old, 0o644))
+
+	s, err = OpenLogStore(dir, LogStoreOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 0, s.LogSize())
+	assert.Equal(t, 1, s.Index().RemovedCount())
+	assert.Equal(t, len(getTestPoints())+1, s.Index().Stats().Input)
+	assert.NoError(t, s.Close())
+
+	//log of a newer generation doesn't belong to the snapshot
+	newer := append([]byte{}, old...)
+	newer[4] = 100
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, logStoreLog), newer, 0o644))
+	_, err = OpenLogStore(dir, LogStoreOptions{})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestLogStore_AppendError(t *testing.T) {
+	s, err := CreateLogStore(t.TempDir(), NewBush(getTestPoints(), 10), LogStoreOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, s.log.Close())
+
+	ok, err := s.Remove(3)
+	assert.False(t, ok)
+	assert.Error(t, err)
+	assert.False(t, s.Index().IsRemoved(3), "the index is not changed if the log is not written")
+	_, err = s.Insert(1, 1)
+	assert.Error(t, err)
+	assert.Equal(t, len(getTestPoints()), s.Index().Stats().Input)
+	assert.Equal(t, 0, s.LogSize())
+}
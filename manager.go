@@ -0,0 +1,182 @@
+package kdbush
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	sorting "sort"
+	"sync"
+	"time"
+)
+
+// Returned by IndexManager when the index is bigger than the whole memory budget
+var ErrOverBudget = errors.New("kdbush: index exceeds memory budget")
+
+// IndexManager owns many named indexes (e.g. one per tenant) and keeps their total memory within the budget.
+// When a new index doesn't fit, least recently used indexes are evicted.
+// Memory of an index is estimated by MemoryUsage, points held by the caller are not counted.
+// Safe for concurrent use.
+type IndexManager struct {
+	budget int64
+
+	mu      sync.Mutex
+	used    int64
+	lru     *list.List //of *managedIndex, most recently used first
+	indexes map[string]*list.Element
+	evicted int
+}
+
+type managedIndex struct {
+	name    string
+	bush    *KDBush
+	bytes   int64
+	builtAt time.Time
+	usedAt  time.Time
+	hits    uint64
+}
+
+// Statistics of the index in the IndexManager
+type IndexStats struct {
+	Stats
+	Name    string
+	Bytes   int64     //estimated memory of the index
+	Hits    uint64    //number of Get calls, that returned the index
+	BuiltAt time.Time //when the index was put into the manager
+	UsedAt  time.Time //last Get of the index
+}
+
+// Creates manager with the memory budget in bytes, budget <= 0 means no limit
+func NewIndexManager(budget int64) *IndexManager {
+	return &IndexManager{
+		budget:  budget,
+		lru:     list.New(),
+		indexes: map[string]*list.Element{},
+	}
+}
+
+// Builds the index from points and puts it under the name, replacing the previous one
+func (m *IndexManager) Build(name string, points []Point, nodeSize int, opts ...Option) (*KDBush, error) {
+	bush := NewBushWithOptions(points, nodeSize, opts...)
+	if err := m.Put(name, bush); err != nil {
+		return nil, err
+	}
+	return bush, nil
+}
+
+// Puts the index under the name, replacing the previous one.
+// Evicts least recently used indexes, if needed to stay within the budget.
+// Returns ErrOverBudget if the index alone doesn't fit, the previous index is kept in this case.
+func (m *IndexManager) Put(name string, bush *KDBush) error {
+	bytes := bush.MemoryUsage()
+	if m.budget > 0 && bytes > m.budget {
+		return fmt.Errorf("%w: %q needs %d bytes, budget is %d", ErrOverBudget, name, bytes, m.budget)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(name)
+	for m.budget > 0 && m.used+bytes > m.budget && m.lru.Len() > 0 {
+		m.remove(m.lru.Back().Value.(*managedIndex).name)
+		m.evicted++
+	}
+	now := time.Now()
+	m.indexes[name] = m.lru.PushFront(&managedIndex{name: name, bush: bush, bytes: bytes, builtAt: now, usedAt: now})
+	m.used += bytes
+	return nil
+}
+
+// Returns the index by name and marks it as recently used
+func (m *IndexManager) Get(name string) (*KDBush, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.indexes[name]
+	if !ok {
+		return nil, false
+	}
+	m.lru.MoveToFront(e)
+	mi := e.Value.(*managedIndex)
+	mi.hits++
+	mi.usedAt = time.Now()
+	return mi.bush, true
+}
+
+// Removes the index by name, returns false if there was no such index
+func (m *IndexManager) Evict(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(name)
+}
+
+func (m *IndexManager) remove(name string) bool {
+	e, ok := m.indexes[name]
+	if !ok {
+		return false
+	}
+	m.lru.Remove(e)
+	delete(m.indexes, name)
+	m.used -= e.Value.(*managedIndex).bytes
+	return true
+}
+
+// Names of all indexes, sorted
+func (m *IndexManager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.indexes))
+	for name := range m.indexes {
+		names = append(names, name)
+	}
+	sorting.Strings(names)
+	return names
+}
+
+// Returns statistics of the index by name, doesn't mark it as used
+func (m *IndexManager) IndexStats(name string) (IndexStats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.indexes[name]
+	if !ok {
+		return IndexStats{}, false
+	}
+	mi := e.Value.(*managedIndex)
+	return IndexStats{
+		Stats:   mi.bush.Stats(),
+		Name:    name,
+		Bytes:   mi.bytes,
+		Hits:    mi.hits,
+		BuiltAt: mi.builtAt,
+		UsedAt:  mi.usedAt,
+	}, true
+}
+
+// Returns estimated memory of all indexes and number of indexes evicted to stay within the budget
+func (m *IndexManager) Usage() (bytes int64, evicted int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.used, m.evicted
+}
+
+// Estimated memory of the index in bytes: tree arrays, tombstones and auxiliary arrays.
+// Points slice is owned by the caller and is not counted.
+func (bush *KDBush) MemoryUsage() int64 {
+	bytes := int64(8*cap(bush.Idxs) + 8*cap(bush.Coords) + 8*cap(bush.pos) + 8*cap(bush.removed) + 8*cap(bush.nodeBoxes))
+	bytes += int64(4*cap(bush.coords32) + 4*cap(bush.idxs32) + 4*cap(bush.pos32) + cap(bush.splitAxes))
+	bytes += int64(8*cap(bush.dupRep) + 8*cap(bush.dupStart) + 8*cap(bush.dupMembers))
+	for _, values := range bush.aux {
+		switch v := values.(type) {
+		case []float64:
+			bytes += int64(8 * cap(v))
+		case []float32:
+			bytes += int64(4 * cap(v))
+		case []int64:
+			bytes += int64(8 * cap(v))
+		case []int32:
+			bytes += int64(4 * cap(v))
+		case []uint32:
+			bytes += int64(4 * cap(v))
+		case []uint8:
+			bytes += int64(cap(v))
+		}
+	}
+	return bytes
+}
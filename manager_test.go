@@ -0,0 +1,62 @@
+package kdbush
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_MemoryUsage(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.Equal(t, int64(100*(8+16+8)), bush.MemoryUsage())
+
+	assert.NoError(t, bush.SetZ(make([]float64, 100)))
+	assert.Equal(t, int64(100*(8+16+8+8)), bush.MemoryUsage())
+}
+
+func TestIndexManager(t *testing.T) {
+	size := NewBush(getTestPoints(), 10).MemoryUsage()
+	m := NewIndexManager(2*size + size/2)
+
+	_, err := m.Build("a", getTestPoints(), 10)
+	assert.NoError(t, err)
+	_, err = m.Build("b", getTestPoints(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, m.Names())
+
+	//a is used recently, so b is evicted
+	bush, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Len(t, bush.Range(0, 0, 100, 100), 100)
+	_, err = m.Build("c", getTestPoints(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, m.Names())
+
+	bytes, evicted := m.Usage()
+	assert.Equal(t, 2*size, bytes)
+	assert.Equal(t, 1, evicted)
+
+	stats, ok := m.IndexStats("a")
+	assert.True(t, ok)
+	assert.Equal(t, "a", stats.Name)
+	assert.Equal(t, size, stats.Bytes)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, 100, stats.Points)
+
+	//replace doesn't evict others
+	assert.NoError(t, m.Put("a", NewBush(getTestPoints(), 10)))
+	assert.Equal(t, []string{"a", "c"}, m.Names())
+
+	big := NewBush(append(getTestPoints(), getTestPoints()...), 10)
+	big = NewBush(append(big.Points, getTestPoints()...), 10)
+	assert.True(t, errors.Is(m.Put("big", big), ErrOverBudget))
+	assert.Equal(t, []string{"a", "c"}, m.Names())
+
+	assert.True(t, m.Evict("c"))
+	assert.False(t, m.Evict("c"))
+	_, ok = m.Get("c")
+	assert.False(t, ok)
+	bytes, _ = m.Usage()
+	assert.Equal(t, size, bytes)
+}
@@ -0,0 +1,120 @@
+package kdbush
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Encodes the index for encoding/gob in the format of Save, with auxiliary arrays.
+// Points are not encoded, set them back after decoding if you need.
+func (bush *KDBush) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := bush.Save(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decodes the index encoded by GobEncode, Points of the decoded index are nil.
+// Returns ErrFrozen if the index is frozen.
+func (bush *KDBush) GobDecode(data []byte) error {
+	if bush.frozen {
+		return ErrFrozen
+	}
+	loaded, err := Load(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*bush = *loaded
+	return nil
+}
+
+// JSON form of the index, auxiliary arrays are not included
+type jsonBush struct {
+	NodeSize int       `json:"nodeSize"`
+	Input    int       `json:"input"`             //number of points in the original input
+	Ids      []int     `json:"ids"`               //Idxs
+	Coords   []float64 `json:"coords"`            //Coords
+	Removed  []int     `json:"removed,omitempty"` //original indices of removed points
+
+	SplitAxes  []uint8         `json:"splitAxes,omitempty"`  //split axes of WithSpreadSplit option, base64
+	Projection *jsonProjection `json:"projection,omitempty"` //projection of WithProjection option
+}
+
+// JSON form of built-in projection, see encodeProjection
+type jsonProjection struct {
+	Kind   uint8      `json:"kind"`
+	Params [2]float64 `json:"params"`
+}
+
+// Encodes the index as JSON object with nodeSize, input, ids, coords and removed fields.
+// Points and auxiliary arrays are not encoded, set Points back after decoding if you need.
+// Returns ErrUnsupportedProjection if the index has a custom projection.
+func (bush *KDBush) MarshalJSON() ([]byte, error) {
+	j := jsonBush{NodeSize: bush.NodeSize, Input: bush.originalCount(), Ids: bush.idxSlice(), Coords: bush.coordSlice(), SplitAxes: bush.splitAxes}
+	if bush.projection != nil {
+		kind, a, b, err := encodeProjection(bush.projection)
+		if err != nil {
+			return nil, err
+		}
+		j.Projection = &jsonProjection{Kind: kind, Params: [2]float64{a, b}}
+	}
+	if j.Ids == nil {
+		j.Ids = []int{}
+	}
+	if j.Coords == nil {
+		j.Coords = []float64{}
+	}
+	for idx := 0; idx < j.Input && bush.removedCount > 0; idx++ {
+		if bush.IsRemoved(idx) && bush.TreePos(idx) >= 0 {
+			j.Removed = append(j.Removed, idx)
+		}
+	}
+	return json.Marshal(j)
+}
+
+// Decodes the index encoded by MarshalJSON, Points of the decoded index are nil.
+// Returns ErrFrozen if the index is frozen.
+func (bush *KDBush) UnmarshalJSON(data []byte) error {
+	if bush.frozen {
+		return ErrFrozen
+	}
+	var j jsonBush
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if len(j.Coords) != 2*len(j.Ids) {
+		return fmt.Errorf("%w: %d coordinates for %d points", ErrInvalidFormat, len(j.Coords), len(j.Ids))
+	}
+	if j.SplitAxes != nil && len(j.SplitAxes) != len(j.Ids) {
+		return fmt.Errorf("%w: %d split axes for %d points", ErrInvalidFormat, len(j.SplitAxes), len(j.Ids))
+	}
+	if i := invalidAxis(j.SplitAxes); i >= 0 {
+		return fmt.Errorf("%w: invalid split axis %d on position %d", ErrInvalidFormat, j.SplitAxes[i], i)
+	}
+	if j.Input < len(j.Ids) {
+		return fmt.Errorf("%w: input %d is less than %d points", ErrInvalidFormat, j.Input, len(j.Ids))
+	}
+	for _, ids := range [][]int{j.Ids, j.Removed} {
+		for _, idx := range ids {
+			if idx < 0 || idx >= j.Input {
+				return fmt.Errorf("%w: index %d is out of range", ErrInvalidFormat, idx)
+			}
+		}
+	}
+
+	loaded := &KDBush{NodeSize: j.NodeSize, Idxs: j.Ids, Coords: j.Coords, input: j.Input, splitAxes: j.SplitAxes}
+	if j.Projection != nil {
+		var err error
+		if loaded.projection, err = decodeProjection(j.Projection.Kind, j.Projection.Params[0], j.Projection.Params[1]); err != nil {
+			return err
+		}
+	}
+	loaded.derive()
+	for _, idx := range j.Removed {
+		loaded.Remove(idx)
+	}
+	*bush = *loaded
+	return nil
+}
@@ -0,0 +1,77 @@
+package kdbush
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Gob(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	bush.Remove(60)
+	assert.NoError(t, SetAux(bush, "weight", make([]float64, len(points))))
+
+	type cached struct {
+		Name  string
+		Index *KDBush
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(cached{"pois", bush}))
+	var decoded cached
+	if !assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded)) {
+		return
+	}
+	loaded := decoded.Index
+	assert.Equal(t, "pois", decoded.Name)
+	assert.Nil(t, loaded.Points)
+	assert.Equal(t, bush.Idxs, loaded.Idxs)
+	assert.Equal(t, bush.Coords, loaded.Coords)
+	assert.True(t, loaded.IsRemoved(60))
+	assert.Equal(t, []string{"weight"}, loaded.AuxNames())
+	assert.Equal(t, bush.Range(20, 30, 50, 70), loaded.Range(20, 30, 50, 70))
+
+	assert.ErrorIs(t, (&KDBush{}).GobDecode([]byte("bad")), ErrInvalidFormat)
+}
+
+func TestKDBush_JSON(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	bush.Remove(60)
+
+	data, err := json.Marshal(bush)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var loaded KDBush
+	if !assert.NoError(t, json.Unmarshal(data, &loaded)) {
+		return
+	}
+	assert.Equal(t, 10, loaded.NodeSize)
+	assert.Equal(t, bush.Idxs, loaded.Idxs)
+	assert.Equal(t, bush.Coords, loaded.Coords)
+	assert.True(t, loaded.IsRemoved(60))
+	assert.Equal(t, 1, loaded.RemovedCount())
+	assert.NoError(t, loaded.Verify())
+
+	//points could be attached back
+	loaded.Points = points
+	assert.Equal(t, bush.Nearest(&SimplePoint{50, 50}, 5, 0), loaded.Nearest(&SimplePoint{50, 50}, 5, 0))
+
+	empty, err := json.Marshal(NewBush(nil, 10))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"nodeSize": 10, "input": 0, "ids": [], "coords": []}`, string(empty))
+
+	for _, bad := range []string{
+		`[]`,
+		`{"nodeSize": 10, "input": 1, "ids": [0], "coords": [1]}`,
+		`{"nodeSize": 10, "input": 1, "ids": [1], "coords": [1, 2]}`,
+		`{"nodeSize": 10, "input": 1, "ids": [0], "coords": [1, 2], "removed": [5]}`,
+		`{"nodeSize": 10, "input": 0, "ids": [0], "coords": [1, 2]}`,
+	} {
+		assert.ErrorIs(t, json.Unmarshal([]byte(bad), &KDBush{}), ErrInvalidFormat, bad)
+	}
+}
@@ -0,0 +1,45 @@
+package kdbush
+
+import (
+	sorting "sort"
+)
+
+// Assigns every query point to a distinct indexed point within maxDist.
+// Greedy by distance: the closest pair of all candidates is matched first, then the next closest one
+// of not yet matched queries and points, and so on.
+// Returns slice parallel to queries with original indexes of matched points, -1 for not matched queries.
+func (bush *KDBush) MatchGreedy(queries []Point, maxDist float64) []int {
+	type pair struct {
+		query, pos int
+		distSq     float64
+	}
+	pairs := []pair{}
+	r2 := maxDist * maxDist
+	cfg := &queryConfig{}
+	for q, p := range queries {
+		qx, qy := p.Coordinates()
+		bush.search(qx-maxDist, qy-maxDist, qx+maxDist, qy+maxDist, cfg, func(i int) bool {
+			if d := sqrtDist(bush.x(i), bush.y(i), qx, qy); d <= r2 {
+				pairs = append(pairs, pair{q, i, d})
+			}
+			return true
+		})
+	}
+	sorting.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].distSq < pairs[j].distSq
+	})
+
+	result := make([]int, len(queries))
+	for i := range result {
+		result[i] = -1
+	}
+	taken := make(map[int]bool, len(queries))
+	for _, p := range pairs {
+		if result[p.query] >= 0 || taken[p.pos] {
+			continue
+		}
+		result[p.query] = bush.idx(p.pos)
+		taken[p.pos] = true
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_MatchGreedy(t *testing.T) {
+	couriers := []Point{
+		&SimplePoint{X: 0, Y: 0},
+		&SimplePoint{X: 10, Y: 0},
+		&SimplePoint{X: 100, Y: 100},
+	}
+	bush := NewBush(couriers, 2)
+
+	orders := []Point{
+		&SimplePoint{X: 1, Y: 0},   //closest to 0
+		&SimplePoint{X: 2, Y: 0},   //0 is taken by more close order, gets 1
+		&SimplePoint{X: 0, Y: 1},   //both near couriers are taken
+		&SimplePoint{X: 50, Y: 50}, //nothing within the distance
+	}
+	assert.Equal(t, []int{0, 1, -1, -1}, bush.MatchGreedy(orders, 20))
+
+	bush.Remove(0)
+	//order 1 is closer to courier 1 than order 0
+	assert.Equal(t, []int{-1, 1, -1, -1}, bush.MatchGreedy(orders, 20))
+}
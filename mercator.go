@@ -0,0 +1,59 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Spherical mercator normalized to [0, 1], the coordinates of supercluster and of map tiles:
+// x grows eastward from the antimeridian, y grows southward from the north edge of the map.
+// Latitudes beyond the clamp are stored at the clamp, so inverse of such points returns the clamped latitude.
+type NormalizedMercator struct {
+	MaxLat float64 //latitude clamp in degrees, WebMercator limit 85.0511... if 0
+}
+
+func (m NormalizedMercator) maxLat() float64 {
+	if m.MaxLat <= 0 || m.MaxLat > 90 {
+		return webMercatorMaxLat
+	}
+	return m.MaxLat
+}
+
+func (m NormalizedMercator) Forward(lng, lat float64) (float64, float64) {
+	maxLat := m.maxLat()
+	lat = math.Max(-maxLat, math.Min(maxLat, lat))
+	sin := math.Sin(lat * rad)
+	y := 0.5 - 0.25*math.Log((1+sin)/(1-sin))/math.Pi
+	return lng/360 + 0.5, math.Max(0, math.Min(1, y))
+}
+
+func (m NormalizedMercator) Inverse(x, y float64) (float64, float64) {
+	return (x - 0.5) * 360, 360*math.Atan(math.Exp((180-y*360)*rad))/math.Pi - 90
+}
+
+// Stores points in NormalizedMercator coordinates with the latitude clamp, input coordinates are lng, lat degrees.
+// Geographic queries GeoNearest, GeoAround and RangeLngLat take lng, lat and convert them automatically,
+// FromLngLat query option converts queries with options, RangeTile finds points of a map tile.
+func WithMercatorStorage(maxLatClamp float64) Option {
+	return WithProjection(NormalizedMercator{MaxLat: maxLatClamp})
+}
+
+// Finds all points of the map tile z/x/y. Index should be built with WithMercatorStorage option,
+// otherwise stored coordinates are treated as normalized mercator as they are.
+func (bush *KDBush) RangeTile(z, x, y int) []int {
+	z2 := float64(uint64(1) << uint(z))
+	return bush.Range(float64(x)/z2, float64(y)/z2, float64(x+1)/z2, float64(y+1)/z2)
+}
+
+// mercator storage of the index, ok is false for other projections
+func (bush *KDBush) mercator() (NormalizedMercator, bool) {
+	m, ok := bush.projection.(NormalizedMercator)
+	return m, ok
+}
+
+// lng, lat box of the node in mercator storage, northern edge has the minimal y
+func (m NormalizedMercator) lngLatNode(n treeNode) treeNode {
+	minLng, maxLat := m.Inverse(n.minX, n.minY)
+	maxLng, minLat := m.Inverse(n.maxX, n.maxY)
+	n.minX, n.minY, n.maxX, n.maxY = minLng, minLat, maxLng, maxLat
+	return n
+}
@@ -0,0 +1,60 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizedMercator(t *testing.T) {
+	m := NormalizedMercator{}
+	x, y := m.Forward(0, 0)
+	assert.InDelta(t, 0.5, x, 1e-12)
+	assert.InDelta(t, 0.5, y, 1e-12)
+	x, y = m.Forward(-180, 85.0511287798066)
+	assert.InDelta(t, 0, x, 1e-12)
+	assert.InDelta(t, 0, y, 1e-9)
+	_, y = m.Forward(90, -89)
+	assert.InDelta(t, 1, y, 1e-9, "clamped")
+	_, y = NormalizedMercator{MaxLat: 60}.Forward(0, 70)
+	_, y60 := m.Forward(0, 60)
+	assert.InDelta(t, y60, y, 1e-12)
+
+	lng, lat := m.Inverse(m.Forward(2.2945, 48.8583))
+	assert.InDelta(t, 2.2945, lng, 1e-9)
+	assert.InDelta(t, 48.8583, lat, 1e-9)
+}
+
+func TestWithMercatorStorage(t *testing.T) {
+	//geo test points without polar ones, that are clamped
+	points := []Point{}
+	for _, p := range geoTestPoints() {
+		if _, lat := p.Coordinates(); lat > -80 && lat < 80 {
+			points = append(points, p)
+		}
+	}
+	plain := NewBush(points, 16)
+	bush := NewBushWithOptions(points, 16, WithMercatorStorage(0))
+	assert.Equal(t, NormalizedMercator{}, bush.Projection())
+	minX, minY, maxX, maxY := bush.Bounds()
+	assert.True(t, minX >= 0 && minY >= 0 && maxX <= 1 && maxY <= 1)
+
+	for _, q := range []LngLat{{0, 0}, {179.9, 10}, {-30, 70}, {100, -55}} {
+		expected := plain.GeoNearest(q, 10, 0, Kilometers)
+		result := bush.GeoNearest(q, 10, 0, Kilometers)
+		for i := range expected {
+			assert.Equal(t, expected[i].Index, result[i].Index)
+			assert.InDelta(t, expected[i].Dist, result[i].Dist, 1e-6)
+		}
+		assert.Equal(t, plain.GeoAround(q.Lng, q.Lat, 0, 1000), bush.GeoAround(q.Lng, q.Lat, 0, 1000))
+	}
+	assert.Equal(t, sortedInts(plain.RangeLngLat(-20, 10, 40, 50)), sortedInts(bush.RangeLngLat(-20, 10, 40, 50)))
+	assert.Equal(t, sortedInts(plain.RangeLngLat(170, -30, -170, 30)), sortedInts(bush.RangeLngLat(170, -30, -170, 30)))
+
+	result, _ := bush.RangeWithOptions(-20, 10, 40, 50, FromLngLat())
+	assert.Equal(t, sortedInts(plain.RangeLngLat(-20, 10, 40, 50)), sortedInts(result))
+
+	//tile 1/1/0 is the north-east quarter of the map
+	assert.Equal(t, sortedInts(plain.RangeLngLat(0, 0, 180, 90)), sortedInts(bush.RangeTile(1, 1, 0)))
+	assert.Len(t, bush.RangeTile(0, 0, 0), len(points))
+}
@@ -0,0 +1,101 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Distance metric of WithinMetric query
+type Metric int
+
+const (
+	Euclidean Metric = iota //L2
+	Manhattan               //L1, sum of absolute differences of coordinates
+	Chebyshev               //L∞, maximum of absolute differences of coordinates
+)
+
+// Distance between two points in the metric
+func (m Metric) Distance(ax, ay, bx, by float64) float64 {
+	dx, dy := math.Abs(ax-bx), math.Abs(ay-by)
+	switch m {
+	case Manhattan:
+		return dx + dy
+	case Chebyshev:
+		return math.Max(dx, dy)
+	}
+	return math.Hypot(dx, dy)
+}
+
+// Finds all items within a given radius from the query point in the metric and returns an array of indices.
+// Chebyshev radius gives a square neighborhood, Manhattan - a diamond one.
+func (bush *KDBush) WithinMetric(point Point, radius float64, metric Metric) []int {
+	result := []int{}
+	qx, qy := point.Coordinates()
+	bush.search(qx-radius, qy-radius, qx+radius, qy+radius, &queryConfig{}, func(i int) bool {
+		x, y := bush.x(i), bush.y(i)
+		var ok bool
+		switch metric {
+		case Chebyshev:
+			ok = true //the search box is the neighborhood
+		case Manhattan:
+			ok = metric.Distance(x, y, qx, qy) <= radius
+		default:
+			ok = sqrtDist(x, y, qx, qy) <= radius*radius //the same as Within
+		}
+		if ok {
+			result = append(result, bush.idx(i))
+		}
+		return true
+	})
+	return result
+}
+
+// Distance between points a and b used by WithinCustom
+type DistanceFunc func(ax, ay, bx, by float64) float64
+
+// Euclidean distance with coordinates scaled per axis: circle of the radius becomes an axis aligned ellipse
+// with semi-axes radius/sx and radius/sy
+func ScaledDistance(sx, sy float64) DistanceFunc {
+	return func(ax, ay, bx, by float64) float64 {
+		return math.Hypot((ax-bx)*sx, (ay-by)*sy)
+	}
+}
+
+// Finds all items within a given radius from the query point by the distance function and returns an array of indices.
+// A node is skipped, when the distance to its closest point, the query point clamped to the node box, is bigger than the radius.
+// That is correct for distances, that don't decrease when the point moves away from the query point along any axis,
+// like all norms (Metric.Distance), weighted norms (ScaledDistance) and other metrics based on absolute coordinate differences.
+func (bush *KDBush) WithinCustom(point Point, radius float64, dist DistanceFunc) []int {
+	qx, qy := point.Coordinates()
+	result := []int{}
+	stack := []treeNode{bush.rootNode()}
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.right < n.left {
+			continue
+		}
+		cx, cy := math.Max(n.minX, math.Min(qx, n.maxX)), math.Max(n.minY, math.Min(qy, n.maxY))
+		if dist(qx, qy, cx, cy) > radius {
+			continue
+		}
+
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				if dist(qx, qy, bush.x(i), bush.y(i)) <= radius && !bush.removedAt(i) {
+					result = append(result, bush.idx(i))
+				}
+			}
+			continue
+		}
+
+		m := floor(float64(n.left+n.right) / 2.0)
+		if dist(qx, qy, bush.x(m), bush.y(m)) <= radius && !bush.removedAt(m) {
+			result = append(result, bush.idx(m))
+		}
+		l, r := bush.children(n, m)
+		stack = append(stack, l, r)
+	}
+	return result
+}
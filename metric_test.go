@@ -0,0 +1,59 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetric_Distance(t *testing.T) {
+	assert.Equal(t, 5.0, Euclidean.Distance(0, 0, 3, -4))
+	assert.Equal(t, 7.0, Manhattan.Distance(0, 0, 3, -4))
+	assert.Equal(t, 4.0, Chebyshev.Distance(0, 0, 3, -4))
+}
+
+func TestKDBush_WithinMetric(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	assert.Equal(t, sortedInts(bush.Within(q, 20)), sortedInts(bush.WithinMetric(q, 20, Euclidean)))
+	assert.Equal(t, sortedInts(bush.Range(30, 30, 70, 70)), sortedInts(bush.WithinMetric(q, 20, Chebyshev)))
+
+	manhattan := bush.WithinMetric(q, 20, Manhattan)
+	expected := []int{}
+	for i, p := range points {
+		x, y := p.Coordinates()
+		if Manhattan.Distance(x, y, 50, 50) <= 20 {
+			expected = append(expected, i)
+		}
+	}
+	assert.NotEmpty(t, expected)
+	assert.Equal(t, expected, sortedInts(manhattan))
+}
+
+func TestKDBush_WithinCustom(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	q := &SimplePoint{X: 50, Y: 50}
+
+	assert.Equal(t, sortedInts(bush.WithinMetric(q, 20, Manhattan)), sortedInts(bush.WithinCustom(q, 20, Manhattan.Distance)))
+	assert.Equal(t, sortedInts(bush.WithinMetric(q, 20, Chebyshev)), sortedInts(bush.WithinCustom(q, 20, Chebyshev.Distance)))
+
+	//ellipse with semi-axes 40 and 10
+	scaled := ScaledDistance(0.5, 2)
+	expected := []int{}
+	for i, p := range points {
+		x, y := p.Coordinates()
+		dx, dy := (x-50)/40, (y-50)/10
+		if dx*dx+dy*dy <= 1+1e-12 {
+			expected = append(expected, i)
+		}
+	}
+	assert.NotEmpty(t, expected)
+	assert.Equal(t, expected, sortedInts(bush.WithinCustom(q, 20, scaled)))
+
+	bush.Remove(expected[0])
+	assert.Equal(t, expected[1:], sortedInts(bush.WithinCustom(q, 20, scaled)))
+	assert.Empty(t, NewBush(nil, 10).WithinCustom(q, 20, scaled))
+}
@@ -0,0 +1,260 @@
+package kdbush
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"unsafe"
+)
+
+// Memory-mappable index format, arrays are stored in the in-memory layout of a little endian 64-bit platform,
+// every array is 8 bytes aligned, so they are used directly from the mapped file:
+//
+//	magic "KDBM", version uint32, nodeSize uint64, count uint64, input uint64, bbox 4*float64
+//	idxs count*int64, coords 2*count*float64, positions input*int64
+//	removed words uint64, removed bitset words*uint64
+//	version 3 only: flags uint64 (bit 0: split axes, bit 1: projection)
+//	version 2 and version 3 with flag: split axes count*uint8, padded to 8 bytes, written for indexes built with WithSpreadSplit option
+//	version 3 with flag: projection kind uint64, parameters 2*float64, written for indexes built with WithProjection option
+//
+// Version 3 is written only for indexes with a projection, so files of other indexes are opened by older versions.
+// Auxiliary arrays are not saved, custom projections can't be saved, SaveMmap fails with ErrUnsupportedProjection.
+const (
+	mmapMagic       = "KDBM"
+	mmapVersion     = 1
+	mmapVersionAxes = 2
+	//version with flags of the optional sections
+	mmapVersionFlags = 3
+	mmapHeaderSize   = 64
+)
+
+// Returned by OpenBushMmap on platforms, where in-memory layout differs from the file format
+var ErrMmapUnsupported = errors.New("kdbush: memory-mapped index is not supported on this platform")
+
+// Index, that uses arrays of a memory-mapped file. Idxs and Coords are read-only, writing to them crashes the process.
+// Tombstones are kept in memory, they are not written back to the file.
+type MmapBush struct {
+	*KDBush
+	data  []byte
+	unmap func() error
+}
+
+// Writes the index in the memory-mappable format, that is opened by OpenBushMmap
+func (bush *KDBush) SaveMmap(w io.Writer) error {
+	var projKind uint8
+	var projA, projB float64
+	if bush.projection != nil {
+		var err error
+		if projKind, projA, projB, err = encodeProjection(bush.projection); err != nil {
+			return err
+		}
+	}
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+	e.bytes([]byte(mmapMagic))
+	if bush.projection != nil {
+		e.u32(mmapVersionFlags)
+	} else if bush.splitAxes != nil {
+		e.u32(mmapVersionAxes)
+	} else {
+		e.u32(mmapVersion)
+	}
+	e.u64(uint64(bush.NodeSize))
+	e.u64(uint64(bush.size()))
+	e.u64(uint64(bush.originalCount()))
+	for _, v := range bush.bbox {
+		e.f64(v)
+	}
+	for i := 0; i < bush.size(); i++ {
+		e.u64(uint64(bush.idx(i)))
+	}
+	for i := 0; i < bush.coordsLen(); i++ {
+		e.f64(bush.coord(i/2, i%2))
+	}
+	for idx := 0; idx < bush.posLen(); idx++ {
+		e.u64(uint64(bush.treePos(idx)))
+	}
+	removed := bush.removed
+	if bush.removedCount == 0 {
+		removed = nil
+	}
+	e.u64(uint64(len(removed)))
+	for _, v := range removed {
+		e.u64(v)
+	}
+	if bush.projection != nil {
+		flags := uint64(formatFlagProjection)
+		if bush.splitAxes != nil {
+			flags |= formatFlagAxes
+		}
+		e.u64(flags)
+	}
+	if bush.splitAxes != nil {
+		e.bytes(bush.splitAxes)
+		e.bytes(make([]byte, (8-len(bush.splitAxes)%8)%8))
+	}
+	if bush.projection != nil {
+		e.u64(uint64(projKind))
+		e.f64(projA)
+		e.f64(projB)
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// Opens index written by SaveMmap. On unix systems the file is memory-mapped read-only,
+// so arrays of the index are not copied into the heap and the pages are shared by all processes, that opened the file.
+// On other systems the file is read into memory. Close the index, when it's not used anymore.
+func OpenBushMmap(path string) (*MmapBush, error) {
+	if !mmapLayoutNative() {
+		return nil, ErrMmapUnsupported
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, unmap, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	bush, err := parseMmap(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	return &MmapBush{KDBush: bush, data: data, unmap: unmap}, nil
+}
+
+// Unmaps the file, the index can't be used afterwards
+func (b *MmapBush) Close() error {
+	if b.unmap == nil {
+		return nil
+	}
+	unmap := b.unmap
+	b.unmap = nil
+	b.KDBush = nil
+	return unmap()
+}
+
+// int and float64 are 64-bit little endian, so the file could be used as is
+func mmapLayoutNative() bool {
+	one := uint16(1)
+	return strconv.IntSize == 64 && *(*byte)(unsafe.Pointer(&one)) == 1
+}
+
+func parseMmap(data []byte) (*KDBush, error) {
+	if len(data) < mmapHeaderSize || string(data[:4]) != mmapMagic {
+		return nil, ErrInvalidFormat
+	}
+	words := unsafe.Slice((*uint64)(unsafe.Pointer(&data[0])), len(data)/8)
+	version := uint32(words[0] >> 32)
+	if version != mmapVersion && version != mmapVersionAxes && version != mmapVersionFlags {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	nodeSize, count, input := words[1], words[2], words[3]
+	//header, idxs, coords, positions and length of removed bitset
+	size := uint64(mmapHeaderSize/8) + 3*count + input + 1
+	if count > input || input > uint64(len(words)) || size > uint64(len(words)) {
+		return nil, fmt.Errorf("%w: truncated data", ErrInvalidFormat)
+	}
+	removedWords := words[size-1]
+	if removedWords > uint64(len(words))-size {
+		return nil, fmt.Errorf("%w: truncated data", ErrInvalidFormat)
+	}
+	//optional sections follow the removed bitset
+	tail := size + removedWords
+	flags := uint64(0)
+	switch version {
+	case mmapVersionAxes:
+		flags = formatFlagAxes
+	case mmapVersionFlags:
+		if tail >= uint64(len(words)) {
+			return nil, fmt.Errorf("%w: truncated data", ErrInvalidFormat)
+		}
+		flags = words[tail]
+		tail++
+		if flags&^(formatFlagAxes|formatFlagProjection) != 0 {
+			return nil, fmt.Errorf("%w: unsupported flags %#x", ErrInvalidFormat, flags)
+		}
+	}
+	axesWords, projWords := uint64(0), uint64(0)
+	if flags&formatFlagAxes != 0 {
+		axesWords = (count + 7) / 8
+	}
+	if flags&formatFlagProjection != 0 {
+		projWords = 3
+	}
+	if axesWords > uint64(len(words))-tail || projWords > uint64(len(words))-tail-axesWords {
+		return nil, fmt.Errorf("%w: truncated data", ErrInvalidFormat)
+	}
+
+	bush := &KDBush{NodeSize: int(nodeSize), input: int(input)}
+	for i := range bush.bbox {
+		bush.bbox[i] = *(*float64)(unsafe.Pointer(&words[4+i]))
+	}
+	off := uint64(mmapHeaderSize / 8)
+	bush.Idxs = unsafe.Slice((*int)(unsafe.Pointer(&words[off])), count)
+	off += count
+	if count > 0 {
+		bush.Coords = unsafe.Slice((*float64)(unsafe.Pointer(&words[off])), 2*count)
+	} else {
+		bush.Coords = []float64{}
+	}
+	off += 2 * count
+	if input > 0 {
+		bush.pos = unsafe.Slice((*int)(unsafe.Pointer(&words[off])), input)
+	}
+	off += input + 1
+	if axesWords > 0 {
+		bush.splitAxes = unsafe.Slice((*uint8)(unsafe.Pointer(&words[tail])), count)
+		if i := invalidAxis(bush.splitAxes); i >= 0 {
+			return nil, fmt.Errorf("%w: invalid split axis %d on position %d", ErrInvalidFormat, bush.splitAxes[i], i)
+		}
+	}
+	if projWords > 0 {
+		p := words[tail+axesWords:]
+		if p[0] > math.MaxUint8 {
+			return nil, fmt.Errorf("%w: unknown projection %d", ErrInvalidFormat, p[0])
+		}
+		var err error
+		bush.projection, err = decodeProjection(uint8(p[0]), math.Float64frombits(p[1]), math.Float64frombits(p[2]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	//positions are used without bounds checks by queries, so they must be the exact inverse of idxs
+	for i, idx := range bush.Idxs {
+		if idx < 0 || idx >= bush.input {
+			return nil, fmt.Errorf("%w: index %d out of range", ErrInvalidFormat, idx)
+		}
+		if bush.pos[idx] != i {
+			return nil, fmt.Errorf("%w: position of index %d doesn't match", ErrInvalidFormat, idx)
+		}
+	}
+	for idx, i := range bush.pos {
+		if i != -1 && (i < 0 || i >= len(bush.Idxs) || bush.Idxs[i] != idx) {
+			return nil, fmt.Errorf("%w: position %d of index %d out of range", ErrInvalidFormat, i, idx)
+		}
+	}
+	if removedWords > 0 {
+		//tombstones could be changed, so they are copied
+		bush.removed = append([]uint64(nil), words[off:off+removedWords]...)
+		for _, idx := range bush.Idxs {
+			if bush.IsRemoved(idx) {
+				bush.removedCount++
+			}
+		}
+	}
+	return bush, nil
+}
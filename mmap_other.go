@@ -0,0 +1,22 @@
+//go:build !unix
+
+package kdbush
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// reads the file into 8 bytes aligned memory
+func mapFile(f *os.File, size int) ([]byte, func() error, error) {
+	if size == 0 {
+		return nil, nil, ErrInvalidFormat
+	}
+	words := make([]uint64, (size+7)/8)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
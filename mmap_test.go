@@ -0,0 +1,72 @@
+package kdbush
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenBushMmap(t *testing.T) {
+	bush := NewBush(benchmarkPoints()[:10000], 16)
+	bush.Remove(5)
+	path := filepath.Join(t.TempDir(), "index.kdbm")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, bush.SaveMmap(f))
+	assert.NoError(t, f.Close())
+
+	mapped, err := OpenBushMmap(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, bush.Idxs, mapped.Idxs)
+	assert.Equal(t, bush.Coords, mapped.Coords)
+	assert.Equal(t, bush.Stats(), mapped.Stats())
+	assert.NoError(t, mapped.Verify())
+	q := &SimplePoint{X: 500, Y: 500}
+	assert.Equal(t, bush.Range(100, 100, 400, 300), mapped.Range(100, 100, 400, 300))
+	assert.Equal(t, bush.Nearest(q, 10, 0), mapped.Nearest(q, 10, 0))
+	assert.Equal(t, 7, mapped.TreePos(bush.Idxs[7]))
+	assert.True(t, mapped.IsRemoved(5))
+
+	//tombstones are in memory
+	assert.True(t, mapped.Remove(6))
+	assert.NoError(t, mapped.Close())
+	assert.NoError(t, mapped.Close())
+
+	for _, data := range [][]byte{{}, []byte("KDBM"), []byte("KDBG12345678")} {
+		bad := filepath.Join(t.TempDir(), "bad")
+		assert.NoError(t, os.WriteFile(bad, data, 0644))
+		_, err := OpenBushMmap(bad)
+		assert.ErrorIs(t, err, ErrInvalidFormat)
+	}
+
+	//truncated file
+	var buf bytes.Buffer
+	assert.NoError(t, bush.SaveMmap(&buf))
+	truncated := filepath.Join(t.TempDir(), "truncated")
+	assert.NoError(t, os.WriteFile(truncated, buf.Bytes()[:buf.Len()/2], 0644))
+	_, err = OpenBushMmap(truncated)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	//corrupted positions
+	for _, pos := range []uint64{1 << 40, 3} {
+		corrupted := filepath.Join(t.TempDir(), "corrupted")
+		assert.NoError(t, os.WriteFile(corrupted, corruptMmapPos(buf.Bytes(), bush.Idxs[7], pos), 0644))
+		_, err = OpenBushMmap(corrupted)
+		assert.ErrorIs(t, err, ErrInvalidFormat)
+	}
+}
+
+// returns copy of the data, written by SaveMmap, with tree position of the original index replaced by pos
+func corruptMmapPos(data []byte, idx int, pos uint64) []byte {
+	corrupted := append([]byte(nil), data...)
+	count := binary.LittleEndian.Uint64(corrupted[16:])
+	off := mmapHeaderSize + 8*(3*int(count)+idx)
+	binary.LittleEndian.PutUint64(corrupted[off:], pos)
+	return corrupted
+}
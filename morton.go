@@ -0,0 +1,70 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Maximum precision per axis for Morton keys, two axes fit into uint64
+const MaxMortonBits = 32
+
+// Computes Morton (Z-order) key for every point, normalized to the bounding box of the indexed points.
+// Returns slice of keys, where key i belongs to the point i of the original points input slice.
+// Input:
+// precisionBits - number of bits per axis, from 1 to 32. Values out of this range are clamped.
+func (bush *KDBush) MortonKeys(precisionBits int) []uint64 {
+	b := bush.bbox
+	return bush.MortonKeysIn(precisionBits, b[0], b[1], b[2], b[3])
+}
+
+// Same as MortonKeys, but normalizes coordinates to the given extent instead of the bounding box of the points.
+// Use it when keys should be stable between different builds, e.g. -180, -90, 180, 90 for lng/lat data.
+// Coordinates outside of the extent are clamped to it.
+func (bush *KDBush) MortonKeysIn(precisionBits int, minX, minY, maxX, maxY float64) []uint64 {
+	precisionBits = iMax(1, iMin(MaxMortonBits, precisionBits))
+	cells := float64(uint64(1)<<uint(precisionBits)) - 1
+
+	keys := make([]uint64, bush.originalCount())
+	for i := 0; i < bush.size(); i++ {
+		idx := bush.idx(i)
+		x := quantize(bush.x(i), minX, maxX, cells)
+		y := quantize(bush.y(i), minY, maxY, cells)
+		keys[idx] = interleave(x) | interleave(y)<<1
+	}
+	return keys
+}
+
+// maps value from [min, max] to [0, cells]
+func quantize(v, min, max, cells float64) uint32 {
+	if max <= min {
+		return 0
+	}
+	t := (v - min) / (max - min)
+	t = math.Max(0, math.Min(1, t))
+	return uint32(math.Floor(t * cells))
+}
+
+// spreads lower 32 bits of v to even bits of result
+func interleave(v uint32) uint64 {
+	x := uint64(v)
+	x = (x | x<<16) & 0x0000FFFF0000FFFF
+	x = (x | x<<8) & 0x00FF00FF00FF00FF
+	x = (x | x<<4) & 0x0F0F0F0F0F0F0F0F
+	x = (x | x<<2) & 0x3333333333333333
+	x = (x | x<<1) & 0x5555555555555555
+	return x
+}
+
+func coordsBounds(coords []float64) (minX, minY, maxX, maxY float64) {
+	if len(coords) == 0 {
+		return 0, 0, 0, 0
+	}
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for i := 0; i < len(coords); i += 2 {
+		minX = math.Min(minX, coords[i])
+		minY = math.Min(minY, coords[i+1])
+		maxX = math.Max(maxX, coords[i])
+		maxY = math.Max(maxY, coords[i+1])
+	}
+	return minX, minY, maxX, maxY
+}
@@ -0,0 +1,39 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_MortonKeys(t *testing.T) {
+	points := []Point{
+		&SimplePoint{X: 0, Y: 0},
+		&SimplePoint{X: 1, Y: 0},
+		&SimplePoint{X: 0, Y: 1},
+		&SimplePoint{X: 1, Y: 1},
+	}
+	bush := NewBush(points, 10)
+
+	assert.Equal(t, []uint64{0, 1, 2, 3}, bush.MortonKeys(1))
+	assert.Equal(t, []uint64{0, 5, 10, 15}, bush.MortonKeys(2))
+}
+
+func TestKDBush_MortonKeysIn(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	keys := bush.MortonKeysIn(32, 0, 0, 100, 100)
+	assert.Len(t, keys, len(testPoints))
+
+	//keys should keep z-order of the quadrants
+	q := func(k uint64) uint64 { return k >> 62 }
+	for i, p := range testPoints {
+		expected := uint64(0)
+		if p[0] > 50 {
+			expected |= 1
+		}
+		if p[1] > 50 {
+			expected |= 2
+		}
+		assert.Equal(t, expected, q(keys[i]), "point %v", p)
+	}
+}
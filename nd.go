@@ -0,0 +1,199 @@
+package kdbush
+
+import (
+	"fmt"
+	"math"
+)
+
+// Interface, that should be implemented by points of N-dimensional index, e.g. x, y, time or x, y, z
+type PointND interface {
+	CoordinatesND() []float64
+}
+
+// Minimal struct, that implements PointND interface
+type SimplePointND []float64
+
+func (p SimplePointND) CoordinatesND() []float64 {
+	return p
+}
+
+// Static KD-tree index for N-dimensional points, the same flat layout as KDBush, split axis cycles through all dimensions
+type KDBushND struct {
+	NodeSize int
+	Dims     int
+	Points   []PointND
+
+	Idxs   []int     //array of indexes
+	Coords []float64 //array of coordinates, Dims values per point
+}
+
+// Creates new index from N-dimensional points, every point should have exactly dims coordinates
+func NewBushND(points []PointND, dims, nodeSize int) (*KDBushND, error) {
+	if dims < 1 {
+		return nil, fmt.Errorf("kdbush: invalid number of dimensions %d", dims)
+	}
+	b := &KDBushND{NodeSize: nodeSize, Dims: dims, Points: points}
+	b.Idxs = make([]int, len(points))
+	b.Coords = make([]float64, 0, dims*len(points))
+	for i, p := range points {
+		c := p.CoordinatesND()
+		if len(c) != dims {
+			return nil, fmt.Errorf("kdbush: item %d has %d coordinates, expected %d", i, len(c), dims)
+		}
+		b.Idxs[i] = i
+		b.Coords = append(b.Coords, c...)
+	}
+	b.sort(0, len(b.Idxs)-1, 0)
+	return b, nil
+}
+
+// Finds all items within the box from min to max corners inclusive, and returns an array of indices
+func (bush *KDBushND) RangeND(min, max []float64) []int {
+	result := []int{}
+	bush.search(min, max, func(i int) {
+		if bush.inBox(i, min, max) {
+			result = append(result, bush.Idxs[i])
+		}
+	})
+	return result
+}
+
+// Finds all items within a given radius from the query point and returns an array of indices
+func (bush *KDBushND) WithinND(point []float64, radius float64) []int {
+	result := []int{}
+	min := make([]float64, bush.Dims)
+	max := make([]float64, bush.Dims)
+	for d := range min {
+		min[d], max[d] = point[d]-radius, point[d]+radius
+	}
+	r2 := radius * radius
+	bush.search(min, max, func(i int) {
+		if bush.distSq(i, point) <= r2 {
+			result = append(result, bush.Idxs[i])
+		}
+	})
+	return result
+}
+
+// visits all points of the nodes intersecting the box, points should be checked by visit
+func (bush *KDBushND) search(min, max []float64, visit func(i int)) {
+	dims := bush.Dims
+	stack := []int{0, len(bush.Idxs) - 1, 0}
+	for len(stack) > 0 {
+		axis := stack[len(stack)-1]
+		right := stack[len(stack)-2]
+		left := stack[len(stack)-3]
+		stack = stack[:len(stack)-3]
+
+		if right-left <= bush.NodeSize {
+			for i := left; i <= right; i++ {
+				visit(i)
+			}
+			continue
+		}
+
+		m := floor(float64(left+right) / 2.0)
+		visit(m)
+
+		v := bush.Coords[dims*m+axis]
+		nextAxis := (axis + 1) % dims
+		if min[axis] <= v {
+			stack = append(stack, left, m-1, nextAxis)
+		}
+		if max[axis] >= v {
+			stack = append(stack, m+1, right, nextAxis)
+		}
+	}
+}
+
+func (bush *KDBushND) inBox(i int, min, max []float64) bool {
+	for d := 0; d < bush.Dims; d++ {
+		if v := bush.Coords[bush.Dims*i+d]; v < min[d] || v > max[d] {
+			return false
+		}
+	}
+	return true
+}
+
+func (bush *KDBushND) distSq(i int, point []float64) float64 {
+	sum := 0.0
+	for d := 0; d < bush.Dims; d++ {
+		delta := bush.Coords[bush.Dims*i+d] - point[d]
+		sum += delta * delta
+	}
+	return sum
+}
+
+func (bush *KDBushND) sort(left, right, axis int) {
+	if right-left <= bush.NodeSize {
+		return
+	}
+	m := floor(float64(left+right) / 2.0)
+	bush.sselect(m, left, right, axis)
+	bush.sort(left, m-1, (axis+1)%bush.Dims)
+	bush.sort(m+1, right, (axis+1)%bush.Dims)
+}
+
+// the same Floyd-Rivest selection, as for 2D index, with Dims coordinates per point
+func (bush *KDBushND) sselect(k, left, right, axis int) {
+	dims := bush.Dims
+	c := bush.Coords
+	for right > left {
+		if right-left > 600 {
+			n := right - left + 1
+			m := k - left + 1
+			z := math.Log(float64(n))
+			s := 0.5 * math.Exp(2.0*z/3.0)
+			sds := 1.0
+			if float64(m)-float64(n)/2.0 < 0 {
+				sds = -1.0
+			}
+			sd := 0.5 * math.Sqrt(z*s*(float64(n)-s)/float64(n)) * sds
+			newLeft := iMax(left, floor(float64(k)-float64(m)*s/float64(n)+sd))
+			newRight := iMin(right, floor(float64(k)+float64(n-m)*s/float64(n)+sd))
+			bush.sselect(k, newLeft, newRight, axis)
+		}
+
+		t := c[dims*k+axis]
+		i := left
+		j := right
+
+		bush.swap(left, k)
+		if c[dims*right+axis] > t {
+			bush.swap(left, right)
+		}
+
+		for i < j {
+			bush.swap(i, j)
+			i++
+			j--
+			for c[dims*i+axis] < t {
+				i++
+			}
+			for c[dims*j+axis] > t {
+				j--
+			}
+		}
+
+		if c[dims*left+axis] == t {
+			bush.swap(left, j)
+		} else {
+			j++
+			bush.swap(j, right)
+		}
+
+		if j <= k {
+			left = j + 1
+		}
+		if k <= j {
+			right = j - 1
+		}
+	}
+}
+
+func (bush *KDBushND) swap(i, j int) {
+	swapi(bush.Idxs, i, j)
+	for d := 0; d < bush.Dims; d++ {
+		swapf(bush.Coords, bush.Dims*i+d, bush.Dims*j+d)
+	}
+}
@@ -0,0 +1,74 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomPointsND(n, dims int) []PointND {
+	rnd := rand.New(rand.NewSource(3))
+	points := make([]PointND, n)
+	for i := range points {
+		p := make(SimplePointND, dims)
+		for d := range p {
+			p[d] = float64(rnd.Intn(100))
+		}
+		points[i] = p
+	}
+	return points
+}
+
+func TestKDBushND_2DIsTheSame(t *testing.T) {
+	points := make([]PointND, len(testPoints))
+	for i, p := range testPoints {
+		points[i] = SimplePointND{p[0], p[1]}
+	}
+	bush, err := NewBushND(points, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+}
+
+func TestKDBushND_RangeND(t *testing.T) {
+	points := randomPointsND(3000, 3)
+	bush, err := NewBushND(points, 3, 16)
+	assert.NoError(t, err)
+
+	min, max := []float64{10, 20, 30}, []float64{50, 60, 40}
+	expected := []int{}
+	for i, p := range points {
+		c := p.CoordinatesND()
+		if c[0] >= min[0] && c[0] <= max[0] && c[1] >= min[1] && c[1] <= max[1] && c[2] >= min[2] && c[2] <= max[2] {
+			expected = append(expected, i)
+		}
+	}
+	assert.NotEmpty(t, expected)
+	assert.Equal(t, expected, sortedInts(bush.RangeND(min, max)))
+}
+
+func TestKDBushND_WithinND(t *testing.T) {
+	points := randomPointsND(3000, 4)
+	bush, err := NewBushND(points, 4, 16)
+	assert.NoError(t, err)
+
+	q := []float64{50, 50, 50, 50}
+	expected := []int{}
+	for i, p := range points {
+		sum := 0.0
+		for d, v := range p.CoordinatesND() {
+			sum += (v - q[d]) * (v - q[d])
+		}
+		if sum <= 30*30 {
+			expected = append(expected, i)
+		}
+	}
+	assert.NotEmpty(t, expected)
+	assert.Equal(t, expected, sortedInts(bush.WithinND(q, 30)))
+
+	_, err = NewBushND([]PointND{SimplePointND{1, 2}, SimplePointND{1}}, 2, 10)
+	assert.Error(t, err)
+	_, err = NewBushND(nil, 0, 10)
+	assert.Error(t, err)
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package kdbush
+
+const raceEnabled = false
@@ -0,0 +1,252 @@
+package kdbush
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime/debug"
+)
+
+// Option, that changes the way index is built
+type Option func(*buildConfig)
+
+type buildConfig struct {
+	nodeSize      int
+	sortThreshold int
+	workers       int
+
+	bounded                bool
+	drop                   bool
+	minX, minY, maxX, maxY float64
+
+	presorted     bool
+	hilbertLeaves bool
+	nodeBounds    bool
+	dedup         bool
+	skipInvalid   bool
+	compact       bool
+	spreadSplit   bool
+	copyPoints    bool
+	clonePoint    func(p Point) Point
+	projection    Projection
+
+	hooks []func(allocBytes int64) (restore func())
+}
+
+func newBuildConfig(opts []Option) *buildConfig {
+	cfg := &buildConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Default node size of New
+const DefaultNodeSize = 64
+
+// Returned by New for invalid options
+var ErrInvalidOption = errors.New("kdbush: invalid option")
+
+// Creates new index from points, node size and other parameters are set by options, see DefaultNodeSize.
+// Returns ErrInvalidOption error for invalid configuration, e.g. node size < 1.
+func New(points []Point, opts ...Option) (*KDBush, error) {
+	cfg := &buildConfig{nodeSize: DefaultNodeSize, sortThreshold: DefaultSortThreshold, workers: 1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	b := KDBush{}
+	b.buildIndex(points, cfg.nodeSize, cfg)
+	return &b, nil
+}
+
+func (cfg *buildConfig) validate() error {
+	switch {
+	case cfg.nodeSize < 1:
+		return fmt.Errorf("%w: node size %d, should be at least 1", ErrInvalidOption, cfg.nodeSize)
+	case cfg.sortThreshold < 1:
+		return fmt.Errorf("%w: sort threshold %d, should be at least 1", ErrInvalidOption, cfg.sortThreshold)
+	case cfg.workers < 1:
+		return fmt.Errorf("%w: %d build workers, should be at least 1", ErrInvalidOption, cfg.workers)
+	case cfg.bounded && !(cfg.minX <= cfg.maxX && cfg.minY <= cfg.maxY):
+		return fmt.Errorf("%w: empty bounds %v, %v, %v, %v", ErrInvalidOption, cfg.minX, cfg.minY, cfg.maxX, cfg.maxY)
+	}
+	return nil
+}
+
+// Size of the KD-tree node, DefaultNodeSize by default. Higher means faster indexing but slower search, and vise versa.
+// Overrides nodeSize argument of NewBushWithOptions.
+func WithNodeSize(n int) Option {
+	return func(cfg *buildConfig) {
+		cfg.nodeSize = n
+	}
+}
+
+// Selection of medians samples subarrays longer than n points to choose pivots (Floyd-Rivest), DefaultSortThreshold by default.
+func WithSortThreshold(n int) Option {
+	return func(cfg *buildConfig) {
+		cfg.sortThreshold = n
+	}
+}
+
+// Sorts independent subtrees by up to workers goroutines, 1 means sequential build.
+// The index is the same as of sequential build.
+func WithParallelBuild(workers int) Option {
+	return func(cfg *buildConfig) {
+		cfg.workers = workers
+	}
+}
+
+// Clamps coordinates of the points to the given bounds at build, e.g. -180, -90, 180, 90 for lng/lat data.
+// Points with NaN coordinates can't be clamped and are dropped.
+// Number of clamped and dropped points is reported in Stats.
+func WithClampBounds(minX, minY, maxX, maxY float64) Option {
+	return func(cfg *buildConfig) {
+		cfg.bounded, cfg.drop = true, false
+		cfg.minX, cfg.minY, cfg.maxX, cfg.maxY = minX, minY, maxX, maxY
+	}
+}
+
+// Drops points with coordinates out of the given bounds at build, they are never returned by queries.
+// Number of dropped points is reported in Stats.
+func WithDropOutOfBounds(minX, minY, maxX, maxY float64) Option {
+	return func(cfg *buildConfig) {
+		cfg.bounded, cfg.drop = true, true
+		cfg.minX, cfg.minY, cfg.maxX, cfg.maxY = minX, minY, maxX, maxY
+	}
+}
+
+// Splits every node by the axis with the largest spread of its points instead of alternating x and y,
+// which keeps nodes closer to square on anisotropic data, e.g. long thin coastlines, so queries visit fewer nodes.
+// The chosen axes take one byte per point. Build scans every subtree once more to measure the spread.
+func WithSpreadSplit() Option {
+	return func(cfg *buildConfig) {
+		cfg.spreadSplit = true
+	}
+}
+
+// Copies the points slice at build, so the caller could sort or reuse its slice, and Points of the index
+// still match Idxs. clone is optional, it's applied to every point to copy point values too,
+// e.g. for pointers to structs, that the caller changes later. Nil points are not cloned.
+// For TypedBush clone should return points of the same type.
+func WithCopyPoints(clone func(p Point) Point) Option {
+	return func(cfg *buildConfig) {
+		cfg.copyPoints, cfg.clonePoint = true, clone
+	}
+}
+
+// returns points, copied if WithCopyPoints option is set
+func copyPoints[T Point](points []T, cfg *buildConfig) []T {
+	if !cfg.copyPoints {
+		return points
+	}
+	copied := make([]T, len(points))
+	for i, p := range points {
+		if cfg.clonePoint != nil && Point(p) != nil {
+			p = cfg.clonePoint(p).(T)
+		}
+		copied[i] = p
+	}
+	return copied
+}
+
+// Tells, that points are already in KD-order for the node size, as returned by SpatialOrder, so sorting is skipped.
+// The order is verified, and the points are sorted as usual if it's not valid.
+func WithPresorted() Option {
+	return func(cfg *buildConfig) {
+		cfg.presorted = true
+	}
+}
+
+// Memory in bytes allocated by build of the index from n points: ids, coordinates and the inverse of ids.
+// Input points and build options, that keep extra data, are not counted.
+func BuildBytes(n int) int64 {
+	return int64(n) * (8 + 16 + 8)
+}
+
+// Calls hook before the build with number of bytes the build is going to allocate (see BuildBytes),
+// restore function returned by the hook, if not nil, is called when the build is done.
+// Could be used to allocate memory ballast, tune GC or log progress of huge builds.
+func WithBuildHook(hook func(allocBytes int64) (restore func())) Option {
+	return func(cfg *buildConfig) {
+		cfg.hooks = append(cfg.hooks, hook)
+	}
+}
+
+// Sets GC percent with debug.SetGCPercent for the time of the build and restores previous value afterwards,
+// e.g. -1 disables GC during the build. GC percent is global, so concurrent builds with this option are not recommended.
+func WithGCPercent(percent int) Option {
+	return WithBuildHook(func(int64) func() {
+		prev := debug.SetGCPercent(percent)
+		return func() { debug.SetGCPercent(prev) }
+	})
+}
+
+// runs build hooks, returns function, that restores everything hooks changed in reverse order
+func (cfg *buildConfig) runHooks(n int) func() {
+	restores := make([]func(), 0, len(cfg.hooks))
+	for _, hook := range cfg.hooks {
+		if restore := hook(BuildBytes(n)); restore != nil {
+			restores = append(restores, restore)
+		}
+	}
+	return func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+}
+
+// Orders points inside of every leaf along Hilbert curve of the bounding box of all points.
+// Only the order within leaves is affected: the tree is still built by median splits, it's not a Hilbert-packed layout.
+// Consecutive points of every leaf are close in space, which improves cache locality of range scans of adjacent areas.
+// Order of query results changes accordingly.
+func WithHilbertLeafOrder() Option {
+	return func(cfg *buildConfig) {
+		cfg.hilbertLeaves = true
+	}
+}
+
+// applies bounds option to the point, returns fixed coordinates and false if the point should be dropped
+func (cfg *buildConfig) bound(bush *KDBush, x, y float64) (float64, float64, bool) {
+	if x >= cfg.minX && x <= cfg.maxX && y >= cfg.minY && y <= cfg.maxY {
+		return x, y, true
+	}
+	if cfg.drop || math.IsNaN(x) || math.IsNaN(y) {
+		bush.dropped++
+		return x, y, false
+	}
+	bush.clamped++
+	return math.Max(cfg.minX, math.Min(cfg.maxX, x)), math.Max(cfg.minY, math.Min(cfg.maxY, y)), true
+}
+
+// Same as NewBush, but accepts build options
+func NewBushWithOptions(points []Point, nodeSize int, opts ...Option) *KDBush {
+	b := KDBush{}
+	b.buildIndex(points, nodeSize, newBuildConfig(opts))
+	return &b
+}
+
+// Statistics of the index
+type Stats struct {
+	Input     int //number of points in the original input
+	Points    int //number of points in the index
+	Removed   int //number of points marked as removed
+	Clamped   int //number of points with coordinates clamped at build
+	Dropped   int //number of points dropped at build
+	Collapsed int //number of duplicates collapsed into their representatives at build, see WithDedup
+}
+
+// Returns statistics of the index
+func (bush *KDBush) Stats() Stats {
+	return Stats{
+		Input:     bush.originalCount(),
+		Points:    bush.size(),
+		Removed:   bush.removedCount,
+		Clamped:   bush.clamped,
+		Dropped:   bush.dropped,
+		Collapsed: bush.collapsed,
+	}
+}
@@ -0,0 +1,240 @@
+package kdbush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dirtyPoints() []Point {
+	return []Point{
+		&SimplePoint{X: 10, Y: 10},
+		&SimplePoint{X: 200, Y: 50},
+		&SimplePoint{X: -20, Y: 91},
+		&SimplePoint{X: math.NaN(), Y: 10},
+		&SimplePoint{X: 170, Y: -80},
+	}
+}
+
+func TestWithClampBounds(t *testing.T) {
+	bush := NewBushWithOptions(dirtyPoints(), 10, WithClampBounds(-180, -90, 180, 90))
+
+	assert.Equal(t, Stats{Input: 5, Points: 4, Clamped: 2, Dropped: 1}, bush.Stats())
+	assert.Equal(t, []int{1}, bush.Range(180, 50, 180, 50))
+	assert.Equal(t, []int{2}, bush.Range(-20, 90, -20, 90))
+	assert.Equal(t, []int{0, 1, 2, 4}, sortedInts(bush.Range(-180, -90, 180, 90)))
+}
+
+func TestWithDropOutOfBounds(t *testing.T) {
+	bush := NewBushWithOptions(dirtyPoints(), 10, WithDropOutOfBounds(-180, -90, 180, 90))
+
+	assert.Equal(t, Stats{Input: 5, Points: 2, Dropped: 3}, bush.Stats())
+	assert.Equal(t, []int{0, 4}, sortedInts(bush.Range(-1000, -1000, 1000, 1000)))
+	assert.Len(t, bush.MortonKeys(16), 5)
+}
+
+func sortedInts(a []int) []int {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j] < a[j-1]; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+	return a
+}
+
+func TestWithBuildHook(t *testing.T) {
+	calls := []string{}
+	var allocated int64
+	bush := NewBushWithOptions(getTestPoints(), 10,
+		WithBuildHook(func(allocBytes int64) func() {
+			allocated = allocBytes
+			calls = append(calls, "first")
+			return func() { calls = append(calls, "restore first") }
+		}),
+		WithBuildHook(func(int64) func() {
+			calls = append(calls, "second")
+			return nil
+		}))
+	assert.Equal(t, []string{"first", "second", "restore first"}, calls)
+	assert.Equal(t, BuildBytes(len(testPoints)), allocated)
+	assert.Equal(t, bush.MemoryUsage(), allocated)
+}
+
+func TestWithGCPercent(t *testing.T) {
+	prev := debug.SetGCPercent(150)
+	defer debug.SetGCPercent(prev)
+
+	bush := NewBushWithOptions(getTestPoints(), 10, WithGCPercent(-1))
+	assert.Len(t, bush.Idxs, len(testPoints))
+	assert.Equal(t, 150, debug.SetGCPercent(150))
+}
+
+func TestNew(t *testing.T) {
+	bush, err := New(getTestPoints(), WithNodeSize(10))
+	if assert.NoError(t, err) {
+		assert.Equal(t, testIdxs, bush.Idxs)
+		assert.Equal(t, testCoords, bush.Coords)
+	}
+
+	bush, err = New(getTestPoints())
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultNodeSize, bush.NodeSize)
+	}
+
+	for _, opt := range []Option{WithNodeSize(0), WithNodeSize(-5), WithSortThreshold(0), WithParallelBuild(0), WithClampBounds(10, 0, 0, 10)} {
+		_, err = New(getTestPoints(), opt)
+		assert.ErrorIs(t, err, ErrInvalidOption)
+	}
+}
+
+func TestWithParallelBuild(t *testing.T) {
+	points := benchmarkPoints()
+	sequential, err := New(points, WithNodeSize(16))
+	assert.NoError(t, err)
+	for _, opts := range [][]Option{
+		{WithNodeSize(16), WithParallelBuild(4)},
+		{WithNodeSize(16), WithParallelBuild(3), WithSortThreshold(100)},
+	} {
+		bush, err := New(points, opts...)
+		if assert.NoError(t, err) {
+			assert.NoError(t, bush.Verify())
+			assert.Equal(t, sortedInts(sequential.Range(100, 100, 300, 400)), sortedInts(bush.Range(100, 100, 300, 400)))
+		}
+	}
+	parallel, _ := New(points, WithNodeSize(16), WithParallelBuild(4))
+	assert.Equal(t, sequential.Idxs, parallel.Idxs)
+}
+
+func TestWithSpreadSplit(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	points := make([]Point, 20000)
+	for i := range points {
+		//thin strip along a coastline, 1000 x 2
+		points[i] = &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 2}
+	}
+	plain := NewBushWithOptions(points, 8)
+	bush := NewBushWithOptions(points, 8, WithSpreadSplit())
+	assert.NoError(t, bush.Verify())
+	assert.Equal(t, len(bush.Idxs), len(bush.splitAxes))
+
+	q := &SimplePoint{X: 500, Y: 1}
+	expected, plainInfo := plain.RangeInfo(400, 0.5, 401, 1.5)
+	result, info := bush.RangeInfo(400, 0.5, 401, 1.5)
+	assert.Equal(t, sortedInts(expected), sortedInts(result))
+	assert.Less(t, info.NodesVisited, plainInfo.NodesVisited)
+	assert.Equal(t, sortedInts(plain.Within(q, 3)), sortedInts(bush.Within(q, 3)))
+	assert.Equal(t, plain.Nearest(q, 30, 0), bush.Nearest(q, 30, 0))
+	assert.Equal(t, plain.RangeCount(100, 0, 300, 1), bush.RangeCount(100, 0, 300, 1))
+	strip, _ := plain.Query(Circle{X: 700, Y: 1, Radius: 5})
+	spread, _ := bush.Query(Circle{X: 700, Y: 1, Radius: 5})
+	assert.Equal(t, sortedInts(strip), sortedInts(spread))
+
+	var buf bytes.Buffer
+	assert.NoError(t, bush.Save(&buf))
+	loaded, err := Load(&buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, bush.splitAxes, loaded.splitAxes)
+		assert.NoError(t, loaded.Verify())
+		assert.Equal(t, bush.Nearest(q, 30, 0), loaded.Nearest(q, 30, 0))
+	}
+
+	buf.Reset()
+	assert.NoError(t, bush.SaveMmap(&buf))
+	if mapped, err := OpenBushBytes(buf.Bytes()); err != ErrMmapUnsupported && assert.NoError(t, err) {
+		assert.Equal(t, bush.splitAxes, mapped.splitAxes)
+		assert.Equal(t, sortedInts(result), sortedInts(mapped.Range(400, 0.5, 401, 1.5)))
+	}
+
+	data, err := json.Marshal(bush)
+	assert.NoError(t, err)
+	var decoded KDBush
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.Equal(t, bush.splitAxes, decoded.splitAxes)
+		assert.NoError(t, decoded.Verify())
+	}
+
+	_, err = bush.Marshal()
+	assert.Error(t, err)
+}
+
+func TestWithSpreadSplit_InvalidAxes(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	points := make([]Point, 1000)
+	for i := range points {
+		points[i] = &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 2}
+	}
+	bush := NewBushWithOptions(points, 8, WithSpreadSplit())
+	n := len(bush.Idxs)
+	bad := bytes.Repeat([]byte{9}, n)
+
+	//version 2 has no checksum, axes are at the end
+	var buf bytes.Buffer
+	assert.NoError(t, bush.Save(&buf))
+	data := buf.Bytes()
+	v2 := append([]byte("KDBG\x02"), data[8:len(data)-4]...)
+	copy(v2[len(v2)-n:], bad)
+	_, err := Load(bytes.NewReader(v2))
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	buf.Reset()
+	assert.NoError(t, bush.SaveMmap(&buf))
+	mmapData := buf.Bytes()
+	copy(mmapData[mmapHeaderSize+8*(4*n+1):], bad)
+	if _, err := OpenBushBytes(mmapData); err != ErrMmapUnsupported {
+		assert.ErrorIs(t, err, ErrInvalidFormat)
+	}
+
+	var j map[string]interface{}
+	jsonData, _ := json.Marshal(bush)
+	assert.NoError(t, json.Unmarshal(jsonData, &j))
+	j["splitAxes"] = bad
+	jsonData, _ = json.Marshal(j)
+	var decoded KDBush
+	assert.ErrorIs(t, json.Unmarshal(jsonData, &decoded), ErrInvalidFormat)
+
+	bush.splitAxes[n/2] = 9
+	assert.ErrorIs(t, bush.Verify(), ErrCorrupted)
+	assert.ErrorIs(t, bush.verifyIncremental(context.Background(), 0), ErrCorrupted)
+}
+
+func TestWithCopyPoints(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBushWithOptions(points, 10, WithCopyPoints(nil))
+	q := &SimplePoint{X: 50, Y: 50}
+	expected := bush.Nearest(q, 5, 0)
+	first := bush.Points[expected[0]]
+
+	//caller reorders its slice after build
+	points[0], points[expected[0]] = points[expected[0]], points[0]
+	assert.Same(t, first, bush.Points[expected[0]])
+
+	//values are shared without clone, and copied with it
+	clone := func(p Point) Point {
+		c := *p.(*SimplePoint)
+		return &c
+	}
+	cloned := NewBushWithOptions(points, 10, WithCopyPoints(clone))
+	shared := NewBushWithOptions(points, 10, WithCopyPoints(nil))
+	points[3].(*SimplePoint).X = -1000
+	assert.Equal(t, -1000.0, shared.Points[3].(*SimplePoint).X)
+	assert.NotEqual(t, -1000.0, cloned.Points[3].(*SimplePoint).X)
+	x, y := cloned.Points[3].Coordinates()
+	assert.Equal(t, cloned.Coords[2*cloned.TreePos(3)], x)
+	assert.Equal(t, cloned.Coords[2*cloned.TreePos(3)+1], y)
+
+	typed := NewTypedBush(points, 10, WithCopyPoints(clone))
+	assert.NotSame(t, points[5], typed.Points[5])
+	assert.Equal(t, *points[5].(*SimplePoint), *typed.Points[5].(*SimplePoint))
+
+	safe, err := NewBushSafe(append(points, nil), 10, WithCopyPoints(clone), WithSkipInvalid())
+	if assert.NoError(t, err) {
+		assert.Nil(t, safe.Points[len(points)])
+		assert.NotSame(t, points[5], safe.Points[5])
+	}
+}
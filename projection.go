@@ -0,0 +1,209 @@
+package kdbush
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Transformation between lng, lat degrees and planar coordinates
+type Projection interface {
+	Forward(lng, lat float64) (x, y float64)
+	Inverse(x, y float64) (lng, lat float64)
+}
+
+// Radius of WGS84 ellipsoid, used by WebMercator
+const webMercatorRadius = 6378137
+
+// Latitude limit of WebMercator, the map is a square
+const webMercatorMaxLat = 85.0511287798066
+
+// EPSG:3857 projection, the one of web maps, coordinates are in meters
+var WebMercator Projection = webMercator{}
+
+type webMercator struct{}
+
+func (webMercator) Forward(lng, lat float64) (float64, float64) {
+	lat = math.Max(-webMercatorMaxLat, math.Min(webMercatorMaxLat, lat))
+	return webMercatorRadius * lng * rad, webMercatorRadius * math.Log(math.Tan(math.Pi/4+lat*rad/2))
+}
+
+func (webMercator) Inverse(x, y float64) (float64, float64) {
+	return x / webMercatorRadius / rad, (2*math.Atan(math.Exp(y/webMercatorRadius)) - math.Pi/2) / rad
+}
+
+// UTM zone projection on WGS84 ellipsoid, coordinates are easting and northing in meters.
+// Uses Krüger series, error is below a millimeter within the zone and grows slowly outside of it.
+type UTM struct {
+	Zone  int  //from 1 to 60
+	North bool //northern hemisphere, false adds 10000 km false northing
+}
+
+// Returns UTM projection for the zone with the point, not counting Norway and Svalbard exceptions
+func UTMZoneOf(lng, lat float64) UTM {
+	zone := int(math.Floor((normLng(lng)+180)/6)) + 1
+	return UTM{Zone: iMin(60, zone), North: lat >= 0}
+}
+
+// WGS84 constants of Krüger series
+var utmN, utmA, utmAlpha, utmBeta, utmDelta = func() (float64, float64, [3]float64, [3]float64, [3]float64) {
+	f := 1 / 298.257223563
+	n := f / (2 - f)
+	n2, n3 := n*n, n*n*n
+	a := webMercatorRadius / (1 + n) * (1 + n2/4 + n2*n2/64)
+	alpha := [3]float64{n/2 - 2*n2/3 + 5*n3/16, 13*n2/48 - 3*n3/5, 61 * n3 / 240}
+	beta := [3]float64{n/2 - 2*n2/3 + 37*n3/96, n2/48 + n3/15, 17 * n3 / 480}
+	delta := [3]float64{2*n - 2*n2/3 - 2*n3, 7*n2/3 - 8*n3/5, 56 * n3 / 15}
+	return n, a, alpha, beta, delta
+}()
+
+const (
+	utmScale    = 0.9996
+	utmEasting  = 500e3
+	utmNorthing = 10000e3
+)
+
+// longitude of the central meridian of the zone in degrees
+func (u UTM) centralMeridian() float64 {
+	return float64(u.Zone*6 - 183)
+}
+
+func (u UTM) Forward(lng, lat float64) (float64, float64) {
+	phi, dl := lat*rad, normLng(lng-u.centralMeridian())*rad
+	c := 2 * math.Sqrt(utmN) / (1 + utmN)
+	t := math.Sinh(math.Atanh(math.Sin(phi)) - c*math.Atanh(c*math.Sin(phi)))
+	xi := math.Atan2(t, math.Cos(dl))
+	eta := math.Atanh(math.Sin(dl) / math.Sqrt(1+t*t))
+
+	e, n := eta, xi
+	for j, a := range utmAlpha {
+		k := 2 * float64(j+1)
+		e += a * math.Cos(k*xi) * math.Sinh(k*eta)
+		n += a * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+	x, y := utmEasting+utmScale*utmA*e, utmScale*utmA*n
+	if !u.North {
+		y += utmNorthing
+	}
+	return x, y
+}
+
+func (u UTM) Inverse(x, y float64) (float64, float64) {
+	if !u.North {
+		y -= utmNorthing
+	}
+	xi := y / (utmScale * utmA)
+	eta := (x - utmEasting) / (utmScale * utmA)
+	xi1, eta1 := xi, eta
+	for j, b := range utmBeta {
+		k := 2 * float64(j+1)
+		xi1 -= b * math.Sin(k*xi) * math.Cosh(k*eta)
+		eta1 -= b * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	chi := math.Asin(math.Sin(xi1) / math.Cosh(eta1))
+	phi := chi
+	for j, d := range utmDelta {
+		phi += d * math.Sin(2*float64(j+1)*chi)
+	}
+	return normLng(u.centralMeridian() + math.Atan2(math.Sinh(eta1), math.Cos(xi1))/rad), phi / rad
+}
+
+func (u UTM) String() string {
+	hemisphere := "N"
+	if !u.North {
+		hemisphere = "S"
+	}
+	return fmt.Sprintf("UTM %d%s", u.Zone, hemisphere)
+}
+
+// Treats coordinates of the points as lng, lat degrees and projects them at build.
+// The index keeps the projection, see Projection method and FromLngLat query option.
+// Built-in projections are also kept by Save, SaveMmap and MarshalJSON, custom ones make them fail.
+func WithProjection(p Projection) Option {
+	return func(cfg *buildConfig) {
+		cfg.projection = p
+	}
+}
+
+// Returns projection, the index was built with, nil if coordinates were not transformed
+func (bush *KDBush) Projection() Projection {
+	return bush.projection
+}
+
+// Query coordinates of RangeWithOptions box and WithinWithOptions center are lng, lat degrees,
+// they are projected with the projection of the index. Radius of Within is in projected units.
+// Range box is checked against inverse projected points, so it's exact also for curved projections.
+// Has no effect if the index has no projection.
+func FromLngLat() QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.fromLngLat = true
+	}
+}
+
+// number of samples per edge of the query box, to find bounds of projected box
+const projectionSamples = 16
+
+// bounds of the projected lng, lat box
+func projectBox(p Projection, minLng, minLat, maxLng, maxLat float64) (float64, float64, float64, float64) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	add := func(lng, lat float64) {
+		x, y := p.Forward(lng, lat)
+		minX, minY, maxX, maxY = math.Min(minX, x), math.Min(minY, y), math.Max(maxX, x), math.Max(maxY, y)
+	}
+	for s := 0; s <= projectionSamples; s++ {
+		t := float64(s) / projectionSamples
+		lng, lat := minLng+t*(maxLng-minLng), minLat+t*(maxLat-minLat)
+		add(lng, minLat)
+		add(lng, maxLat)
+		add(minLng, lat)
+		add(maxLng, lat)
+	}
+	//a little margin for bulging edges between samples
+	mx, my := (maxX-minX)*1e-3, (maxY-minY)*1e-3
+	return minX - mx, minY - my, maxX + mx, maxY + my
+}
+
+// Returned by Save and other serialization of an index with a custom projection, that Load couldn't restore.
+// Only WebMercator, UTM and NormalizedMercator projections are serialized.
+var ErrUnsupportedProjection = errors.New("kdbush: custom projection can't be serialized")
+
+// kinds of serialized projections
+const (
+	projectionWebMercator = iota + 1
+	projectionUTM
+	projectionNormalizedMercator
+)
+
+// encodes built-in projection as its kind and parameters: zone and 1 for north of UTM, MaxLat of NormalizedMercator
+func encodeProjection(p Projection) (kind uint8, a, b float64, err error) {
+	switch v := p.(type) {
+	case webMercator:
+		return projectionWebMercator, 0, 0, nil
+	case UTM:
+		north := 0.0
+		if v.North {
+			north = 1
+		}
+		return projectionUTM, float64(v.Zone), north, nil
+	case NormalizedMercator:
+		return projectionNormalizedMercator, v.MaxLat, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("%w: %T", ErrUnsupportedProjection, p)
+}
+
+// decodes projection, encoded by encodeProjection
+func decodeProjection(kind uint8, a, b float64) (Projection, error) {
+	switch kind {
+	case projectionWebMercator:
+		return WebMercator, nil
+	case projectionUTM:
+		if a < 1 || a > 60 || a != math.Trunc(a) {
+			return nil, fmt.Errorf("%w: invalid UTM zone %v", ErrInvalidFormat, a)
+		}
+		return UTM{Zone: int(a), North: b != 0}, nil
+	case projectionNormalizedMercator:
+		return NormalizedMercator{MaxLat: a}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown projection %d", ErrInvalidFormat, kind)
+}
@@ -0,0 +1,123 @@
+package kdbush
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebMercator(t *testing.T) {
+	x, y := WebMercator.Forward(180, 0)
+	assert.InDelta(t, 20037508.34, x, 0.01)
+	assert.InDelta(t, 0, y, 1e-9)
+	x, y = WebMercator.Forward(0, 85.0511287798066)
+	assert.InDelta(t, 0, x, 1e-9)
+	assert.InDelta(t, 20037508.34, y, 0.01)
+
+	lng, lat := WebMercator.Inverse(WebMercator.Forward(2.2945, 48.8583))
+	assert.InDelta(t, 2.2945, lng, 1e-9)
+	assert.InDelta(t, 48.8583, lat, 1e-9)
+}
+
+func TestUTM(t *testing.T) {
+	//Eiffel tower, reference values are computed with Snyder's series
+	zone := UTMZoneOf(2.2945, 48.8583)
+	assert.Equal(t, UTM{Zone: 31, North: true}, zone)
+	assert.Equal(t, "UTM 31N", zone.String())
+	x, y := zone.Forward(2.2945, 48.8583)
+	assert.InDelta(t, 448251.898, x, 0.01)
+	assert.InDelta(t, 5411943.794, y, 0.01)
+
+	//Sydney Opera House
+	zone = UTMZoneOf(151.2153, -33.8568)
+	assert.Equal(t, UTM{Zone: 56}, zone)
+	x, y = zone.Forward(151.2153, -33.8568)
+	assert.InDelta(t, 334900.570, x, 0.01)
+	assert.InDelta(t, 6252288.753, y, 0.01)
+
+	for _, p := range []LngLat{{151.2153, -33.8568}, {149, -10}, {155, -60}} {
+		lng, lat := zone.Inverse(zone.Forward(p.Lng, p.Lat))
+		assert.InDelta(t, p.Lng, lng, 1e-8)
+		assert.InDelta(t, p.Lat, lat, 1e-8)
+	}
+}
+
+func TestWithProjection(t *testing.T) {
+	cities := []Point{LngLat{2.35, 48.85}, LngLat{2.29, 48.86}, LngLat{2.45, 48.80}, LngLat{4.83, 45.76}}
+	zone := UTMZoneOf(2.35, 48.85)
+	bush := NewBushWithOptions(cities, 10, WithProjection(zone))
+	assert.Equal(t, zone, bush.Projection())
+
+	x, y := zone.Forward(2.29, 48.86)
+	assert.Equal(t, []int{1}, bush.Range(x-1, y-1, x+1, y+1))
+
+	result, _ := bush.RangeWithOptions(2.3, 48.82, 2.5, 48.9, FromLngLat())
+	assert.Equal(t, []int{0}, result)
+	result, _ = bush.RangeWithOptions(2, 45, 5, 50, FromLngLat())
+	assert.Equal(t, []int{0, 1, 2, 3}, sortedInts(result))
+
+	//radius is in meters of the projection
+	result, _ = bush.WithinWithOptions(LngLat{2.35, 48.85}, 5000, FromLngLat())
+	assert.Equal(t, []int{0, 1}, sortedInts(result))
+
+	assert.Nil(t, NewBush(cities, 10).Projection())
+}
+
+// custom projection, that can't be serialized
+type swapProjection struct{}
+
+func (swapProjection) Forward(lng, lat float64) (float64, float64) { return lat, lng }
+func (swapProjection) Inverse(x, y float64) (float64, float64)     { return y, x }
+
+func TestWithProjection_Serialization(t *testing.T) {
+	cities := []Point{LngLat{2.35, 48.85}, LngLat{2.29, 48.86}, LngLat{2.45, 48.80}, LngLat{4.83, 45.76}}
+	for _, opt := range []Option{WithProjection(UTM{Zone: 31, North: true}), WithProjection(WebMercator), WithMercatorStorage(80), WithProjection(UTM{Zone: 31})} {
+		bush := NewBushWithOptions(cities, 2, opt, WithSpreadSplit())
+		box, _ := bush.RangeWithOptions(2.3, 48.82, 2.5, 48.9, FromLngLat())
+		near, _ := bush.WithinWithOptions(LngLat{2.35, 48.85}, 5000, FromLngLat())
+		check := func(loaded *KDBush) {
+			assert.Equal(t, bush.Projection(), loaded.Projection())
+			result, _ := loaded.RangeWithOptions(2.3, 48.82, 2.5, 48.9, FromLngLat())
+			assert.Equal(t, box, result)
+			result, _ = loaded.WithinWithOptions(LngLat{2.35, 48.85}, 5000, FromLngLat())
+			assert.Equal(t, near, result)
+		}
+
+		var buf bytes.Buffer
+		assert.NoError(t, bush.Save(&buf))
+		if loaded, err := Load(&buf); assert.NoError(t, err) {
+			check(loaded)
+		}
+
+		buf.Reset()
+		assert.NoError(t, bush.SaveMmap(&buf))
+		if mapped, err := OpenBushBytes(buf.Bytes()); err != ErrMmapUnsupported && assert.NoError(t, err) {
+			check(mapped)
+		}
+
+		data, err := json.Marshal(bush)
+		assert.NoError(t, err)
+		var decoded KDBush
+		if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+			check(&decoded)
+		}
+	}
+
+	bush := NewBushWithOptions(cities, 2, WithProjection(swapProjection{}))
+	var buf bytes.Buffer
+	assert.True(t, errors.Is(bush.Save(&buf), ErrUnsupportedProjection))
+	assert.Zero(t, buf.Len())
+	assert.True(t, errors.Is(bush.SaveMmap(&buf), ErrUnsupportedProjection))
+	_, err := json.Marshal(bush)
+	assert.True(t, errors.Is(err, ErrUnsupportedProjection))
+
+	for _, zone := range []float64{0, 61, 31.5} {
+		_, err = decodeProjection(projectionUTM, zone, 1)
+		assert.True(t, errors.Is(err, ErrInvalidFormat))
+	}
+	_, err = decodeProjection(0, 0, 0)
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+}
@@ -0,0 +1,448 @@
+package kdbush
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Option, that changes behaviour of a single query
+type QueryOption func(*queryConfig)
+
+type queryConfig struct {
+	maxNodes int
+
+	sampled    bool
+	sampleSeed uint64
+	sampleMax  uint64
+
+	zFiltered  bool
+	zMin, zMax float64
+	z          []float64 //Z values of the index, set by prepare
+
+	genFiltered bool
+	genExact    bool
+	gen         uint32
+	gens        []uint32 //generations of the index, set by prepare
+
+	workers     int
+	parallelMin int
+
+	score      func(idx int, distSq float64) float64
+	scoreBound func(distSq float64) float64
+
+	fromLngLat bool
+
+	where func(idx int) bool
+
+	excludeSelf bool
+	self        int //original index skipped by ExcludeSelf
+
+	ties    bool
+	lastHit float64 //distance of the last result of k nearest query, used for ties
+
+	deadline time.Time
+	timedOut bool //nearest query was stopped by the deadline
+
+	visited   int  //number of tree nodes visited by the query
+	truncated bool //traversal was stopped by maxNodes
+
+	excluded     []QueryGeom
+	excludedBBox [][4]float64 //bounds of excluded geometries, set by prepare
+}
+
+func newQueryConfig(opts []QueryOption) *queryConfig {
+	cfg := &queryConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Limits the work of the query: traversal stops after n nodes of the tree are visited,
+// and the query returns results found so far, with truncated flag set.
+// Nearest queries return points already found, completed with the closest of seen candidates, the same as after Deadline.
+// n <= 0 means no limit.
+func MaxNodesVisited(n int) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.maxNodes = n
+	}
+}
+
+// Keeps only a sample of the results, about rate fraction of them (0..1).
+// Sampling is deterministic: whether the point is kept depends only on its index and the seed,
+// so repeated queries with the same seed return the same subset, and the subset for a smaller area
+// is always a part of the subset for a bigger one.
+func Sample(rate float64, seed uint64) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.sampled = true
+		cfg.sampleSeed = seed
+		switch {
+		case rate <= 0:
+			cfg.sampleMax = 0
+		case rate >= 1:
+			cfg.sampleMax = math.MaxUint64
+		default:
+			cfg.sampleMax = uint64(rate * float64(math.MaxUint64))
+		}
+	}
+}
+
+// Keeps only points with Z value (see SetZ) from zMin to zMax inclusive.
+// If the index has no Z values, nothing matches.
+func WithZRange(zMin, zMax float64) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.zFiltered = true
+		cfg.zMin, cfg.zMax = zMin, zMax
+	}
+}
+
+// Keeps only points of generation gen or older (see TagGeneration), "data as of batch gen".
+// If the index has no generations, all points are generation 0.
+func AsOfGeneration(gen uint32) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.genFiltered, cfg.genExact, cfg.gen = true, false, gen
+	}
+}
+
+// Keeps only points of exactly generation gen
+func InGeneration(gen uint32) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.genFiltered, cfg.genExact, cfg.gen = true, true, gen
+	}
+}
+
+// Scans leaves with at least minLeaf points in parallel by workers goroutines.
+// Useful for indexes with large NodeSize (1024+), that trade tree depth for build speed.
+// Results are the same and in the same order, as without the option.
+// Only coordinates are checked in parallel, filters and predicates like Where run in the query goroutine,
+// so they don't need to be goroutine-safe.
+func ParallelLeafScan(workers, minLeaf int) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.workers, cfg.parallelMin = workers, minLeaf
+	}
+}
+
+// Ranks results of Nearest by the combined score instead of the distance, lower score is better.
+// Nearest returns k points with the lowest scores within maxDist.
+// bound is optional: the lowest possible score of any point at squared distance distSq or farther,
+// non-decreasing by distSq. With bound traversal stops as soon as no farther point could get to the top k,
+// without it all points within maxDist are scored.
+func ScoreWith(score func(idx int, distSq float64) float64, bound func(distSq float64) float64) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.score, cfg.scoreBound = score, bound
+	}
+}
+
+// Nearest queries return all points at the same distance as the k-th one, so there could be more than k results.
+// Without the option exactly k points are returned, ties are broken by lower original index.
+func WithTies() QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.ties = true
+	}
+}
+
+// Stops nearest queries when the deadline passes: points found so far are returned, completed with
+// the closest of already seen candidates, but closer points could be in subtrees not searched yet.
+func Deadline(t time.Time) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.deadline = t
+	}
+}
+
+// decides if the next point of k nearest query at distance d is added to n results found so far
+func (cfg *queryConfig) kept(k, n int, d float64) bool {
+	if k > 0 && n >= k && !(cfg.ties && d == cfg.lastHit) {
+		return false
+	}
+	cfg.lastHit = d
+	return true
+}
+
+// Keeps only points, for which pred returns true, pred gets original index of the point.
+// The predicate is applied during traversal, so Nearest finds k nearest matching points without over-fetching.
+func Where(pred func(idx int) bool) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.where = pred
+	}
+}
+
+// Skips the point with original index idx, e.g. when the query point is this indexed point,
+// so Within and Nearest don't trivially return it first. Other points at the same location are kept.
+func ExcludeSelf(idx int) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.excludeSelf, cfg.self = true, idx
+	}
+}
+
+// Removes points inside of any of the geometries from results.
+// Subtrees, fully covered by a geometry implementing BoxContainer (Rect and Circle do), are skipped without visiting their points.
+func Excluding(geoms ...QueryGeom) QueryOption {
+	return func(cfg *queryConfig) {
+		cfg.excluded = append(cfg.excluded, geoms...)
+	}
+}
+
+// resolves per index data, that filters need, called once before the traversal
+func (cfg *queryConfig) prepare(bush *KDBush) {
+	if cfg.zFiltered {
+		cfg.z, _ = GetAux[float64](bush, ZAux)
+	}
+	if cfg.genFiltered {
+		cfg.gens, _ = GetAux[uint32](bush, GenerationAux)
+	}
+	cfg.excludedBBox = cfg.excludedBBox[:0]
+	for _, g := range cfg.excluded {
+		minX, minY, maxX, maxY := g.Bounds()
+		cfg.excludedBBox = append(cfg.excludedBBox, [4]float64{minX, minY, maxX, maxY})
+	}
+}
+
+// checks the point on position i against all filters of the query
+func (cfg *queryConfig) accepts(bush *KDBush, i int) bool {
+	if bush.removedAt(i) {
+		return false
+	}
+	if cfg.sampled && !sampled(bush.idx(i), cfg.sampleSeed, cfg.sampleMax) {
+		return false
+	}
+	if cfg.zFiltered {
+		if cfg.z == nil {
+			return false
+		}
+		if z := cfg.z[bush.idx(i)]; z < cfg.zMin || z > cfg.zMax || math.IsNaN(z) {
+			return false
+		}
+	}
+	if cfg.genFiltered {
+		gen := uint32(0)
+		if cfg.gens != nil {
+			gen = cfg.gens[bush.idx(i)]
+		}
+		if gen > cfg.gen || (cfg.genExact && gen != cfg.gen) {
+			return false
+		}
+	}
+	if cfg.excludeSelf && bush.idx(i) == cfg.self {
+		return false
+	}
+	if cfg.where != nil && !cfg.where(bush.idx(i)) {
+		return false
+	}
+	for k, g := range cfg.excluded {
+		b := cfg.excludedBBox[k]
+		x, y := bush.x(i), bush.y(i)
+		if x >= b[0] && x <= b[2] && y >= b[1] && y <= b[3] && g.Contains(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns true if the whole box is inside of some excluded geometry
+func (cfg *queryConfig) excludesBox(minX, minY, maxX, maxY float64) bool {
+	for _, g := range cfg.excluded {
+		if c, ok := g.(BoxContainer); ok && c.ContainsBox(minX, minY, maxX, maxY) {
+			return true
+		}
+	}
+	return false
+}
+
+func sampled(idx int, seed, max uint64) bool {
+	if max == math.MaxUint64 {
+		return true
+	}
+	return hashIndex(uint64(idx), seed) < max
+}
+
+// stable hash of the point index, splitmix64 finalizer
+func hashIndex(idx, seed uint64) uint64 {
+	z := idx + seed + 0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Same as Range, but accepts query options.
+// Returns results and the flag, that is true when the query was stopped before the whole tree was searched.
+func (bush *KDBush) RangeWithOptions(minX, minY, maxX, maxY float64, opts ...QueryOption) (result []int, truncated bool) {
+	return bush.rangeWith(minX, minY, maxX, maxY, newQueryConfig(opts))
+}
+
+func (bush *KDBush) rangeWith(minX, minY, maxX, maxY float64, cfg *queryConfig) (result []int, truncated bool) {
+	result = []int{}
+	if p := bush.projection; cfg.fromLngLat && p != nil {
+		pMinX, pMinY, pMaxX, pMaxY := projectBox(p, minX, minY, maxX, maxY)
+		truncated = bush.search(pMinX, pMinY, pMaxX, pMaxY, cfg, func(i int) bool {
+			if lng, lat := p.Inverse(bush.x(i), bush.y(i)); lng >= minX && lng <= maxX && lat >= minY && lat <= maxY {
+				result = append(result, bush.idx(i))
+			}
+			return true
+		})
+		return result, truncated
+	}
+	truncated = bush.search(minX, minY, maxX, maxY, cfg, func(i int) bool {
+		result = append(result, bush.idx(i))
+		return true
+	})
+	return result, truncated
+}
+
+// Same as Within, but accepts query options.
+// Returns results and the flag, that is true when the query was stopped before the whole tree was searched.
+func (bush *KDBush) WithinWithOptions(point Point, radius float64, opts ...QueryOption) (result []int, truncated bool) {
+	return bush.withinWith(point, radius, newQueryConfig(opts))
+}
+
+func (bush *KDBush) withinWith(point Point, radius float64, cfg *queryConfig) (result []int, truncated bool) {
+	result = []int{}
+	r2 := radius * radius
+	qx, qy := point.Coordinates()
+	if cfg.fromLngLat && bush.projection != nil {
+		qx, qy = bush.projection.Forward(qx, qy)
+	}
+	truncated = bush.search(qx-radius, qy-radius, qx+radius, qy+radius, cfg, func(i int) bool {
+		if sqrtDist(bush.x(i), bush.y(i), qx, qy) <= r2 {
+			result = append(result, bush.idx(i))
+		}
+		return true
+	})
+	return result, truncated
+}
+
+// Walks all nodes intersecting the bounding box and calls visit for every point inside of the box,
+// i is the position of the point in the tree. Visit could return false to stop the search.
+// Returns true if the search was interrupted by the query limits.
+// If the box contains all the points, they are scanned in tree order without traversal.
+func (bush *KDBush) search(minX, minY, maxX, maxY float64, cfg *queryConfig, visit func(i int) bool) bool {
+	cfg.prepare(bush)
+	if bush.CoversAll(minX, minY, maxX, maxY) {
+		cfg.visited++
+		for i := 0; i < bush.size(); i++ {
+			if cfg.accepts(bush, i) && !visit(i) {
+				return false
+			}
+		}
+		return false
+	}
+
+	sp := stackPool.Get().(*[]int)
+	stack := append((*sp)[:0], 0, bush.size()-1, 0)
+	defer func() { putStack(sp, stack) }()
+	visited := 0
+	var x, y float64
+
+	//bounds of the nodes in the stack, tracked only to prune excluded subtrees
+	pruning := len(cfg.excluded) > 0
+	var boxes [][4]float64
+	if pruning {
+		boxes = append(boxes, bush.bbox)
+	}
+
+	for len(stack) > 0 {
+		if cfg.maxNodes > 0 && visited >= cfg.maxNodes {
+			cfg.truncated = true
+			return true
+		}
+		visited++
+		cfg.visited++
+
+		axis := stack[len(stack)-1]
+		right := stack[len(stack)-2]
+		left := stack[len(stack)-3]
+		stack = stack[:len(stack)-3]
+
+		var box [4]float64
+		if pruning {
+			box = boxes[len(boxes)-1]
+			boxes = boxes[:len(boxes)-1]
+			if cfg.excludesBox(box[0], box[1], box[2], box[3]) {
+				continue
+			}
+		}
+
+		if right-left <= bush.NodeSize {
+			if cfg.workers > 1 && right-left+1 >= cfg.parallelMin {
+				for _, i := range bush.scanParallel(left, right, minX, minY, maxX, maxY, cfg.workers) {
+					if cfg.accepts(bush, i) && !visit(i) {
+						return false
+					}
+				}
+				continue
+			}
+			for i := left; i <= right; i++ {
+				x = bush.x(i)
+				y = bush.y(i)
+				if x >= minX && x <= maxX && y >= minY && y <= maxY && cfg.accepts(bush, i) && !visit(i) {
+					return false
+				}
+			}
+			continue
+		}
+
+		m := floor(float64(left+right) / 2.0)
+		x = bush.x(m)
+		y = bush.y(m)
+
+		if x >= minX && x <= maxX && y >= minY && y <= maxY && cfg.accepts(bush, m) && !visit(m) {
+			return false
+		}
+
+		axis = bush.splitAxis(m, axis)
+		nextAxis := (axis + 1) % 2
+
+		if (axis == 0 && minX <= x) || (axis != 0 && minY <= y) {
+			stack = append(stack, left, m-1, nextAxis)
+			if pruning {
+				b := box
+				b[2+axis] = bush.coord(m, axis)
+				boxes = append(boxes, b)
+			}
+		}
+
+		if (axis == 0 && maxX >= x) || (axis != 0 && maxY >= y) {
+			stack = append(stack, m+1, right, nextAxis)
+			if pruning {
+				b := box
+				b[axis] = bush.coord(m, axis)
+				boxes = append(boxes, b)
+			}
+		}
+	}
+	return false
+}
+
+// scans the leaf in parallel, returns positions of points inside of the box in order.
+// Filters of the query are not applied, they run user predicates, that are not required to be goroutine-safe.
+func (bush *KDBush) scanParallel(left, right int, minX, minY, maxX, maxY float64, workers int) []int {
+	parts := make([][]int, workers)
+	size := (right - left + workers) / workers
+	var wg sync.WaitGroup
+	for w := range parts {
+		from, to := left+w*size, iMin(right, left+(w+1)*size-1)
+		if from > to {
+			break
+		}
+		wg.Add(1)
+		go func(w, from, to int) {
+			defer wg.Done()
+			for i := from; i <= to; i++ {
+				x, y := bush.x(i), bush.y(i)
+				if x >= minX && x <= maxX && y >= minY && y <= maxY {
+					parts[w] = append(parts[w], i)
+				}
+			}
+		}(w, from, to)
+	}
+	wg.Wait()
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	hits := []int{}
+	for _, p := range parts {
+		hits = append(hits, p...)
+	}
+	return hits
+}
@@ -0,0 +1,148 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_RangeWithOptions(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+
+	result, truncated := bush.RangeWithOptions(20, 30, 50, 70)
+	assert.False(t, truncated)
+	assert.Equal(t, bush.Range(20, 30, 50, 70), result)
+
+	result, truncated = bush.WithinWithOptions(&SimplePoint{X: 50, Y: 50}, 20)
+	assert.False(t, truncated)
+	assert.Equal(t, bush.Within(&SimplePoint{X: 50, Y: 50}, 20), result)
+}
+
+func TestKDBush_MaxNodesVisited(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	full := bush.Range(2, 2, 99, 99)
+
+	result, truncated := bush.RangeWithOptions(2, 2, 99, 99, MaxNodesVisited(1))
+	assert.True(t, truncated)
+	assert.Equal(t, full[:1], result)
+
+	result, truncated = bush.RangeWithOptions(2, 2, 99, 99, MaxNodesVisited(1000))
+	assert.False(t, truncated)
+	assert.Equal(t, full, result)
+
+	result, truncated = bush.WithinWithOptions(&SimplePoint{X: 50, Y: 50}, 40, MaxNodesVisited(3))
+	assert.True(t, truncated)
+	assert.True(t, len(result) < len(full))
+
+	//box with all the points is not traversed
+	result, truncated = bush.RangeWithOptions(-1, -1, 101, 101, MaxNodesVisited(1))
+	assert.False(t, truncated)
+	assert.Len(t, result, len(testPoints))
+}
+
+func TestKDBush_Sample(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	full := bush.Range(0, 0, 100, 100)
+
+	sample, _ := bush.RangeWithOptions(0, 0, 100, 100, Sample(0.3, 42))
+	assert.True(t, len(sample) > 10 && len(sample) < 50, "sample size %d", len(sample))
+
+	//the same subset for the same seed
+	again, _ := bush.RangeWithOptions(0, 0, 100, 100, Sample(0.3, 42))
+	assert.Equal(t, sample, again)
+
+	//subset of a smaller area is a part of the bigger one
+	small, _ := bush.RangeWithOptions(20, 30, 50, 70, Sample(0.3, 42))
+	for _, idx := range small {
+		assert.True(t, index(sample, idx) >= 0)
+	}
+
+	other, _ := bush.RangeWithOptions(0, 0, 100, 100, Sample(0.3, 7))
+	assert.NotEqual(t, sample, other)
+
+	all, _ := bush.RangeWithOptions(0, 0, 100, 100, Sample(1, 42))
+	assert.Equal(t, full, all)
+	none, _ := bush.RangeWithOptions(0, 0, 100, 100, Sample(0, 42))
+	assert.Empty(t, none)
+}
+
+func TestKDBush_WithZRange(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	none, _ := bush.RangeWithOptions(0, 0, 100, 100, WithZRange(0, 100))
+	assert.Empty(t, none)
+
+	z := make([]float64, len(testPoints))
+	for i := range z {
+		z[i] = float64(i)
+	}
+	assert.NoError(t, bush.SetZ(z))
+	assert.Error(t, bush.SetZ(z[:10]))
+
+	result, _ := bush.RangeWithOptions(20, 30, 50, 70, WithZRange(10, 50))
+	expected := []int{}
+	for _, idx := range bush.Range(20, 30, 50, 70) {
+		if idx >= 10 && idx <= 50 {
+			expected = append(expected, idx)
+		}
+	}
+	assert.Equal(t, expected, result)
+
+	result, _ = bush.WithinWithOptions(&SimplePoint{X: 50, Y: 50}, 20, WithZRange(90, 200))
+	assert.Equal(t, []int{92, 96}, result)
+}
+
+func TestKDBush_Generations(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	all := bush.Range(0, 0, 100, 100)
+
+	result, _ := bush.RangeWithOptions(0, 0, 100, 100, AsOfGeneration(0))
+	assert.Equal(t, all, result)
+
+	assert.NoError(t, bush.TagGeneration(50, 80, 1))
+	assert.NoError(t, bush.TagGeneration(80, 100, 2))
+	assert.Error(t, bush.TagGeneration(90, 101, 3))
+
+	count := func(opt QueryOption) int {
+		result, _ := bush.RangeWithOptions(0, 0, 100, 100, opt)
+		return len(result)
+	}
+	assert.Equal(t, 50, count(AsOfGeneration(0)))
+	assert.Equal(t, 80, count(AsOfGeneration(1)))
+	assert.Equal(t, 100, count(AsOfGeneration(2)))
+	assert.Equal(t, 30, count(InGeneration(1)))
+	assert.Equal(t, 0, count(InGeneration(3)))
+
+	result, _ = bush.RangeWithOptions(0, 0, 100, 100, InGeneration(2))
+	for _, idx := range result {
+		assert.True(t, idx >= 80)
+	}
+}
+
+func TestKDBush_ParallelLeafScan(t *testing.T) {
+	points := make([]Point, 20000)
+	for i := range points {
+		points[i] = &SimplePoint{X: float64(i % 211), Y: float64(i % 307)}
+	}
+	bush := NewBush(points, 2048)
+	bush.RemoveRange(0, 0, 20, 20)
+
+	expected, _ := bush.RangeWithOptions(30, 40, 150, 250, Sample(0.5, 1))
+	result, _ := bush.RangeWithOptions(30, 40, 150, 250, Sample(0.5, 1), ParallelLeafScan(4, 1024))
+	assert.Equal(t, expected, result)
+
+	expected = bush.Within(&SimplePoint{X: 100, Y: 100}, 50)
+	result, _ = bush.WithinWithOptions(&SimplePoint{X: 100, Y: 100}, 50, ParallelLeafScan(3, 100))
+	assert.Equal(t, expected, result)
+
+	//run with -race: predicates are not required to be goroutine-safe
+	seen := map[int]bool{}
+	odd := Where(func(idx int) bool {
+		seen[idx] = true
+		return idx%2 == 1
+	})
+	expected, _ = bush.RangeWithOptions(30, 40, 150, 250, odd)
+	checked := len(seen)
+	result, _ = bush.RangeWithOptions(30, 40, 150, 250, odd, ParallelLeafScan(4, 1024))
+	assert.Equal(t, expected, result)
+	assert.Equal(t, checked, len(seen))
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package kdbush
+
+// sync.Pool drops items randomly under the race detector, so allocation counts are not checked
+const raceEnabled = true
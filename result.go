@@ -0,0 +1,70 @@
+package kdbush
+
+// Defines the form of query results, converts position i in the tree to the result value.
+// Using it with RangeAs or WithinAs builds results of required type directly,
+// without intermediate slice of indices.
+type ResultKind[T any] func(bush *KDBush, i int) T
+
+// Results are indices in the original points input slice, the same as Range and Within return
+func AsIndices() ResultKind[int] {
+	return func(bush *KDBush, i int) int {
+		return bush.idx(i)
+	}
+}
+
+// Results are original points. Index should be created from points, not from coordinates.
+func AsPoints() ResultKind[Point] {
+	return func(bush *KDBush, i int) Point {
+		return bush.Points[bush.idx(i)]
+	}
+}
+
+// Results are coordinates of the points
+func AsCoords() ResultKind[[2]float64] {
+	return func(bush *KDBush, i int) [2]float64 {
+		return [2]float64{bush.x(i), bush.y(i)}
+	}
+}
+
+// Results are taken from ids slice, that is parallel to the original points input slice
+func AsIDs[ID any](ids []ID) ResultKind[ID] {
+	return func(bush *KDBush, i int) ID {
+		return ids[bush.idx(i)]
+	}
+}
+
+// Same as RangeWithOptions, but returns results in the form defined by kind
+func RangeAs[T any](bush *KDBush, kind ResultKind[T], minX, minY, maxX, maxY float64, opts ...QueryOption) (result []T, truncated bool) {
+	result = []T{}
+	truncated = bush.search(minX, minY, maxX, maxY, newQueryConfig(opts), func(i int) bool {
+		result = append(result, kind(bush, i))
+		return true
+	})
+	return result, truncated
+}
+
+// Same as WithinWithOptions, but returns results in the form defined by kind
+func WithinAs[T any](bush *KDBush, kind ResultKind[T], point Point, radius float64, opts ...QueryOption) (result []T, truncated bool) {
+	result = []T{}
+	r2 := radius * radius
+	qx, qy := point.Coordinates()
+	truncated = bush.search(qx-radius, qy-radius, qx+radius, qy+radius, newQueryConfig(opts), func(i int) bool {
+		if sqrtDist(bush.x(i), bush.y(i), qx, qy) <= r2 {
+			result = append(result, kind(bush, i))
+		}
+		return true
+	})
+	return result, truncated
+}
+
+// Same as Range, but returns original points instead of indices. Index should be created from points, not from coordinates.
+func (bush *KDBush) RangePoints(minX, minY, maxX, maxY float64, opts ...QueryOption) []Point {
+	result, _ := RangeAs(bush, AsPoints(), minX, minY, maxX, maxY, opts...)
+	return result
+}
+
+// Same as Within, but returns original points instead of indices. Index should be created from points, not from coordinates.
+func (bush *KDBush) WithinPoints(point Point, radius float64, opts ...QueryOption) []Point {
+	result, _ := WithinAs(bush, AsPoints(), point, radius, opts...)
+	return result
+}
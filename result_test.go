@@ -0,0 +1,64 @@
+package kdbush
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeAs(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+	expected := bush.Range(20, 30, 50, 70)
+
+	idxs, _ := RangeAs(bush, AsIndices(), 20, 30, 50, 70)
+	assert.Equal(t, expected, idxs)
+
+	pts, _ := RangeAs(bush, AsPoints(), 20, 30, 50, 70)
+	coords, _ := RangeAs(bush, AsCoords(), 20, 30, 50, 70)
+	ids := make([]string, len(points))
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id%d", i)
+	}
+	names, _ := RangeAs(bush, AsIDs(ids), 20, 30, 50, 70)
+
+	for k, idx := range expected {
+		assert.Equal(t, points[idx], pts[k])
+		x, y := points[idx].Coordinates()
+		assert.Equal(t, [2]float64{x, y}, coords[k])
+		assert.Equal(t, ids[idx], names[k])
+	}
+}
+
+func TestWithinAs(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	point := &SimplePoint{X: 50, Y: 50}
+
+	idxs, _ := WithinAs(bush, AsIndices(), point, 20)
+	assert.Equal(t, bush.Within(point, 20), idxs)
+
+	coords, _ := WithinAs(bush, AsCoords(), point, 20)
+	assert.Len(t, coords, len(idxs))
+	for _, c := range coords {
+		assert.True(t, sqrtDist(c[0], c[1], 50, 50) <= 400)
+	}
+}
+
+func TestKDBush_RangePoints(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+
+	expected := []Point{}
+	for _, idx := range bush.Range(20, 30, 50, 70) {
+		expected = append(expected, points[idx])
+	}
+	assert.Equal(t, expected, bush.RangePoints(20, 30, 50, 70))
+
+	q := &SimplePoint{X: 50, Y: 50}
+	expected = []Point{}
+	for _, idx := range bush.Within(q, 20) {
+		expected = append(expected, points[idx])
+	}
+	assert.Equal(t, expected, bush.WithinPoints(q, 20))
+}
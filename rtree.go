@@ -0,0 +1,207 @@
+package kdbush
+
+import (
+	"container/heap"
+	"math"
+	sorting "sort"
+)
+
+// Common query API of the indexes, so the backend could be chosen per dataset at runtime
+type SpatialIndex interface {
+	Range(minX, minY, maxX, maxY float64) []int
+	Within(point Point, radius float64) []int
+}
+
+var (
+	_ SpatialIndex = (*KDBush)(nil)
+	_ SpatialIndex = (*RTree)(nil)
+)
+
+// Static R-tree of points packed with Sort-Tile-Recursive algorithm.
+// Nodes have tight bounding boxes, so it degrades less than KD-tree on highly clustered data, but builds slower.
+// Queries return indices of the original points input slice, the same as KDBush queries.
+type RTree struct {
+	NodeSize int
+	Points   []Point
+
+	Idxs   []int     //original indexes in the order of leaves
+	Coords []float64 //coordinates in the order of leaves
+
+	boxes      []float64 //minX, minY, maxX, maxY of every node, nodes of all levels from leaves to the root
+	start, end []int     //range of children of every node: positions in Idxs for leaves, node ids otherwise
+	leaves     int       //number of leaf nodes, they go first
+}
+
+// Creates STR-packed R-tree from points, nodeSize is maximum number of children of a node, 16 by default
+func NewRTree(points []Point, nodeSize int) *RTree {
+	if nodeSize < 2 {
+		nodeSize = 16
+	}
+	n := len(points)
+	t := &RTree{NodeSize: nodeSize, Points: points, Idxs: make([]int, n), Coords: make([]float64, 2*n)}
+	xs, ys := make([]float64, n), make([]float64, n)
+	for i, p := range points {
+		xs[i], ys[i] = p.Coordinates()
+	}
+	for i, j := range strOrder(xs, ys, nodeSize) {
+		t.Idxs[i] = j
+		t.Coords[2*i], t.Coords[2*i+1] = xs[j], ys[j]
+	}
+
+	//leaves: runs of nodeSize consecutive points
+	for s := 0; s < n; s += nodeSize {
+		e := min(n, s+nodeSize)
+		minX, minY, maxX, maxY := coordsBounds(t.Coords[2*s : 2*e])
+		t.addNode(minX, minY, maxX, maxY, s, e)
+	}
+	t.leaves = len(t.start)
+
+	//upper levels: nodes of the level below are reordered by STR of their centers and packed the same way
+	for first, last := 0, len(t.start); last-first > 1; first, last = last, len(t.start) {
+		count := last - first
+		cx, cy := make([]float64, count), make([]float64, count)
+		for i := 0; i < count; i++ {
+			b := t.boxes[4*(first+i):]
+			cx[i], cy[i] = (b[0]+b[2])/2, (b[1]+b[3])/2
+		}
+		order := strOrder(cx, cy, nodeSize)
+		boxes := make([]float64, 4*count)
+		start, end := make([]int, count), make([]int, count)
+		for i, j := range order {
+			copy(boxes[4*i:4*i+4], t.boxes[4*(first+j):4*(first+j)+4])
+			start[i], end[i] = t.start[first+j], t.end[first+j]
+		}
+		copy(t.boxes[4*first:], boxes)
+		copy(t.start[first:], start)
+		copy(t.end[first:], end)
+
+		for s := first; s < last; s += nodeSize {
+			e := min(last, s+nodeSize)
+			minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+			for c := s; c < e; c++ {
+				b := t.boxes[4*c:]
+				minX, minY, maxX, maxY = math.Min(minX, b[0]), math.Min(minY, b[1]), math.Max(maxX, b[2]), math.Max(maxY, b[3])
+			}
+			t.addNode(minX, minY, maxX, maxY, s, e)
+		}
+	}
+	return t
+}
+
+func (t *RTree) addNode(minX, minY, maxX, maxY float64, start, end int) {
+	t.boxes = append(t.boxes, minX, minY, maxX, maxY)
+	t.start = append(t.start, start)
+	t.end = append(t.end, end)
+}
+
+// Sort-Tile-Recursive order of the items: sorted by x into vertical slices, every slice is sorted by y
+func strOrder(xs, ys []float64, nodeSize int) []int {
+	n := len(xs)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sorting.Slice(order, func(i, j int) bool { return xs[order[i]] < xs[order[j]] })
+	nodes := (n + nodeSize - 1) / nodeSize
+	slice := nodeSize * int(math.Ceil(math.Sqrt(float64(nodes))))
+	for s := 0; s < n; s += slice {
+		part := order[s:min(n, s+slice)]
+		sorting.Slice(part, func(i, j int) bool { return ys[part[i]] < ys[part[j]] })
+	}
+	return order
+}
+
+// Finds all items within the given bounding box and returns an array of indices
+func (t *RTree) Range(minX, minY, maxX, maxY float64) []int {
+	result := []int{}
+	t.search(minX, minY, maxX, maxY, func(n int) bool { return true }, func(i int) {
+		x, y := t.Coords[2*i], t.Coords[2*i+1]
+		if x >= minX && x <= maxX && y >= minY && y <= maxY {
+			result = append(result, t.Idxs[i])
+		}
+	})
+	return result
+}
+
+// Finds all items within a given radius from the query point and returns an array of indices
+func (t *RTree) Within(point Point, radius float64) []int {
+	result := []int{}
+	qx, qy := point.Coordinates()
+	r2 := radius * radius
+	nodeNear := func(n int) bool { return boxDistSq(qx, qy, t.node(n)) <= r2 }
+	t.search(qx-radius, qy-radius, qx+radius, qy+radius, nodeNear, func(i int) {
+		if sqrtDist(t.Coords[2*i], t.Coords[2*i+1], qx, qy) <= r2 {
+			result = append(result, t.Idxs[i])
+		}
+	})
+	return result
+}
+
+// Finds k nearest points to the query point within maxDist and returns their indices sorted by distance,
+// points at the same distance are sorted by original index, the same as KDBush.Nearest.
+// k <= 0 means no limit on number of results, maxDist <= 0 means no limit on distance.
+func (t *RTree) Nearest(point Point, k int, maxDist float64) []int {
+	result := []int{}
+	if len(t.start) == 0 {
+		return result
+	}
+	qx, qy := point.Coordinates()
+	maxDistSq := math.Inf(1)
+	if maxDist > 0 {
+		maxDistSq = maxDist * maxDist
+	}
+	//node id is kept in left of the treeNode
+	q := &knnQueue{{node: treeNode{left: len(t.start) - 1}, pos: -1}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(knnItem)
+		if item.pos >= 0 {
+			result = append(result, item.idx)
+			if k > 0 && len(result) >= k {
+				break
+			}
+			continue
+		}
+		n := item.node.left
+		for c := t.start[n]; c < t.end[n]; c++ {
+			if n < t.leaves {
+				if d := sqrtDist(t.Coords[2*c], t.Coords[2*c+1], qx, qy); d <= maxDistSq {
+					heap.Push(q, knnItem{pos: c, idx: t.Idxs[c], dist: d})
+				}
+			} else if d := boxDistSq(qx, qy, t.node(c)); d <= maxDistSq {
+				heap.Push(q, knnItem{node: treeNode{left: c}, pos: -1, dist: d})
+			}
+		}
+	}
+	return result
+}
+
+// bounds of the node n as treeNode
+func (t *RTree) node(n int) treeNode {
+	b := t.boxes[4*n:]
+	return treeNode{minX: b[0], minY: b[1], maxX: b[2], maxY: b[3]}
+}
+
+// visits positions of points in leaves intersecting the box, accept could prune nodes further
+func (t *RTree) search(minX, minY, maxX, maxY float64, accept func(n int) bool, visit func(i int)) {
+	if len(t.start) == 0 {
+		return
+	}
+	stack := []int{len(t.start) - 1}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		b := t.boxes[4*n:]
+		if b[0] > maxX || b[2] < minX || b[1] > maxY || b[3] < minY || !accept(n) {
+			continue
+		}
+		if n < t.leaves {
+			for i := t.start[n]; i < t.end[n]; i++ {
+				visit(i)
+			}
+			continue
+		}
+		for c := t.end[n] - 1; c >= t.start[n]; c-- {
+			stack = append(stack, c)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(9))
+	points := make([]Point, 10000)
+	for i := range points {
+		//clustered data
+		c := float64(rnd.Intn(5)) * 200
+		points[i] = &SimplePoint{X: c + rnd.NormFloat64()*5, Y: c + rnd.NormFloat64()*5}
+	}
+	bush := NewBush(points, 16)
+
+	for _, nodeSize := range []int{2, 9, 16} {
+		tree := NewRTree(points, nodeSize)
+		var index SpatialIndex = tree
+		for i := 0; i < 20; i++ {
+			x, y := rnd.Float64()*900, rnd.Float64()*900
+			assert.Equal(t, sortedInts(bush.Range(x, y, x+100, y+100)), sortedInts(index.Range(x, y, x+100, y+100)))
+			q := &SimplePoint{X: x, Y: y}
+			assert.Equal(t, sortedInts(bush.Within(q, 50)), sortedInts(index.Within(q, 50)))
+			assert.Equal(t, bush.Nearest(q, 20, 0), tree.Nearest(q, 20, 0))
+			assert.Equal(t, bush.Nearest(q, 0, 30), tree.Nearest(q, 0, 30))
+		}
+	}
+
+	empty := NewRTree(nil, 16)
+	assert.Empty(t, empty.Range(0, 0, 1, 1))
+	assert.Empty(t, empty.Nearest(&SimplePoint{}, 1, 0))
+	assert.Equal(t, []int{0}, NewRTree([]Point{&SimplePoint{X: 1, Y: 1}}, 16).Within(&SimplePoint{}, 2))
+}
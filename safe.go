@@ -0,0 +1,57 @@
+package kdbush
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Returned by NewBushSafe for nil points and points with NaN or infinite coordinates
+var ErrInvalidPoint = errors.New("kdbush: invalid point")
+
+// Same as NewBushWithOptions, but validates the input instead of panicking or building a broken index:
+// node size < 1 and invalid options return ErrInvalidOption error,
+// nil points and points with NaN or infinite coordinates return ErrInvalidPoint error with index of the first such point.
+// With WithSkipInvalid option such points are dropped instead, they are never returned by queries and counted in Stats.Dropped.
+// Empty or nil points give an empty index, all queries of it return empty results.
+func NewBushSafe(points []Point, nodeSize int, opts ...Option) (*KDBush, error) {
+	cfg := &buildConfig{nodeSize: nodeSize, sortThreshold: DefaultSortThreshold, workers: 1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.skipInvalid {
+		for i, p := range points {
+			if p == nil {
+				return nil, fmt.Errorf("%w: point %d is nil", ErrInvalidPoint, i)
+			}
+			if x, y := p.Coordinates(); !isFinite(x, y) {
+				return nil, fmt.Errorf("%w: point %d has coordinates %v, %v", ErrInvalidPoint, i, x, y)
+			}
+		}
+	}
+	points = copyPoints(points, cfg)
+	b := KDBush{Points: points}
+	b.buildFrom(len(points), func(i int) (float64, float64) {
+		if points[i] == nil {
+			return math.NaN(), math.NaN()
+		}
+		return points[i].Coordinates()
+	}, cfg.nodeSize, cfg)
+	return &b, nil
+}
+
+// Drops points with NaN or infinite coordinates at build, instead of putting them into the index,
+// where NaN breaks KD-order and makes queries miss valid points.
+// Number of dropped points is reported in Stats.
+func WithSkipInvalid() Option {
+	return func(cfg *buildConfig) {
+		cfg.skipInvalid = true
+	}
+}
+
+func isFinite(x, y float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0) && !math.IsNaN(y) && !math.IsInf(y, 0)
+}
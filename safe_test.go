@@ -0,0 +1,58 @@
+package kdbush
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBushSafe(t *testing.T) {
+	points := getTestPoints()
+	bush, err := NewBushSafe(points, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, NewBush(points, 10).Idxs, bush.Idxs)
+
+	_, err = NewBushSafe(points, 0)
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	bush, err = NewBushSafe(points, 0, WithNodeSize(16))
+	assert.NoError(t, err)
+	assert.Equal(t, 16, bush.NodeSize)
+
+	invalid := append([]Point{}, points...)
+	invalid[5] = &SimplePoint{X: math.NaN(), Y: 1}
+	invalid[7] = nil
+	invalid[9] = &SimplePoint{X: 1, Y: math.Inf(-1)}
+	_, err = NewBushSafe(invalid, 10)
+	assert.ErrorIs(t, err, ErrInvalidPoint)
+	assert.Contains(t, err.Error(), "point 5")
+
+	bush, err = NewBushSafe(invalid, 10, WithSkipInvalid())
+	assert.NoError(t, err)
+	assert.NoError(t, bush.Verify())
+	assert.Equal(t, Stats{Input: len(points), Points: len(points) - 3, Dropped: 3}, bush.Stats())
+	for _, idx := range []int{5, 7, 9} {
+		assert.Equal(t, -1, bush.TreePos(idx))
+	}
+	all := bush.Range(math.Inf(-1), math.Inf(-1), math.Inf(1), math.Inf(1))
+	assert.Len(t, all, len(points)-3)
+	assert.NotContains(t, all, 5)
+	assert.Len(t, bush.Nearest(&SimplePoint{50, 50}, len(points), 0), len(points)-3)
+}
+
+func TestNewBushSafe_Empty(t *testing.T) {
+	for _, points := range [][]Point{nil, {}, {nil}} {
+		bush, err := NewBushSafe(points, 64, WithSkipInvalid())
+		if !assert.NoError(t, err) {
+			continue
+		}
+		q := &SimplePoint{X: 0, Y: 0}
+		assert.Empty(t, bush.Range(-1, -1, 1, 1))
+		assert.Empty(t, bush.Within(q, 1))
+		assert.Empty(t, bush.WithinDistSorted(q, 1))
+		assert.Empty(t, bush.Nearest(q, 3, 0))
+		assert.Zero(t, bush.RangeCount(-1, -1, 1, 1))
+		_, _, ok := bush.NearestIter(q)()
+		assert.False(t, ok)
+	}
+}
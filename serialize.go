@@ -0,0 +1,395 @@
+package kdbush
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// Serialized index format, all values are little endian:
+//
+//	magic "KDBG", version uint8
+//	version 3 only: byte order mark uint16 0x0102, flags uint8 (bit 0: split axes, bit 1: projection)
+//	nodeSize uint32, count uint64 (points in the index), input uint64 (points in the original input)
+//	idxs count*uint32, coords 2*count*float64
+//	removed words uint64, removed bitset words*uint64
+//	aux arrays uint32, for every array: name length uint16, name, type tag uint8, length uint64, values
+//	version 2 and version 3 with flag: split axes count*uint8, from indexes built with WithSpreadSplit option
+//	version 3 with flag: projection kind uint8, parameters 2*float64, from indexes built with WithProjection option
+//	version 3 only: CRC-32 (IEEE) uint32 of all the bytes before it
+//
+// Save writes version 3, Load reads all versions, so snapshots of older releases are still loaded.
+// A new version is added only when older readers can't skip the change, readers reject unknown versions and flags.
+// Points are not serialized, loaded index has nil Points, you could set them back if you need.
+// Only built-in projections are serialized, Save fails with ErrUnsupportedProjection for custom ones.
+const (
+	formatMagic = "KDBG"
+	//first version, without header flags and checksum
+	formatVersion = 1
+	//version 1 with split axes
+	formatVersionAxes = 2
+	//version with byte order mark, flags and checksum
+	formatVersionChecked = 3
+
+	formatByteOrder      = 0x0102
+	formatFlagAxes       = 1
+	formatFlagProjection = 2
+
+	//number of values, that are allocated before they are read
+	decodeChunk = 1 << 16
+)
+
+// Returned by Load when data is not a serialized index or it is corrupted
+var ErrInvalidFormat = errors.New("kdbush: invalid format")
+
+// Returned by Load when the checksum of data doesn't match, e.g. for a partially written or damaged file
+var ErrChecksum = fmt.Errorf("%w: checksum mismatch", ErrInvalidFormat)
+
+// Writes the index with all registered auxiliary arrays to w
+func (bush *KDBush) Save(w io.Writer) error {
+	var projKind uint8
+	var projA, projB float64
+	if bush.projection != nil {
+		var err error
+		if projKind, projA, projB, err = encodeProjection(bush.projection); err != nil {
+			return err
+		}
+	}
+	bw := bufio.NewWriter(w)
+	crc := crc32.NewIEEE()
+	e := &encoder{w: io.MultiWriter(bw, crc)}
+
+	e.bytes([]byte(formatMagic))
+	e.u8(formatVersionChecked)
+	e.u16(formatByteOrder)
+	flags := uint8(0)
+	if bush.splitAxes != nil {
+		flags |= formatFlagAxes
+	}
+	if bush.projection != nil {
+		flags |= formatFlagProjection
+	}
+	e.u8(flags)
+	e.u32(uint32(bush.NodeSize))
+	e.u64(uint64(bush.size()))
+	e.u64(uint64(bush.originalCount()))
+	for i := 0; i < bush.size(); i++ {
+		e.u32(uint32(bush.idx(i)))
+	}
+	for i := 0; i < bush.coordsLen(); i++ {
+		e.f64(bush.coord(i/2, i%2))
+	}
+
+	removed := bush.removed
+	if bush.removedCount == 0 {
+		removed = nil
+	}
+	e.u64(uint64(len(removed)))
+	for _, v := range removed {
+		e.u64(v)
+	}
+
+	names := bush.AuxNames()
+	e.u32(uint32(len(names)))
+	for _, name := range names {
+		e.u16(uint16(len(name)))
+		e.bytes([]byte(name))
+		e.aux(bush.aux[name])
+	}
+	if bush.splitAxes != nil {
+		e.bytes(bush.splitAxes)
+	}
+	if bush.projection != nil {
+		e.u8(projKind)
+		e.f64(projA)
+		e.f64(projB)
+	}
+	e.w = bw
+	e.u32(crc.Sum32())
+
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// Reads the index, written by Save
+func Load(r io.Reader) (*KDBush, error) {
+	br := bufio.NewReader(r)
+	crc := crc32.NewIEEE()
+	d := &decoder{r: io.TeeReader(br, crc)}
+
+	magic := d.bytes(len(formatMagic))
+	version := d.u8()
+	if d.err != nil || string(magic) != formatMagic {
+		return nil, ErrInvalidFormat
+	}
+	axes := version == formatVersionAxes
+	projected := false
+	switch version {
+	case formatVersion, formatVersionAxes:
+	case formatVersionChecked:
+		if order := d.u16(); d.err == nil && order != formatByteOrder {
+			return nil, fmt.Errorf("%w: unsupported byte order %#04x", ErrInvalidFormat, order)
+		}
+		flags := d.u8()
+		if d.err == nil && flags&^(formatFlagAxes|formatFlagProjection) != 0 {
+			return nil, fmt.Errorf("%w: unsupported flags %#02x", ErrInvalidFormat, flags)
+		}
+		axes = flags&formatFlagAxes != 0
+		projected = flags&formatFlagProjection != 0
+	default:
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+
+	bush := &KDBush{}
+	bush.NodeSize = int(d.u32())
+	count := d.length()
+	bush.input = d.length()
+	if d.err != nil {
+		return nil, d.err
+	}
+	bush.Idxs = decodeSlice(d, count, func() int { return int(d.u32()) })
+	bush.Coords = decodeSlice(d, 2*count, d.f64)
+
+	words := d.length()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if words > 0 {
+		bush.removed = decodeSlice(d, words, d.u64)
+		for _, idx := range bush.Idxs {
+			if bush.IsRemoved(idx) {
+				bush.removedCount++
+			}
+		}
+	}
+
+	auxCount := int(d.u32())
+	for i := 0; i < auxCount && d.err == nil; i++ {
+		name := string(d.bytes(int(d.u16())))
+		values := d.aux()
+		if d.err != nil {
+			break
+		}
+		if auxLen(values) != bush.input {
+			return nil, fmt.Errorf("%w: aux array %q has wrong length", ErrInvalidFormat, name)
+		}
+		if bush.aux == nil {
+			bush.aux = map[string]interface{}{}
+		}
+		bush.aux[name] = values
+	}
+	if axes {
+		bush.splitAxes = d.bytes(count)
+		if i := invalidAxis(bush.splitAxes); d.err == nil && i >= 0 {
+			return nil, fmt.Errorf("%w: invalid split axis %d on position %d", ErrInvalidFormat, bush.splitAxes[i], i)
+		}
+	}
+	if projected {
+		kind, a, b := d.u8(), d.f64(), d.f64()
+		if d.err == nil {
+			var err error
+			if bush.projection, err = decodeProjection(kind, a, b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if version == formatVersionChecked {
+		d.checksum(br, crc)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	for _, idx := range bush.Idxs {
+		if idx >= bush.input {
+			return nil, fmt.Errorf("%w: index %d is out of range", ErrInvalidFormat, idx)
+		}
+	}
+	bush.derive()
+	return bush, nil
+}
+
+type encoder struct {
+	w   io.Writer
+	buf [8]byte
+	err error
+}
+
+func (e *encoder) bytes(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) u8(v uint8) {
+	e.buf[0] = v
+	e.bytes(e.buf[:1])
+}
+
+func (e *encoder) u16(v uint16) {
+	binary.LittleEndian.PutUint16(e.buf[:], v)
+	e.bytes(e.buf[:2])
+}
+
+func (e *encoder) u32(v uint32) {
+	binary.LittleEndian.PutUint32(e.buf[:], v)
+	e.bytes(e.buf[:4])
+}
+
+func (e *encoder) u64(v uint64) {
+	binary.LittleEndian.PutUint64(e.buf[:], v)
+	e.bytes(e.buf[:8])
+}
+
+func (e *encoder) f64(v float64) {
+	e.u64(math.Float64bits(v))
+}
+
+func (e *encoder) aux(values interface{}) {
+	switch v := values.(type) {
+	case []float64:
+		e.u8(auxFloat64)
+		e.u64(uint64(len(v)))
+		for _, x := range v {
+			e.f64(x)
+		}
+	case []float32:
+		e.u8(auxFloat32)
+		e.u64(uint64(len(v)))
+		for _, x := range v {
+			e.u32(math.Float32bits(x))
+		}
+	case []int64:
+		e.u8(auxInt64)
+		e.u64(uint64(len(v)))
+		for _, x := range v {
+			e.u64(uint64(x))
+		}
+	case []int32:
+		e.u8(auxInt32)
+		e.u64(uint64(len(v)))
+		for _, x := range v {
+			e.u32(uint32(x))
+		}
+	case []uint32:
+		e.u8(auxUint32)
+		e.u64(uint64(len(v)))
+		for _, x := range v {
+			e.u32(x)
+		}
+	case []uint8:
+		e.u8(auxUint8)
+		e.u64(uint64(len(v)))
+		e.bytes(v)
+	}
+}
+
+type decoder struct {
+	r   io.Reader
+	buf [8]byte
+	err error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return d.buf[:n]
+	}
+	if _, err := io.ReadFull(d.r, d.buf[:n]); err != nil {
+		d.err = fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	return d.buf[:n]
+}
+
+func (d *decoder) bytes(n int) []byte {
+	b := make([]byte, 0, min(n, decodeChunk))
+	for len(b) < n && d.err == nil {
+		m := min(n-len(b), decodeChunk)
+		b = append(b, make([]byte, m)...)
+		if _, err := io.ReadFull(d.r, b[len(b)-m:]); err != nil {
+			d.err = fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+		}
+	}
+	return b
+}
+
+// reads n values, the slice grows as values are actually read, so a corrupted length fails on the end of data
+// instead of a huge allocation before the checksum is verified
+func decodeSlice[T any](d *decoder, n int, read func() T) []T {
+	v := make([]T, 0, min(n, decodeChunk))
+	for len(v) < n && d.err == nil {
+		v = append(v, read())
+	}
+	return v
+}
+
+// reads the checksum from r, that is not hashed, and compares it with the checksum of data read so far
+func (d *decoder) checksum(r io.Reader, crc hash.Hash32) {
+	sum := crc.Sum32()
+	d.r = r
+	if stored := d.u32(); d.err == nil && stored != sum {
+		d.err = ErrChecksum
+	}
+}
+
+func (d *decoder) u8() uint8 {
+	return d.read(1)[0]
+}
+
+func (d *decoder) u16() uint16 {
+	return binary.LittleEndian.Uint16(d.read(2))
+}
+
+func (d *decoder) u32() uint32 {
+	return binary.LittleEndian.Uint32(d.read(4))
+}
+
+func (d *decoder) u64() uint64 {
+	return binary.LittleEndian.Uint64(d.read(8))
+}
+
+func (d *decoder) f64() float64 {
+	return math.Float64frombits(d.u64())
+}
+
+// reads array length and checks it fits into int32, arrays are read with decodeSlice, so the length isn't trusted for allocation
+func (d *decoder) length() int {
+	n := d.u64()
+	if d.err == nil && n > math.MaxInt32 {
+		d.err = fmt.Errorf("%w: length %d is too big", ErrInvalidFormat, n)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) aux() interface{} {
+	tag := d.u8()
+	n := d.length()
+	if d.err != nil {
+		return nil
+	}
+	switch tag {
+	case auxFloat64:
+		return decodeSlice(d, n, d.f64)
+	case auxFloat32:
+		return decodeSlice(d, n, func() float32 { return math.Float32frombits(d.u32()) })
+	case auxInt64:
+		return decodeSlice(d, n, func() int64 { return int64(d.u64()) })
+	case auxInt32:
+		return decodeSlice(d, n, func() int32 { return int32(d.u32()) })
+	case auxUint32:
+		return decodeSlice(d, n, d.u32)
+	case auxUint8:
+		return d.bytes(n)
+	}
+	d.err = fmt.Errorf("%w: unknown aux type %d", ErrInvalidFormat, tag)
+	return nil
+}
@@ -0,0 +1,122 @@
+package kdbush
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_SaveLoad(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	bush.Remove(60)
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, bush.Save(&buf)) {
+		return
+	}
+	loaded, err := Load(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Nil(t, loaded.Points)
+	assert.Equal(t, 10, loaded.NodeSize)
+	assert.Equal(t, bush.Idxs, loaded.Idxs)
+	assert.Equal(t, bush.Coords, loaded.Coords)
+	assert.True(t, loaded.IsRemoved(60))
+	assert.Equal(t, 1, loaded.RemovedCount())
+	assert.Equal(t, bush.Range(20, 30, 50, 70), loaded.Range(20, 30, 50, 70))
+}
+
+func TestKDBush_SaveLoadAux(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	n := len(testPoints)
+	weights := make([]float64, n)
+	masks := make([]uint8, n)
+	times := make([]int64, n)
+	for i := 0; i < n; i++ {
+		weights[i] = float64(i) / 2
+		masks[i] = uint8(i % 3)
+		times[i] = int64(i) * 1000000007
+	}
+	assert.NoError(t, SetAux(bush, "weight", weights))
+	assert.NoError(t, SetAux(bush, "mask", masks))
+	assert.NoError(t, SetAux(bush, "time", times))
+	assert.Error(t, SetAux(bush, "short", []float32{1, 2}))
+	assert.Equal(t, []string{"mask", "time", "weight"}, bush.AuxNames())
+
+	var buf bytes.Buffer
+	assert.NoError(t, bush.Save(&buf))
+	loaded, err := Load(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w, ok := GetAux[float64](loaded, "weight")
+	assert.True(t, ok)
+	assert.Equal(t, weights, w)
+	m, ok := GetAux[uint8](loaded, "mask")
+	assert.True(t, ok)
+	assert.Equal(t, masks, m)
+	ts, ok := GetAux[int64](loaded, "time")
+	assert.True(t, ok)
+	assert.Equal(t, times, ts)
+
+	_, ok = GetAux[float32](loaded, "weight")
+	assert.False(t, ok)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("garbage data")))
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+
+	var buf bytes.Buffer
+	NewBush(getTestPoints(), 10).Save(&buf)
+	_, err = Load(bytes.NewReader(buf.Bytes()[:buf.Len()/2]))
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+
+	//damaged count is not trusted for allocation before the data is read
+	damaged := append([]byte{}, buf.Bytes()...)
+	binary.LittleEndian.PutUint64(damaged[12:], math.MaxInt32)
+	binary.LittleEndian.PutUint64(damaged[20:], math.MaxInt32)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = Load(bytes.NewReader(damaged))
+	runtime.ReadMemStats(&after)
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(16<<20))
+}
+
+func TestLoad_Versions(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	var buf bytes.Buffer
+	assert.NoError(t, bush.Save(&buf))
+	data := buf.Bytes()
+	assert.Equal(t, []byte{'K', 'D', 'B', 'G', 3, 0x02, 0x01, 0}, data[:8])
+
+	//version 1 snapshot: no byte order mark, flags and checksum
+	v1 := append([]byte("KDBG\x01"), data[8:len(data)-4]...)
+	loaded, err := Load(bytes.NewReader(v1))
+	if assert.NoError(t, err) {
+		assert.Equal(t, bush.Idxs, loaded.Idxs)
+		assert.Equal(t, bush.Coords, loaded.Coords)
+	}
+
+	damaged := append([]byte{}, data...)
+	damaged[100] ^= 1
+	_, err = Load(bytes.NewReader(damaged))
+	assert.True(t, errors.Is(err, ErrChecksum))
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+
+	for _, header := range [][]byte{{'K', 'D', 'B', 'G', 4}, {'K', 'D', 'B', 'G', 3, 0x01, 0x02, 0}, {'K', 'D', 'B', 'G', 3, 0x02, 0x01, 0x80}} {
+		changed := append(append([]byte{}, header...), data[len(header):]...)
+		_, err = Load(bytes.NewReader(changed))
+		assert.True(t, errors.Is(err, ErrInvalidFormat), "header %v", header)
+		assert.False(t, errors.Is(err, ErrChecksum), "header %v", header)
+	}
+}
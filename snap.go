@@ -0,0 +1,49 @@
+package kdbush
+
+import (
+	"fmt"
+	"math"
+	sorting "sort"
+)
+
+// Representative point of a grid cell with number of points in the cell
+type SnappedPoint struct {
+	CellX, CellY int64   //cell of the grid: floor(x / cellSize), floor(y / cellSize)
+	Index        int     //index of the representative point, the lowest index of the points in the cell
+	X, Y         float64 //coordinates of the representative point
+	Count        int     //number of points of the cell within the bounding box
+}
+
+// Finds all items within the given bounding box and snaps them to the grid of cellSize cells aligned to 0, 0.
+// Returns one representative per non empty cell, sorted by CellY, then CellX. Only cells are kept during traversal,
+// not all found points, so it's cheap for low zoom rendering of huge ranges. Panics if cellSize is not positive.
+func (bush *KDBush) RangeSnapped(minX, minY, maxX, maxY, cellSize float64) []SnappedPoint {
+	if !(cellSize > 0) {
+		panic(fmt.Sprintf("kdbush: invalid cell size %v", cellSize))
+	}
+	cells := map[[2]int64]int{} //cell -> position in result
+	result := []SnappedPoint{}
+	bush.search(minX, minY, maxX, maxY, &queryConfig{}, func(i int) bool {
+		x, y := bush.x(i), bush.y(i)
+		cell := [2]int64{int64(math.Floor(x / cellSize)), int64(math.Floor(y / cellSize))}
+		idx := bush.idx(i)
+		if r, ok := cells[cell]; ok {
+			s := &result[r]
+			s.Count++
+			if idx < s.Index {
+				s.Index, s.X, s.Y = idx, x, y
+			}
+			return true
+		}
+		cells[cell] = len(result)
+		result = append(result, SnappedPoint{cell[0], cell[1], idx, x, y, 1})
+		return true
+	})
+	sorting.Slice(result, func(i, j int) bool {
+		if result[i].CellY != result[j].CellY {
+			return result[i].CellY < result[j].CellY
+		}
+		return result[i].CellX < result[j].CellX
+	})
+	return result
+}
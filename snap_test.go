@@ -0,0 +1,42 @@
+package kdbush
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_RangeSnapped(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+
+	snapped := bush.RangeSnapped(20, 30, 50, 70, 10)
+	found := bush.Range(20, 30, 50, 70)
+	total := 0
+	for i, s := range snapped {
+		total += s.Count
+		x, y := points[s.Index].Coordinates()
+		assert.Equal(t, [2]float64{x, y}, [2]float64{s.X, s.Y})
+		assert.Equal(t, [2]int64{int64(math.Floor(x / 10)), int64(math.Floor(y / 10))}, [2]int64{s.CellX, s.CellY})
+		for _, idx := range found {
+			px, py := points[idx].Coordinates()
+			if int64(math.Floor(px/10)) == s.CellX && int64(math.Floor(py/10)) == s.CellY {
+				assert.True(t, s.Index <= idx)
+			}
+		}
+		if i > 0 {
+			prev := snapped[i-1]
+			assert.True(t, prev.CellY < s.CellY || prev.CellY == s.CellY && prev.CellX < s.CellX)
+		}
+	}
+	assert.Equal(t, len(found), total)
+
+	//one cell for everything
+	all := bush.RangeSnapped(0, 0, 100, 100, 1000)
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, len(points), all[0].Count)
+		assert.Equal(t, 0, all[0].Index)
+	}
+	assert.Panics(t, func() { bush.RangeSnapped(0, 0, 1, 1, 0) })
+}
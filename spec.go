@@ -0,0 +1,247 @@
+package kdbush
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// number of source records read between checks of the context
+const specCheckRecords = 4096
+
+// Declarative description of an index build, e.g. decoded from a JSON or YAML config of a data pipeline job:
+//
+//	{"source": "pois.csv", "header": true, "x": "lon", "y": "lat", "crs": "EPSG:3857", "nodeSize": 32, "output": "pois.kdb"}
+type BuildSpec struct {
+	Source string `json:"source" yaml:"source"`                     //path of the source file
+	Format string `json:"format,omitempty" yaml:"format,omitempty"` //csv (default), tsv or ndjson
+
+	//Columns of x (longitude) and y (latitude): header names or zero-based numbers for csv and tsv, field names for ndjson.
+	//Empty means the first and the second column for csv and tsv, "x" and "y" fields for ndjson.
+	X      string `json:"x,omitempty" yaml:"x,omitempty"`
+	Y      string `json:"y,omitempty" yaml:"y,omitempty"`
+	Header bool   `json:"header,omitempty" yaml:"header,omitempty"` //the first line of csv or tsv is a header
+
+	//Coordinate system of the index, source coordinates are lng, lat degrees if it's set:
+	//EPSG:4326 keeps lng, lat, EPSG:3857 projects them with WebMercator, EPSG:326zz and EPSG:327zz with UTM zone zz.
+	//Empty means coordinates are indexed as they are.
+	CRS string `json:"crs,omitempty" yaml:"crs,omitempty"`
+
+	NodeSize int    `json:"nodeSize,omitempty" yaml:"nodeSize,omitempty"` //DefaultNodeSize if 0
+	Output   string `json:"output,omitempty" yaml:"output,omitempty"`     //path to Save the index to, empty means the index is not saved
+}
+
+// Reads points of the source, builds the index and saves it to the output, if it's set.
+// Output is written to a temporary file in the same directory and renamed, so readers never see a partial index.
+// Build is canceled with the context, invalid spec returns ErrInvalidOption error.
+func BuildFromSpec(ctx context.Context, spec BuildSpec) (*KDBush, error) {
+	projection, err := specProjection(spec.CRS)
+	if err != nil {
+		return nil, err
+	}
+	nodeSize := spec.NodeSize
+	if nodeSize == 0 {
+		nodeSize = DefaultNodeSize
+	}
+	cfg := &buildConfig{nodeSize: nodeSize, sortThreshold: DefaultSortThreshold, workers: 1, projection: projection}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if spec.Source == "" {
+		return nil, fmt.Errorf("%w: build spec has no source", ErrInvalidOption)
+	}
+
+	f, err := os.Open(spec.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var pairs [][2]float64
+	switch strings.ToLower(spec.Format) {
+	case "", "csv":
+		pairs, err = readSpecCSV(ctx, f, ',', spec)
+	case "tsv":
+		pairs, err = readSpecCSV(ctx, f, '\t', spec)
+	case "ndjson":
+		pairs, err = readSpecNDJSON(ctx, f, spec)
+	default:
+		return nil, fmt.Errorf("%w: unknown source format %q", ErrInvalidOption, spec.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kdbush: %s: %w", spec.Source, err)
+	}
+
+	bush := &KDBush{}
+	bush.buildFrom(len(pairs), func(i int) (float64, float64) { return pairs[i][0], pairs[i][1] }, nodeSize, cfg)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if spec.Output != "" {
+		if err := saveAtomically(bush, spec.Output); err != nil {
+			return nil, err
+		}
+	}
+	return bush, nil
+}
+
+// projection of the coordinate system by its EPSG code
+func specProjection(crs string) (Projection, error) {
+	code := strings.ToUpper(strings.TrimSpace(crs))
+	switch code {
+	case "", "EPSG:4326":
+		return nil, nil
+	case "EPSG:3857":
+		return WebMercator, nil
+	}
+	if strings.HasPrefix(code, "EPSG:") {
+		n, _ := strconv.Atoi(code[len("EPSG:"):])
+		switch {
+		case n > 32600 && n <= 32660:
+			return UTM{Zone: n - 32600, North: true}, nil
+		case n > 32700 && n <= 32760:
+			return UTM{Zone: n - 32700}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unsupported CRS %q", ErrInvalidOption, crs)
+}
+
+func readSpecCSV(ctx context.Context, r io.Reader, comma rune, spec BuildSpec) ([][2]float64, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	xCol, yCol := 0, 1
+	resolve := func(header []string) error {
+		var err error
+		if xCol, err = specColumn(spec.X, 0, header); err != nil {
+			return err
+		}
+		yCol, err = specColumn(spec.Y, 1, header)
+		return err
+	}
+	if !spec.Header {
+		if err := resolve(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	pairs := [][2]float64{}
+	for line := 1; ; line++ {
+		if line%specCheckRecords == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if spec.Header && line == 1 {
+			if err := resolve(rec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if xCol >= len(rec) || yCol >= len(rec) {
+			return nil, fmt.Errorf("line %d: expected at least %d columns", line, iMax(xCol, yCol)+1)
+		}
+		x, err := strconv.ParseFloat(strings.TrimSpace(rec[xCol]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		y, err := strconv.ParseFloat(strings.TrimSpace(rec[yCol]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		pairs = append(pairs, [2]float64{x, y})
+	}
+}
+
+// number of the column by its header name or number, def if the column is not set
+func specColumn(col string, def int, header []string) (int, error) {
+	if col == "" {
+		return def, nil
+	}
+	for i, name := range header {
+		if strings.TrimSpace(name) == col {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(col); err == nil && n >= 0 {
+		return n, nil
+	}
+	return 0, fmt.Errorf("%w: no column %q", ErrInvalidOption, col)
+}
+
+func readSpecNDJSON(ctx context.Context, r io.Reader, spec BuildSpec) ([][2]float64, error) {
+	xField, yField := spec.X, spec.Y
+	if xField == "" {
+		xField = "x"
+	}
+	if yField == "" {
+		yField = "y"
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	pairs := [][2]float64{}
+	for line := 1; sc.Scan(); line++ {
+		if line%specCheckRecords == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var rec map[string]json.RawMessage
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		var p [2]float64
+		for i, field := range []string{xField, yField} {
+			raw, ok := rec[field]
+			if !ok {
+				return nil, fmt.Errorf("line %d: no field %q", line, field)
+			}
+			if err := json.Unmarshal(raw, &p[i]); err != nil {
+				return nil, fmt.Errorf("line %d: field %q: %v", line, field, err)
+			}
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, sc.Err()
+}
+
+// saves the index to a temporary file next to the path and renames it to the path
+func saveAtomically(bush *KDBush, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := bush.Save(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
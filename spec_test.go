@@ -0,0 +1,85 @@
+package kdbush
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSpecSource(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	return path
+}
+
+func TestBuildFromSpec(t *testing.T) {
+	csvPath := writeSpecSource(t, "pois.csv", "name,lat,lon\na,10,20\nb,11,21\nc,-5,30\n")
+	out := filepath.Join(filepath.Dir(csvPath), "pois.kdb")
+
+	var spec BuildSpec
+	assert.NoError(t, json.Unmarshal([]byte(`{"source": "`+csvPath+`", "header": true, "x": "lon", "y": "lat", "nodeSize": 2, "output": "`+out+`"}`), &spec))
+	bush, err := BuildFromSpec(context.Background(), spec)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, bush.NodeSize)
+	assert.Equal(t, []int{0, 1}, sortedInts(bush.Range(19, 9, 22, 12)))
+
+	f, err := os.Open(out)
+	if assert.NoError(t, err) {
+		loaded, err := Load(f)
+		f.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, bush.Idxs, loaded.Idxs)
+		assert.Equal(t, bush.Coords, loaded.Coords)
+	}
+	tmp, _ := filepath.Glob(filepath.Join(filepath.Dir(out), "*.tmp"))
+	assert.Empty(t, tmp)
+
+	//columns by number, default node size
+	tsvPath := writeSpecSource(t, "pois.tsv", "1\t20\t10\n2\t21\t11\n")
+	bush, err = BuildFromSpec(context.Background(), BuildSpec{Source: tsvPath, Format: "tsv", X: "1", Y: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultNodeSize, bush.NodeSize)
+	assert.Equal(t, []int{1}, bush.Range(20.5, 10.5, 22, 12))
+
+	ndjsonPath := writeSpecSource(t, "pois.ndjson", `{"lng": 20, "lat": 10}`+"\n\n"+`{"lng": 21, "lat": 11}`+"\n")
+	bush, err = BuildFromSpec(context.Background(), BuildSpec{Source: ndjsonPath, Format: "ndjson", X: "lng", Y: "lat", CRS: "EPSG:3857"})
+	assert.NoError(t, err)
+	assert.Equal(t, WebMercator, bush.Projection())
+	result, _ := bush.RangeWithOptions(20.5, 10.5, 22, 12, FromLngLat())
+	assert.Equal(t, []int{1}, result)
+
+	bush, err = BuildFromSpec(context.Background(), BuildSpec{Source: ndjsonPath, Format: "ndjson", X: "lng", Y: "lat", CRS: "EPSG:32634"})
+	assert.NoError(t, err)
+	assert.Equal(t, UTM{Zone: 34, North: true}, bush.Projection())
+}
+
+func TestBuildFromSpec_Errors(t *testing.T) {
+	csvPath := writeSpecSource(t, "pois.csv", "x,y\n1,2\n")
+	ctx := context.Background()
+	for _, spec := range []BuildSpec{
+		{},
+		{Source: csvPath, Format: "xml"},
+		{Source: csvPath, CRS: "EPSG:2154"},
+		{Source: csvPath, NodeSize: -1},
+		{Source: csvPath, Header: true, X: "lon"},
+	} {
+		_, err := BuildFromSpec(ctx, spec)
+		assert.ErrorIs(t, err, ErrInvalidOption, "%+v", spec)
+	}
+
+	_, err := BuildFromSpec(ctx, BuildSpec{Source: csvPath})
+	assert.Error(t, err, "header line is not a number")
+	_, err = BuildFromSpec(ctx, BuildSpec{Source: filepath.Join(t.TempDir(), "missing.csv")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = BuildFromSpec(canceled, BuildSpec{Source: csvPath, Header: true})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,232 @@
+// Package sqlstore stores serialized kdbush indexes in SQL database, e.g. SQLite used as artifact store.
+//
+// Every saved index gets new version number. Serialized index is streamed into the database
+// in chunks, so it's never held in memory as one big blob, and it's streamed back the same way on load.
+// Package uses only database/sql, any driver with ? placeholders works (SQLite, MySQL).
+//
+// Schema, created by CreateSchema for table name "indexes":
+//
+//	indexes(id TEXT, version INTEGER, meta TEXT, size INTEGER, PRIMARY KEY(id, version))
+//	indexes_chunks(id TEXT, version INTEGER, seq INTEGER, data BLOB, PRIMARY KEY(id, version, seq))
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+// Default size of one chunk of serialized index
+const DefaultChunkSize = 1 << 20
+
+// Returned by Load when there is no such index or version
+var ErrNotFound = errors.New("sqlstore: index not found")
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Store of serialized indexes in the table of the database
+type Store struct {
+	db        *sql.DB
+	table     string
+	ChunkSize int
+}
+
+// Creates store over the table, table name should be a valid SQL identifier
+func New(db *sql.DB, table string) (*Store, error) {
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("sqlstore: invalid table name %q", table)
+	}
+	return &Store{db: db, table: table, ChunkSize: DefaultChunkSize}, nil
+}
+
+// Creates tables of the store if they don't exist
+func (s *Store) CreateSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id TEXT NOT NULL, version INTEGER NOT NULL, meta TEXT, size INTEGER NOT NULL,
+		PRIMARY KEY (id, version))`, s.table))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_chunks (
+		id TEXT NOT NULL, version INTEGER NOT NULL, seq INTEGER NOT NULL, data BLOB NOT NULL,
+		PRIMARY KEY (id, version, seq))`, s.table))
+	return err
+}
+
+// Saves the index under id as the new version, returns the version number
+func (s *Store) Save(ctx context.Context, id string, bush *kdbush.KDBush, meta string) (version int64, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	row := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(version), 0) FROM %s WHERE id = ?`, s.table), id)
+	if err = row.Scan(&version); err != nil {
+		return 0, err
+	}
+	version++
+
+	w := &chunkWriter{ctx: ctx, tx: tx, store: s, id: id, version: version, buf: make([]byte, 0, s.chunkSize())}
+	if err = bush.Save(w); err != nil {
+		return 0, err
+	}
+	if err = w.flush(); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (id, version, meta, size) VALUES (?, ?, ?, ?)`, s.table),
+		id, version, meta, w.size)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// Loads the version of the index with id, version 0 means the latest one.
+// Returns the index and its meta.
+func (s *Store) Load(ctx context.Context, id string, version int64) (*kdbush.KDBush, string, error) {
+	var meta sql.NullString
+	var row *sql.Row
+	if version == 0 {
+		row = s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT version, meta FROM %s WHERE id = ? ORDER BY version DESC LIMIT 1`, s.table), id)
+	} else {
+		row = s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT version, meta FROM %s WHERE id = ? AND version = ?`, s.table), id, version)
+	}
+	if err := row.Scan(&version, &meta); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT data FROM %s_chunks WHERE id = ? AND version = ? ORDER BY seq`, s.table), id, version)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	bush, err := kdbush.Load(&chunkReader{rows: rows})
+	if err != nil {
+		return nil, "", err
+	}
+	return bush, meta.String, rows.Err()
+}
+
+// Returns all versions of the index with id, ascending
+func (s *Store) Versions(ctx context.Context, id string) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT version FROM %s WHERE id = ? ORDER BY version`, s.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := []int64{}
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// Deletes the version of the index with id, version 0 deletes all versions
+func (s *Store) Delete(ctx context.Context, id string, version int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	where, args := `WHERE id = ? AND version = ?`, []interface{}{id, version}
+	if version == 0 {
+		where, args = `WHERE id = ?`, []interface{}{id}
+	}
+	for _, table := range []string{s.table, s.table + "_chunks"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s %s`, table, where), args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) chunkSize() int {
+	if s.ChunkSize <= 0 {
+		return DefaultChunkSize
+	}
+	return s.ChunkSize
+}
+
+// writes serialized index into chunk rows
+type chunkWriter struct {
+	ctx     context.Context
+	tx      *sql.Tx
+	store   *Store
+	id      string
+	version int64
+	seq     int
+	size    int64
+	buf     []byte
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		p = p[k:]
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *chunkWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.tx.ExecContext(w.ctx, fmt.Sprintf(`INSERT INTO %s_chunks (id, version, seq, data) VALUES (?, ?, ?, ?)`, w.store.table),
+		w.id, w.version, w.seq, w.buf)
+	if err != nil {
+		return err
+	}
+	w.seq++
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// reads serialized index from chunk rows
+type chunkReader struct {
+	rows *sql.Rows
+	buf  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if !r.rows.Next() {
+			if err := r.rows.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		if err := r.rows.Scan(&r.buf); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,64 @@
+//go:build sqlite
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/MadAppGang/kdbush"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	store, err := New(db, "indexes")
+	assert.NoError(t, err)
+	store.ChunkSize = 100
+	assert.NoError(t, store.CreateSchema(ctx))
+
+	pairs := make([][2]float64, 1000)
+	for i := range pairs {
+		pairs[i] = [2]float64{float64(i % 37), float64(i % 91)}
+	}
+	bush := kdbush.NewBushFromPairs(pairs, 16)
+
+	v1, err := store.Save(ctx, "poi", bush, `{"source":"test"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v1)
+	bush.RemoveRange(0, 0, 10, 10)
+	v2, err := store.Save(ctx, "poi", bush, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), v2)
+
+	loaded, meta, err := store.Load(ctx, "poi", 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"source":"test"}`, meta)
+		assert.Equal(t, bush.Coords, loaded.Coords)
+		assert.Zero(t, loaded.RemovedCount())
+	}
+	latest, _, err := store.Load(ctx, "poi", 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, bush.Range(0, 0, 50, 50), latest.Range(0, 0, 50, 50))
+	}
+
+	versions, err := store.Versions(ctx, "poi")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions)
+
+	assert.NoError(t, store.Delete(ctx, "poi", 0))
+	_, _, err = store.Load(ctx, "poi", 0)
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = New(db, "bad; DROP TABLE x")
+	assert.Error(t, err)
+}
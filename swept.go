@@ -0,0 +1,85 @@
+package kdbush
+
+import (
+	"math"
+)
+
+// Finds all items within a given radius from the segment p0-p1, swept by a moving query point, and returns an array of indices.
+// The query area is a capsule, subtrees are pruned by their distance to the segment, not by the bounding box of the capsule.
+func (bush *KDBush) WithinSwept(p0, p1 Point, radius float64) []int {
+	result := []int{}
+	if bush.size() == 0 {
+		return result
+	}
+	ax, ay := p0.Coordinates()
+	bx, by := p1.Coordinates()
+	r2 := radius * radius
+	add := func(i int) {
+		if segDistSq(bush.x(i), bush.y(i), ax, ay, bx, by) <= r2 && !bush.removedAt(i) {
+			result = append(result, bush.idx(i))
+		}
+	}
+
+	stack := []treeNode{bush.rootNode()}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.right < n.left || segBoxDistSq(ax, ay, bx, by, n) > r2 {
+			continue
+		}
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				add(i)
+			}
+			continue
+		}
+		m := floor(float64(n.left+n.right) / 2.0)
+		add(m)
+		l, r := bush.children(n, m)
+		stack = append(stack, r, l)
+	}
+	return result
+}
+
+// squared distance from the point x, y to the segment a-b
+func segDistSq(x, y, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((x-ax)*dx+(y-ay)*dy)/l))
+	}
+	return sqrtDist(x, y, ax+t*dx, ay+t*dy)
+}
+
+// squared distance from the segment a-b to the box of the node, 0 if they intersect
+func segBoxDistSq(ax, ay, bx, by float64, n treeNode) float64 {
+	if segIntersectsBox(ax, ay, bx, by, n) {
+		return 0
+	}
+	//without intersection the closest points are an end of the segment or a corner of the box
+	d := math.Min(boxDistSq(ax, ay, n), boxDistSq(bx, by, n))
+	for _, c := range [4][2]float64{{n.minX, n.minY}, {n.minX, n.maxY}, {n.maxX, n.minY}, {n.maxX, n.maxY}} {
+		d = math.Min(d, segDistSq(c[0], c[1], ax, ay, bx, by))
+	}
+	return d
+}
+
+// Liang-Barsky clipping of the segment by the box
+func segIntersectsBox(ax, ay, bx, by float64, n treeNode) bool {
+	t0, t1 := 0.0, 1.0
+	clip := func(p, q float64) bool {
+		if p == 0 {
+			return q >= 0
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		return t0 <= t1
+	}
+	dx, dy := bx-ax, by-ay
+	return clip(-dx, ax-n.minX) && clip(dx, n.maxX-ax) && clip(-dy, ay-n.minY) && clip(dy, n.maxY-ay)
+}
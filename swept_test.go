@@ -0,0 +1,42 @@
+package kdbush
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_WithinSwept(t *testing.T) {
+	points := benchmarkPoints()[:20000]
+	bush := NewBush(points, 16)
+	rnd := rand.New(rand.NewSource(5))
+
+	for i := 0; i < 20; i++ {
+		p0 := &SimplePoint{X: rnd.Float64() * 1000, Y: rnd.Float64() * 1000}
+		p1 := &SimplePoint{X: p0.X + rnd.Float64()*200 - 100, Y: p0.Y + rnd.Float64()*200 - 100}
+		r := rnd.Float64() * 20
+
+		expected := []int{}
+		for idx, p := range points {
+			x, y := p.Coordinates()
+			if segDistSq(x, y, p0.X, p0.Y, p1.X, p1.Y) <= r*r {
+				expected = append(expected, idx)
+			}
+		}
+		assert.Equal(t, expected, sortedInts(bush.WithinSwept(p0, p1, r)))
+	}
+
+	//not moving point is the same as Within
+	q := &SimplePoint{X: 500, Y: 500}
+	assert.Equal(t, sortedInts(bush.Within(q, 30)), sortedInts(bush.WithinSwept(q, q, 30)))
+}
+
+func TestSegBoxDistSq(t *testing.T) {
+	n := treeNode{minX: 0, minY: 0, maxX: 10, maxY: 10}
+	assert.Equal(t, 0.0, segBoxDistSq(-5, 5, 15, 5, n))
+	assert.Equal(t, 0.0, segBoxDistSq(2, 2, 3, 3, n))
+	assert.Equal(t, 4.0, segBoxDistSq(-2, -5, -2, 15, n))
+	//diagonal segment passing near the corner
+	assert.InDelta(t, 2.0, segBoxDistSq(12, 10, 10, 12, n), 1e-9)
+}
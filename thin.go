@@ -0,0 +1,32 @@
+package kdbush
+
+// Decimates dense track: drops points closer than minDist to an already kept point, preserving order.
+// Returns indices of kept points in points slice, in increasing order.
+// Builds temporary index, use Thin if the points are already indexed.
+func ThinTrack(points []Point, minDist float64) []int {
+	return NewBush(points, 64).Thin(minDist)
+}
+
+// Same as ThinTrack for indexed points: visits points in order of original indices
+// and drops ones closer than minDist to an already kept point. Removed points are skipped.
+func (bush *KDBush) Thin(minDist float64) []int {
+	kept := []int{}
+	dropped := make([]bool, bush.originalCount())
+	r2 := minDist * minDist
+	cfg := &queryConfig{}
+	for idx := 0; idx < bush.posLen(); idx++ {
+		i := bush.treePos(idx)
+		if i < 0 || dropped[idx] || bush.removedAt(i) {
+			continue
+		}
+		kept = append(kept, idx)
+		x, y := bush.x(i), bush.y(i)
+		bush.search(x-minDist, y-minDist, x+minDist, y+minDist, cfg, func(j int) bool {
+			if bush.idx(j) > idx && sqrtDist(bush.x(j), bush.y(j), x, y) < r2 {
+				dropped[bush.idx(j)] = true
+			}
+			return true
+		})
+	}
+	return kept
+}
@@ -0,0 +1,39 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThinTrack(t *testing.T) {
+	track := []Point{
+		&SimplePoint{X: 0, Y: 0},
+		&SimplePoint{X: 0.5, Y: 0},
+		&SimplePoint{X: 1, Y: 0},
+		&SimplePoint{X: 1.2, Y: 0},
+		&SimplePoint{X: 2.5, Y: 0},
+		&SimplePoint{X: 0, Y: 0.2}, //back near the start
+		&SimplePoint{X: 4, Y: 0},
+	}
+	assert.Equal(t, []int{0, 2, 4, 6}, ThinTrack(track, 1))
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, ThinTrack(track, 0.1))
+	assert.Equal(t, []int{0}, ThinTrack(track, 10))
+	assert.Empty(t, ThinTrack(nil, 1))
+
+	bush := NewBush(track, 2)
+	bush.Remove(2)
+	assert.Equal(t, []int{0, 3, 4, 6}, bush.Thin(1))
+}
+
+func TestKDBush_Thin(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	kept := bush.Thin(15)
+	for i, a := range kept {
+		for _, b := range kept[:i] {
+			ax, ay := testPoints[a][0], testPoints[a][1]
+			bx, by := testPoints[b][0], testPoints[b][1]
+			assert.True(t, sqrtDist(ax, ay, bx, by) >= 15*15)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package kdbush
+
+// Tombstones: index stays static, but points could be marked as removed.
+// Removed points are skipped by all queries. Removal is not safe to run concurrently with queries.
+// Removal from frozen index panics with ErrFrozen.
+
+// Marks point with original index idx as removed.
+// Returns false if the point is not in the index or already removed.
+func (bush *KDBush) Remove(idx int) bool {
+	bush.mustNotBeFrozen()
+	if bush.TreePos(idx) < 0 || bush.IsRemoved(idx) {
+		return false
+	}
+	bush.markRemoved(idx)
+	return true
+}
+
+// Returns true if the point with original index idx was removed, false for indices out of range
+func (bush *KDBush) IsRemoved(idx int) bool {
+	if idx < 0 {
+		return false
+	}
+	w := idx >> 6
+	return w < len(bush.removed) && bush.removed[w]&(1<<uint(idx&63)) != 0
+}
+
+// Number of removed points
+func (bush *KDBush) RemovedCount() int {
+	return bush.removedCount
+}
+
+// Removes all points within the given bounding box in one traversal.
+// Returns number of removed points.
+func (bush *KDBush) RemoveRange(minX, minY, maxX, maxY float64) int {
+	bush.mustNotBeFrozen()
+	n := 0
+	bush.search(minX, minY, maxX, maxY, &queryConfig{}, func(i int) bool {
+		bush.markRemoved(bush.idx(i))
+		n++
+		return true
+	})
+	return n
+}
+
+// Removes all points within a given radius from the point in one traversal.
+// Returns number of removed points.
+func (bush *KDBush) RemoveWithin(point Point, radius float64) int {
+	bush.mustNotBeFrozen()
+	n := 0
+	r2 := radius * radius
+	qx, qy := point.Coordinates()
+	bush.search(qx-radius, qy-radius, qx+radius, qy+radius, &queryConfig{}, func(i int) bool {
+		if sqrtDist(bush.x(i), bush.y(i), qx, qy) <= r2 {
+			bush.markRemoved(bush.idx(i))
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+func (bush *KDBush) markRemoved(idx int) {
+	if bush.removed == nil {
+		bush.removed = make([]uint64, bush.originalCount()/64+1)
+	}
+	for idx>>6 >= len(bush.removed) {
+		bush.removed = append(bush.removed, 0)
+	}
+	bush.removed[idx>>6] |= 1 << uint(idx&63)
+	bush.removedCount++
+}
+
+// fast check for the point on position i in the tree
+func (bush *KDBush) removedAt(i int) bool {
+	return bush.removedCount > 0 && bush.IsRemoved(bush.idx(i))
+}
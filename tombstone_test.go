@@ -0,0 +1,46 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Remove(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.False(t, bush.IsRemoved(-1))
+
+	assert.True(t, bush.Remove(60))
+	for _, idx := range []int{-1, -64, 1000, 1 << 40} {
+		assert.False(t, bush.IsRemoved(idx), "%d", idx)
+	}
+	assert.False(t, bush.Remove(60))
+	assert.False(t, bush.Remove(1000))
+	assert.True(t, bush.IsRemoved(60))
+	assert.Equal(t, 1, bush.RemovedCount())
+
+	result := bush.Range(20, 30, 50, 70)
+	assert.Equal(t, []int{20, 45, 3, 17, 71, 44, 19, 18, 15, 69, 90, 62, 96, 47, 8, 77, 72}, result)
+	assert.Equal(t, -1, index(bush.Within(&SimplePoint{X: 50, Y: 50}, 20), 60))
+}
+
+func TestKDBush_RemoveRange(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	inRange := bush.Range(20, 30, 50, 70)
+	total := len(bush.Range(0, 0, 100, 100))
+
+	assert.Equal(t, len(inRange), bush.RemoveRange(20, 30, 50, 70))
+	assert.Equal(t, 0, bush.RemoveRange(20, 30, 50, 70))
+	assert.Empty(t, bush.Range(20, 30, 50, 70))
+	assert.Len(t, bush.Range(0, 0, 100, 100), total-len(inRange))
+	for _, idx := range inRange {
+		assert.True(t, bush.IsRemoved(idx))
+	}
+
+	point := &SimplePoint{X: 80, Y: 20}
+	within := bush.Within(point, 15)
+	assert.Equal(t, len(within), bush.RemoveWithin(point, 15))
+	assert.Empty(t, bush.Within(point, 15))
+	result, _ := bush.WithinWithOptions(point, 15)
+	assert.Empty(t, result)
+}
@@ -0,0 +1,35 @@
+package kdbush
+
+// Index with typed points: Points is []T, so points are not boxed into interfaces at build,
+// and query results could be returned as typed values directly.
+// Points field of the embedded KDBush is nil, all other methods work as usual.
+type TypedBush[T Point] struct {
+	*KDBush
+	Points []T
+}
+
+// Same as NewBushWithOptions, but for typed points
+func NewTypedBush[T Point](points []T, nodeSize int, opts ...Option) *TypedBush[T] {
+	b := &KDBush{}
+	cfg := newBuildConfig(opts)
+	points = copyPoints(points, cfg)
+	b.buildFrom(len(points), func(i int) (float64, float64) { return points[i].Coordinates() }, nodeSize, cfg)
+	return &TypedBush[T]{KDBush: b, Points: points}
+}
+
+// Results are typed points of the index
+func (b *TypedBush[T]) AsItems() ResultKind[T] {
+	return AsIDs(b.Points)
+}
+
+// Same as Range, but returns points instead of indices
+func (b *TypedBush[T]) RangeItems(minX, minY, maxX, maxY float64, opts ...QueryOption) []T {
+	result, _ := RangeAs(b.KDBush, b.AsItems(), minX, minY, maxX, maxY, opts...)
+	return result
+}
+
+// Same as Within, but returns points instead of indices
+func (b *TypedBush[T]) WithinItems(point Point, radius float64, opts ...QueryOption) []T {
+	result, _ := WithinAs(b.KDBush, b.AsItems(), point, radius, opts...)
+	return result
+}
@@ -0,0 +1,30 @@
+package kdbush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedBush(t *testing.T) {
+	points := make([]SimplePoint, len(testPoints))
+	ptrs := make([]*SimplePoint, len(testPoints))
+	for i, p := range testPoints {
+		points[i] = SimplePoint{X: p[0], Y: p[1]}
+		ptrs[i] = &points[i]
+	}
+	bush := NewTypedBush(ptrs, 10)
+	assert.Nil(t, bush.KDBush.Points)
+	assert.Equal(t, testIdxs, bush.Idxs)
+	assert.Equal(t, testCoords, bush.Coords)
+
+	expected := []*SimplePoint{}
+	for _, idx := range bush.Range(20, 30, 50, 70) {
+		expected = append(expected, ptrs[idx])
+	}
+	assert.Equal(t, expected, bush.RangeItems(20, 30, 50, 70))
+
+	//value types are not boxed
+	cities := NewTypedBush([]LngLat{{2.35, 48.85}, {-0.13, 51.51}, {13.4, 52.52}}, 10)
+	assert.Equal(t, []LngLat{{-0.13, 51.51}}, cities.WithinItems(LngLat{0, 51}, 1))
+}
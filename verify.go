@@ -0,0 +1,175 @@
+package kdbush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Returned by Verify when the index structure is broken
+var ErrCorrupted = errors.New("kdbush: index is corrupted")
+
+// Checks consistency of the index: sizes of arrays, indexes and KD-order of coordinates.
+// Useful for indexes loaded from untrusted sources.
+func (bush *KDBush) Verify() error {
+	if bush.coordsLen() != 2*bush.size() {
+		return fmt.Errorf("%w: %d coordinates for %d points", ErrCorrupted, bush.coordsLen(), bush.size())
+	}
+	if bush.NodeSize < 0 {
+		return fmt.Errorf("%w: negative node size %d", ErrCorrupted, bush.NodeSize)
+	}
+	if bush.splitAxes != nil && len(bush.splitAxes) != bush.size() {
+		return fmt.Errorf("%w: %d split axes for %d points", ErrCorrupted, len(bush.splitAxes), bush.size())
+	}
+	if i := invalidAxis(bush.splitAxes); i >= 0 {
+		return fmt.Errorf("%w: invalid split axis %d on position %d", ErrCorrupted, bush.splitAxes[i], i)
+	}
+	seen := make([]bool, bush.originalCount())
+	for i := 0; i < bush.size(); i++ {
+		idx := bush.idx(i)
+		if idx < 0 || idx >= len(seen) || seen[idx] {
+			return fmt.Errorf("%w: invalid index %d on position %d", ErrCorrupted, idx, i)
+		}
+		seen[idx] = true
+	}
+	return bush.verifyOrder(0, bush.size()-1, 0)
+}
+
+// checks, that coordinates of the subtree from left to right are in KD-order
+func (bush *KDBush) verifyOrder(left, right, axis int) error {
+	if right-left <= bush.NodeSize {
+		return nil
+	}
+	m := floor(float64(left+right) / 2.0)
+	axis = bush.splitAxis(m, axis)
+	median := bush.coord(m, axis)
+	for i := left; i < m; i++ {
+		if bush.coord(i, axis) > median {
+			return fmt.Errorf("%w: position %d is out of KD-order", ErrCorrupted, i)
+		}
+	}
+	for i := m + 1; i <= right; i++ {
+		if bush.coord(i, axis) < median {
+			return fmt.Errorf("%w: position %d is out of KD-order", ErrCorrupted, i)
+		}
+	}
+	if err := bush.verifyOrder(left, m-1, (axis+1)%2); err != nil {
+		return err
+	}
+	return bush.verifyOrder(m+1, right, (axis+1)%2)
+}
+
+// Returns original indexes of the points in the KD-order of the tree.
+// Points, reordered this way and indexed with the same node size and WithPresorted option, are not sorted again.
+func (bush *KDBush) SpatialOrder() []int {
+	order := make([]int, bush.size())
+	for i := range order {
+		order[i] = bush.idx(i)
+	}
+	return order
+}
+
+// Verifies the index in the background, checking at most budgetPerSecond points per second,
+// so the index could be trusted and used right after load, while bit rot is still detected eventually.
+// onCorrupt is called once from the background goroutine if the index is broken.
+// Returned channel is closed, when verification is finished or ctx is done.
+// budgetPerSecond <= 0 means no limit.
+func (bush *KDBush) VerifyAsync(ctx context.Context, budgetPerSecond int, onCorrupt func(error)) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := bush.verifyIncremental(ctx, budgetPerSecond); err != nil && !errors.Is(err, ctx.Err()) {
+			onCorrupt(err)
+		}
+	}()
+	return done
+}
+
+// number of budget refills per second of VerifyAsync
+const verifyTicks = 10
+
+// number of points VerifyAsync checks between checks of its context
+const verifyCancelCheck = 4096
+
+func (bush *KDBush) verifyIncremental(ctx context.Context, budgetPerSecond int) error {
+	if bush.coordsLen() != 2*bush.size() {
+		return fmt.Errorf("%w: %d coordinates for %d points", ErrCorrupted, bush.coordsLen(), bush.size())
+	}
+	if bush.splitAxes != nil && len(bush.splitAxes) != bush.size() {
+		return fmt.Errorf("%w: %d split axes for %d points", ErrCorrupted, len(bush.splitAxes), bush.size())
+	}
+	if i := invalidAxis(bush.splitAxes); i >= 0 {
+		return fmt.Errorf("%w: invalid split axis %d on position %d", ErrCorrupted, bush.splitAxes[i], i)
+	}
+	seen := make([]uint64, bush.originalCount()/64+1)
+	check := func(i int, n treeNode) error {
+		idx := bush.idx(i)
+		if idx < 0 || idx >= bush.originalCount() || seen[idx>>6]&(1<<uint(idx&63)) != 0 {
+			return fmt.Errorf("%w: invalid index %d on position %d", ErrCorrupted, idx, i)
+		}
+		seen[idx>>6] |= 1 << uint(idx&63)
+		x, y := bush.x(i), bush.y(i)
+		if x < n.minX || x > n.maxX || y < n.minY || y > n.maxY {
+			return fmt.Errorf("%w: position %d is out of KD-order", ErrCorrupted, i)
+		}
+		return nil
+	}
+
+	var ticker *time.Ticker
+	budget := math.MaxInt
+	if budgetPerSecond > 0 {
+		ticker = time.NewTicker(time.Second / verifyTicks)
+		defer ticker.Stop()
+		budget = iMax(1, budgetPerSecond/verifyTicks)
+	}
+	left := budget
+	//context is checked before the first point and then every verifyCancelCheck points, whatever the budget is
+	unchecked := verifyCancelCheck
+
+	//every point should be inside of the bounds, that medians of its ancestors define
+	inf := math.Inf(1)
+	stack := []treeNode{{0, bush.size() - 1, 0, -inf, -inf, inf, inf}}
+	for len(stack) > 0 {
+		if unchecked >= verifyCancelCheck {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			unchecked = 0
+		}
+		if left <= 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				left = budget
+			}
+		}
+
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.right < n.left {
+			continue
+		}
+		if n.right-n.left <= bush.NodeSize {
+			for i := n.left; i <= n.right; i++ {
+				if err := check(i, n); err != nil {
+					return err
+				}
+			}
+			left -= n.right - n.left + 1
+			unchecked += n.right - n.left + 1
+			continue
+		}
+		m := floor(float64(n.left+n.right) / 2.0)
+		if err := check(m, n); err != nil {
+			return err
+		}
+		left--
+		unchecked++
+		l, r := bush.children(n, m)
+		stack = append(stack, r, l)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package kdbush
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDBush_Verify(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	assert.NoError(t, bush.Verify())
+
+	bush.Coords[0], bush.Coords[len(bush.Coords)-2] = bush.Coords[len(bush.Coords)-2], bush.Coords[0]
+	assert.True(t, errors.Is(bush.Verify(), ErrCorrupted))
+
+	bush = NewBush(getTestPoints(), 10)
+	bush.Idxs[0] = bush.Idxs[1]
+	assert.True(t, errors.Is(bush.Verify(), ErrCorrupted))
+
+	bush = NewBush(getTestPoints(), 10)
+	bush.Coords = bush.Coords[:10]
+	assert.True(t, errors.Is(bush.Verify(), ErrCorrupted))
+}
+
+func TestWithPresorted(t *testing.T) {
+	points := getTestPoints()
+	bush := NewBush(points, 10)
+
+	order := bush.SpatialOrder()
+	sorted := make([]Point, len(order))
+	for i, idx := range order {
+		sorted[i] = points[idx]
+	}
+
+	presorted := NewBushWithOptions(sorted, 10, WithPresorted())
+	assert.Equal(t, bush.Coords, presorted.Coords)
+	for i, idx := range presorted.Idxs {
+		assert.Equal(t, i, idx)
+	}
+	assert.NoError(t, presorted.Verify())
+
+	//not sorted input falls back to the usual build
+	fallback := NewBushWithOptions(points, 10, WithPresorted())
+	assert.Equal(t, testIdxs, fallback.Idxs)
+	assert.Equal(t, testCoords, fallback.Coords)
+}
+
+func TestKDBush_VerifyAsync(t *testing.T) {
+	ctx := context.Background()
+	bush := NewBush(getTestPoints(), 10)
+	var reported error
+	<-bush.VerifyAsync(ctx, 0, func(err error) { reported = err })
+	assert.NoError(t, reported)
+
+	bush.Coords[0], bush.Coords[len(bush.Coords)-2] = bush.Coords[len(bush.Coords)-2], bush.Coords[0]
+	<-bush.VerifyAsync(ctx, 50, func(err error) { reported = err })
+	assert.True(t, errors.Is(reported, ErrCorrupted))
+
+	bush = NewBush(getTestPoints(), 10)
+	bush.Idxs[5] = bush.Idxs[50]
+	reported = nil
+	<-bush.VerifyAsync(ctx, 0, func(err error) { reported = err })
+	assert.True(t, errors.Is(reported, ErrCorrupted))
+}
+
+func TestKDBush_VerifyAsyncBudget(t *testing.T) {
+	bush := NewBush(getTestPoints(), 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	reported := false
+	start := time.Now()
+	<-bush.VerifyAsync(ctx, 10, func(err error) { reported = true })
+	assert.False(t, reported)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestKDBush_VerifyAsyncCancel(t *testing.T) {
+	pairs := make([][2]float64, 1000000)
+	for i := range pairs {
+		pairs[i] = [2]float64{float64(i % 1009), float64(i % 997)}
+	}
+	bush := NewBushFromPairs(pairs, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	//no budget limit, cancellation is still noticed
+	assert.ErrorIs(t, bush.verifyIncremental(ctx, 0), context.Canceled)
+	reported := false
+	<-bush.VerifyAsync(ctx, 0, func(err error) { reported = true })
+	assert.False(t, reported)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	done := bush.VerifyAsync(ctx, 0, func(err error) { reported = true })
+	cancel()
+	<-done
+	assert.False(t, reported)
+}
@@ -0,0 +1,11 @@
+#!/bin/sh
+# Builds kdbush.wasm and copies Go JS runtime support next to it
+set -e
+cd "$(dirname "$0")"
+GOOS=js GOARCH=wasm go build -o kdbush.wasm .
+root="$(go env GOROOT)"
+if [ -f "$root/lib/wasm/wasm_exec.js" ]; then
+	cp "$root/lib/wasm/wasm_exec.js" .
+else
+	cp "$root/misc/wasm/wasm_exec.js" .
+fi
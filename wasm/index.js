@@ -0,0 +1,71 @@
+'use strict';
+
+// Thin wrapper around kdbush.wasm, built by build.sh
+
+const fs = require('fs');
+const path = require('path');
+
+let api;
+
+// Loads and starts the wasm module once, resolves to the module API
+async function init(wasmPath = path.join(__dirname, 'kdbush.wasm')) {
+    if (api) return api;
+    require('./wasm_exec.js');
+    const go = new globalThis.Go();
+    const {instance} = await WebAssembly.instantiate(fs.readFileSync(wasmPath), go.importObject);
+    go.run(instance);
+    api = globalThis.__kdbush;
+    return api;
+}
+
+function call(name, ...args) {
+    if (!api) throw new Error('kdbush: call init() first');
+    const result = api[name](...args);
+    if (result instanceof Error) throw result;
+    return result;
+}
+
+class Index {
+    constructor(handle) {
+        this.handle = handle;
+    }
+
+    // Builds index from interleaved coordinates: x0, y0, x1, y1, ...
+    static build(coords, nodeSize = 64) {
+        return new Index(call('build', Float64Array.from(coords), nodeSize));
+    }
+
+    // Loads index saved by Go KDBush.Save
+    static load(data) {
+        return new Index(call('load', new Uint8Array(data)));
+    }
+
+    // Serializes index in the format of Go KDBush.Save
+    save() {
+        return call('save', this.handle);
+    }
+
+    get size() {
+        return call('size', this.handle);
+    }
+
+    range(minX, minY, maxX, maxY) {
+        return call('range', this.handle, minX, minY, maxX, maxY);
+    }
+
+    within(x, y, radius) {
+        return call('within', this.handle, x, y, radius);
+    }
+
+    // k <= 0 means no limit on number of results, maxDist <= 0 means no limit on distance
+    nearest(x, y, k, maxDist = 0) {
+        return call('nearest', this.handle, x, y, k, maxDist);
+    }
+
+    // Releases memory of the index in the wasm module, the index can't be used afterwards
+    free() {
+        call('free', this.handle);
+    }
+}
+
+module.exports = {init, Index};
@@ -0,0 +1,147 @@
+//go:build js && wasm
+
+// Command wasm exposes kdbush indexes to JavaScript, build it with build.sh and use index.js wrapper.
+//
+// Functions are registered on the global __kdbush object, indexes are referenced by numeric handles:
+//
+//	build(coords Float64Array, nodeSize) handle  - coords are interleaved x, y
+//	load(data Uint8Array) handle                 - index saved by KDBush.Save
+//	save(handle) Uint8Array
+//	range(handle, minX, minY, maxX, maxY) Int32Array
+//	within(handle, x, y, radius) Int32Array
+//	nearest(handle, x, y, k, maxDist) Int32Array
+//	size(handle) number
+//	free(handle)
+//
+// Errors are returned as Error values, the wrapper throws them.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+var (
+	indexes = map[int]*kdbush.KDBush{}
+	next    = 1
+)
+
+func main() {
+	api := js.Global().Get("Object").New()
+	register := func(name string, fn func(args []js.Value) (interface{}, error)) {
+		api.Set(name, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			result, err := fn(args)
+			if err != nil {
+				return js.Global().Get("Error").New(err.Error())
+			}
+			return result
+		}))
+	}
+
+	register("build", func(args []js.Value) (interface{}, error) {
+		if len(args) < 2 || args[0].Get("length").Int()%2 != 0 {
+			return nil, fmt.Errorf("kdbush: build expects interleaved coordinates and node size")
+		}
+		data := make([]byte, args[0].Get("byteLength").Int())
+		js.CopyBytesToGo(data, js.Global().Get("Uint8Array").New(args[0].Get("buffer"), args[0].Get("byteOffset"), len(data)))
+		coords := make([]float64, len(data)/8)
+		for i := range coords {
+			coords[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		return add(kdbush.NewBushFromCoords(coords, args[1].Int())), nil
+	})
+	register("load", func(args []js.Value) (interface{}, error) {
+		data := make([]byte, args[0].Get("length").Int())
+		js.CopyBytesToGo(data, args[0])
+		bush, err := kdbush.Load(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return add(bush), nil
+	})
+	register("save", func(args []js.Value) (interface{}, error) {
+		bush, err := get(args)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := bush.Save(&buf); err != nil {
+			return nil, err
+		}
+		data := js.Global().Get("Uint8Array").New(buf.Len())
+		js.CopyBytesToJS(data, buf.Bytes())
+		return data, nil
+	})
+	register("range", func(args []js.Value) (interface{}, error) {
+		bush, err := get(args)
+		if err != nil {
+			return nil, err
+		}
+		return ids(bush.Range(args[1].Float(), args[2].Float(), args[3].Float(), args[4].Float())), nil
+	})
+	register("within", func(args []js.Value) (interface{}, error) {
+		bush, err := get(args)
+		if err != nil {
+			return nil, err
+		}
+		return ids(bush.Within(&kdbush.SimplePoint{X: args[1].Float(), Y: args[2].Float()}, args[3].Float())), nil
+	})
+	register("nearest", func(args []js.Value) (interface{}, error) {
+		bush, err := get(args)
+		if err != nil {
+			return nil, err
+		}
+		return ids(bush.Nearest(&kdbush.SimplePoint{X: args[1].Float(), Y: args[2].Float()}, args[3].Int(), args[4].Float())), nil
+	})
+	register("size", func(args []js.Value) (interface{}, error) {
+		bush, err := get(args)
+		if err != nil {
+			return nil, err
+		}
+		return len(bush.Idxs), nil
+	})
+	register("free", func(args []js.Value) (interface{}, error) {
+		if _, err := get(args); err != nil {
+			return nil, err
+		}
+		delete(indexes, args[0].Int())
+		return nil, nil
+	})
+
+	js.Global().Set("__kdbush", api)
+	select {}
+}
+
+func add(bush *kdbush.KDBush) int {
+	id := next
+	next++
+	indexes[id] = bush
+	return id
+}
+
+func get(args []js.Value) (*kdbush.KDBush, error) {
+	if len(args) == 0 || args[0].Type() != js.TypeNumber {
+		return nil, fmt.Errorf("kdbush: index handle expected")
+	}
+	bush, ok := indexes[args[0].Int()]
+	if !ok {
+		return nil, fmt.Errorf("kdbush: no index with handle %d", args[0].Int())
+	}
+	return bush, nil
+}
+
+// copies result into Int32Array
+func ids(idxs []int) js.Value {
+	data := make([]byte, 4*len(idxs))
+	for i, idx := range idxs {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(idx))
+	}
+	u8 := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(u8, data)
+	return js.Global().Get("Int32Array").New(u8.Get("buffer"))
+}
@@ -0,0 +1,25 @@
+'use strict';
+
+const assert = require('assert');
+const {init, Index} = require('./index.js');
+
+(async () => {
+    await init();
+    const coords = [10, 10, 15, 11, 1, 22, 22, 22, 34, 12, 19, 19, 32, 34];
+    const index = Index.build(coords, 10);
+    assert.strictEqual(index.size, 7);
+    assert.deepStrictEqual(Array.from(index.range(10, 10, 21, 21)).sort(), [0, 1, 5]);
+    assert.deepStrictEqual(Array.from(index.within(20, 20, 2)), [5]);
+    assert.deepStrictEqual(Array.from(index.nearest(0, 0, 2)), [0, 1]);
+
+    const loaded = Index.load(index.save());
+    assert.deepStrictEqual(Array.from(loaded.range(10, 10, 21, 21)).sort(), [0, 1, 5]);
+
+    index.free();
+    assert.throws(() => index.size, /no index/);
+    assert.throws(() => Index.load(new Uint8Array([1, 2, 3])), /kdbush/);
+    console.log('ok');
+})().catch(err => {
+    console.error(err);
+    process.exit(1);
+});